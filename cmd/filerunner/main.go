@@ -8,6 +8,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("usage: %s <file>", os.Args[0])
+	}
+
 	err := interpreter.RunFile(os.Args[1], os.Stdout)
 	if err != nil {
 		log.Fatalf("failed interpreting the script: %s", err.Error())
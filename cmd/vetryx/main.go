@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/adapter/dap"
+	"github.com/avazquezcode/govetryx/internal/adapter/debug"
+	"github.com/avazquezcode/govetryx/internal/adapter/interpreter"
+	"github.com/avazquezcode/govetryx/internal/adapter/lsp"
+	"github.com/avazquezcode/govetryx/internal/adapter/repl"
+	"github.com/avazquezcode/govetryx/internal/usecase/linter"
+	"github.com/avazquezcode/govetryx/vetryx"
+)
+
+const usage = `Usage: vetryx <command> [arguments]
+
+Commands:
+  run [--backend=tree|vm] [--optimize] <file>  runs a script (by default, with the tree-walking backend), or a compiled program (.vxc)
+      [--profile[=text|pprof]]                 measuring where its time is spent (see below)
+      [--profile-output=<file>] [--top=<n>]
+  build [--optimize] <file> [-o <output>]      compiles a script into a program that can be run later (by default, <file>.vxc)
+  ast [--optimize] <file>                      prints the AST of a script (after the optimizations, if enabled)
+  fmt [--check|--write] <file>...              prints the scripts in their canonical format, lists the ones that
+                                               aren't formatted (--check), or formats them in place (--write)
+  lint [--disable=<rules>] <file>...           reports the code that is probably wrong (see lint --rules)
+  repl                                         starts an interactive session
+  debug <file>                                 debugs a script, with breakpoints and steps (type help once started)
+  lsp                                          starts a language server for the editors (talking LSP through stdio)
+  dap                                          starts a debug adapter for the editors (talking DAP through stdio)
+
+The --optimize flag folds the operations between literals, and removes the code that can't be reached.
+
+The --profile flag runs a script in the tree-walking backend measuring the time spent in each function and line,
+and writes a report with the top ones (--profile or --profile=text, to stderr, with the top 10 unless --top is
+given), or a profile that can be opened by go tool pprof (--profile=pprof, to <file>.pprof). The --profile-output
+flag sets the file where the profile is written.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command given in the arguments, returning the exit code.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "run":
+		return runScript(args[1:], stdout, stderr)
+	case "build":
+		return buildScript(args[1:], stderr)
+	case "ast":
+		return dumpScript(args[1:], stdout, stderr)
+	case "fmt":
+		return formatScripts(args[1:], stdout, stderr)
+	case "lint":
+		return lintScripts(args[1:], stdout, stderr)
+	case "repl":
+		err := repl.New(stdin, stdout, stderr).Run(context.Background())
+		if err != nil {
+			fmt.Fprintf(stderr, "failed running the repl: %s\n", err)
+			return 1
+		}
+	case "debug":
+		return debugScript(args[1:], stdin, stdout, stderr)
+	case "dap":
+		err := dap.New(stdin, stdout, stderr).Run(context.Background())
+		if err != nil {
+			fmt.Fprintf(stderr, "failed running the debug adapter: %s\n", err)
+			return 1
+		}
+	case "lsp":
+		err := lsp.New(stdin, stdout, stderr).Run(context.Background())
+		if err != nil {
+			fmt.Fprintf(stderr, "failed running the language server: %s\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	return 0
+}
+
+// runScript runs a script, or a compiled program.
+func runScript(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	backendName := flags.String("backend", string(vetryx.BackendTree), "")
+	optimize := flags.Bool("optimize", false, "")
+	var profile profileFlag
+	flags.Var(&profile, "profile", "")
+	profileOutput := flags.String("profile-output", "", "")
+	top := flags.Int("top", 10, "")
+	files, err := parseFlags(flags, args)
+	if err != nil || len(files) != 1 || *top <= 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	backend, err := vetryx.ParseBackend(*backendName)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n\n%s", err, usage)
+		return 2
+	}
+
+	if filepath.Ext(files[0]) == vetryx.CompiledExtension && isFlagSet(flags, "backend") && backend != vetryx.BackendVM {
+		fmt.Fprintf(stderr, "the compiled programs can only be run by the %q backend\n", vetryx.BackendVM)
+		return 2
+	}
+
+	if profile != "" {
+		if backend != vetryx.BackendTree {
+			fmt.Fprintf(stderr, "the scripts can only be profiled by the %q backend\n", vetryx.BackendTree)
+			return 2
+		}
+		return profileScript(files[0], string(profile), *profileOutput, *top, *optimize, stdout, stderr)
+	}
+
+	err = interpreter.RunFile(files[0], stdout, vetryx.WithBackend(backend), vetryx.WithOptimizations(*optimize))
+	if err != nil {
+		fmt.Fprintf(stderr, "failed interpreting the script: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// profileFlag is the format of the profile given with --profile (that can be given without a value, like a
+// boolean flag, for the text format).
+type profileFlag string
+
+func (f *profileFlag) String() string {
+	return string(*f)
+}
+
+func (f *profileFlag) Set(value string) error {
+	switch value {
+	case "true":
+		*f = interpreter.ProfileText
+	case interpreter.ProfileText, interpreter.ProfilePprof:
+		*f = profileFlag(value)
+	case "false":
+		*f = ""
+	default:
+		return fmt.Errorf("unknown profile format %q", value)
+	}
+	return nil
+}
+
+func (f *profileFlag) IsBoolFlag() bool {
+	return true
+}
+
+// profileScript runs a script measuring where its time is spent, and writes the profile in the given format
+// (to stderr or <file>.pprof, unless an output is given).
+func profileScript(file string, format string, output string, top int, optimize bool, stdout io.Writer, stderr io.Writer) int {
+	if output == "" && format == interpreter.ProfilePprof {
+		output = strings.TrimSuffix(file, filepath.Ext(file)) + ".pprof"
+	}
+
+	profileOutput := stderr
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed creating the profile: %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		profileOutput = f
+	}
+
+	profile := interpreter.ProfileOptions{Output: profileOutput, Format: format, Top: top}
+	err := interpreter.ProfileFile(file, stdout, profile, vetryx.WithOptimizations(optimize))
+	if err != nil {
+		fmt.Fprintf(stderr, "failed interpreting the script: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildScript compiles a script into a program file.
+func buildScript(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	output := flags.String("o", "", "")
+	optimize := flags.Bool("optimize", false, "")
+	files, err := parseFlags(flags, args)
+	if err != nil || len(files) != 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	if *output == "" {
+		*output = strings.TrimSuffix(files[0], filepath.Ext(files[0])) + vetryx.CompiledExtension
+	}
+
+	err = interpreter.BuildFile(files[0], *output, vetryx.WithOptimizations(*optimize))
+	if err != nil {
+		fmt.Fprintf(stderr, "failed building the script: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// dumpScript prints the AST of a script.
+func dumpScript(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	optimize := flags.Bool("optimize", false, "")
+	files, err := parseFlags(flags, args)
+	if err != nil || len(files) != 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	err = interpreter.DumpFile(files[0], stdout, vetryx.WithOptimizations(*optimize))
+	if err != nil {
+		fmt.Fprintf(stderr, "failed parsing the script: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// formatScripts formats the scripts: by default it prints them formatted, with --check it lists the ones that
+// aren't formatted (failing when there is any), and with --write it formats them in place.
+func formatScripts(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := flags.Bool("check", false, "")
+	write := flags.Bool("write", false, "")
+	files, err := parseFlags(flags, args)
+	if err != nil || len(files) == 0 || (*check && *write) {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	code := 0
+	for _, file := range files {
+		err := formatScript(file, *check, *write, stdout)
+		if errors.Is(err, errNotFormatted) {
+			fmt.Fprintln(stdout, file)
+			code = 1
+			continue
+		}
+
+		if err != nil {
+			fmt.Fprintf(stderr, "failed formatting %s: %s\n", file, err)
+			code = 1
+		}
+	}
+	return code
+}
+
+// errNotFormatted is the error returned when checking a script that isn't formatted.
+var errNotFormatted = errors.New("the script is not formatted")
+
+// formatScript formats a script, in the given mode.
+func formatScript(file string, check bool, write bool, stdout io.Writer) error {
+	if write {
+		return interpreter.WriteFormattedFile(file)
+	}
+
+	formatted, changed, err := interpreter.FormatFile(file)
+	if err != nil {
+		return err
+	}
+
+	if check {
+		if changed {
+			return errNotFormatted
+		}
+		return nil
+	}
+
+	_, err = io.WriteString(stdout, formatted)
+	return err
+}
+
+// lintScripts reports the problems found in the scripts, failing when there is any.
+// With --rules, it lists the rules instead.
+func lintScripts(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	disable := flags.String("disable", "", "")
+	rules := flags.Bool("rules", false, "")
+	files, err := parseFlags(flags, args)
+	if err != nil || (len(files) == 0 && !*rules) {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	if *rules {
+		for _, rule := range linter.Rules {
+			fmt.Fprintf(stdout, "%-22s %-8s %s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		return 0
+	}
+
+	var disabled []string
+	if *disable != "" {
+		disabled = strings.Split(*disable, ",")
+	}
+
+	code := 0
+	for _, file := range files {
+		diagnostics, err := interpreter.LintFile(file, disabled...)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed linting %s: %s\n", file, err)
+			code = 1
+			continue
+		}
+
+		for _, d := range diagnostics {
+			fmt.Fprintf(stdout, "%s:%d: %s: %s (%s)\n", file, d.Line, d.Severity, d.Message, d.Rule)
+			code = 1
+		}
+	}
+	return code
+}
+
+// debugScript debugs a script, reading the commands from stdin.
+func debugScript(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	err := debug.New(stdin, stdout, stderr).Run(context.Background(), args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "failed debugging the script: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseFlags parses the flags of a command, returning its positional arguments.
+// Unlike flags.Parse, the flags can be placed after the positional arguments (eg: "build foo.vx -o foo.vxc").
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	flags.SetOutput(io.Discard)
+
+	var positional []string
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// isFlagSet returns true if the flag was given in the arguments.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.vx")
+	err := os.WriteFile(script, []byte("print 1 + 1;"), 0o600)
+	assert.NoError(t, err)
+
+	unlinted := filepath.Join(dir, "unlinted.vx")
+	err = os.WriteFile(unlinted, []byte("fn f(a) {\n  return 1;\n}\nf();"), 0o600)
+	assert.NoError(t, err)
+
+	compiled := filepath.Join(dir, "compiled.vxc")
+	assert.Equal(t, 0, run([]string{"build", script, "-o", compiled}, nil, io.Discard, io.Discard))
+
+	corrupted := filepath.Join(dir, "corrupted.vxc")
+	data, err := os.ReadFile(compiled)
+	assert.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	err = os.WriteFile(corrupted, data, 0o600)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		args             []string
+		stdin            string
+		expectedCode     int
+		expectedStdout   string
+		expectedInStderr string
+	}{
+		"no command": {
+			args:             nil,
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"unknown command": {
+			args:             []string{"foo"},
+			expectedCode:     2,
+			expectedInStderr: `unknown command "foo"`,
+		},
+		"run without file": {
+			args:             []string{"run"},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"run a file": {
+			args:           []string{"run", script},
+			expectedStdout: "2\n",
+		},
+		"run a file with the vm backend": {
+			args:           []string{"run", "--backend=vm", script},
+			expectedStdout: "2\n",
+		},
+		"run a file with the tree backend": {
+			args:           []string{"run", "--backend", "tree", script},
+			expectedStdout: "2\n",
+		},
+		"run with an unknown backend": {
+			args:             []string{"run", "--backend=jit", script},
+			expectedCode:     2,
+			expectedInStderr: `unknown backend "jit"`,
+		},
+		"run with an unknown flag": {
+			args:             []string{"run", "--foo", script},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"run an optimized file": {
+			args:           []string{"run", script, "--optimize"},
+			expectedStdout: "2\n",
+		},
+		"profile a file": {
+			args:             []string{"run", "--profile", script},
+			expectedStdout:   "2\n",
+			expectedInStderr: "lines (top 10 by self time):",
+		},
+		"profile a file showing the top 3": {
+			args:             []string{"run", script, "--profile=text", "--top=3"},
+			expectedStdout:   "2\n",
+			expectedInStderr: "functions (top 3 by self time):",
+		},
+		"profile with an unknown format": {
+			args:             []string{"run", "--profile=json", script},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"profile with the vm backend": {
+			args:             []string{"run", "--profile", "--backend=vm", script},
+			expectedCode:     2,
+			expectedInStderr: `the scripts can only be profiled by the "tree" backend`,
+		},
+		"profile a compiled program": {
+			args:             []string{"run", "--profile", compiled},
+			expectedCode:     1,
+			expectedInStderr: "the compiled programs can't be profiled",
+		},
+		"dump the ast": {
+			args:           []string{"ast", script},
+			expectedStdout: "(print (+ 1 1))\n",
+		},
+		"dump the optimized ast": {
+			args:           []string{"ast", "--optimize", script},
+			expectedStdout: "(print 2)\n",
+		},
+		"dump without file": {
+			args:             []string{"ast", "--optimize"},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"dump a missing file": {
+			args:             []string{"ast", "missing.vx"},
+			expectedCode:     1,
+			expectedInStderr: "failed parsing the script",
+		},
+		"run a compiled program": {
+			args:           []string{"run", compiled},
+			expectedStdout: "2\n",
+		},
+		"run a compiled program with the tree backend": {
+			args:             []string{"run", "--backend=tree", compiled},
+			expectedCode:     2,
+			expectedInStderr: "can only be run by the \"vm\" backend",
+		},
+		"run a corrupted compiled program": {
+			args:             []string{"run", corrupted},
+			expectedCode:     1,
+			expectedInStderr: "the compiled program is corrupted",
+		},
+		"build without file": {
+			args:             []string{"build", "-o", "out.vxc"},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"build a file with errors": {
+			args:             []string{"build", "missing.vx"},
+			expectedCode:     1,
+			expectedInStderr: "failed building the script",
+		},
+		"format a file": {
+			args:           []string{"fmt", script},
+			expectedStdout: "print 1 + 1;\n",
+		},
+		"check a file that isn't formatted": {
+			args:           []string{"fmt", "--check", script},
+			expectedCode:   1,
+			expectedStdout: script + "\n",
+		},
+		"format without files": {
+			args:             []string{"fmt", "--check"},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"format with both modes": {
+			args:             []string{"fmt", "--check", "--write", script},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"format a missing file": {
+			args:             []string{"fmt", "missing.vx"},
+			expectedCode:     1,
+			expectedInStderr: "failed formatting missing.vx",
+		},
+		"lint a file without problems": {
+			args: []string{"lint", script},
+		},
+		"lint a file with problems": {
+			args:         []string{"lint", unlinted},
+			expectedCode: 1,
+			expectedStdout: unlinted + ":1: warning: the parameter \"a\" is never used (unused-parameter)\n" +
+				unlinted + ":4: error: \"f\" expects 1 argument, but it is called with 0 (wrong-argument-count)\n",
+		},
+		"lint with disabled rules": {
+			args:           []string{"lint", "--disable=unused-parameter", unlinted},
+			expectedCode:   1,
+			expectedStdout: unlinted + ":4: error: \"f\" expects 1 argument, but it is called with 0 (wrong-argument-count)\n",
+		},
+		"lint with an unknown rule": {
+			args:             []string{"lint", "--disable=unknown", script},
+			expectedCode:     1,
+			expectedInStderr: `unknown rule "unknown"`,
+		},
+		"lint without files": {
+			args:             []string{"lint"},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"run a missing file": {
+			args:             []string{"run", "missing.vx"},
+			expectedCode:     1,
+			expectedInStderr: "failed when reading the file",
+		},
+		"debug a file": {
+			args:           []string{"debug", script},
+			stdin:          "c\n",
+			expectedStdout: "debugging " + script + " (type help to see the commands)\nstopped at line 1: print 1 + 1;\n(debug) 2\nthe script ended\n",
+		},
+		"debug without file": {
+			args:             []string{"debug"},
+			expectedCode:     2,
+			expectedInStderr: "Usage: vetryx",
+		},
+		"debug a missing file": {
+			args:             []string{"debug", "missing.vx"},
+			expectedCode:     1,
+			expectedInStderr: "failed debugging the script",
+		},
+		"debug adapter without requests": {
+			args: []string{"dap"},
+		},
+		"repl": {
+			args:           []string{"repl"},
+			stdin:          "1 + 1\n",
+			expectedStdout: ">> 2\n>> ",
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+			assert.Equal(t, test.expectedCode, code)
+			assert.Equal(t, test.expectedStdout, stdout.String())
+			assert.Contains(t, stderr.String(), test.expectedInStderr)
+		})
+	}
+}
+
+func TestBuildWithDefaultOutput(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.vx")
+	err := os.WriteFile(script, []byte("print \"built\";"), 0o600)
+	assert.NoError(t, err)
+
+	var stderr bytes.Buffer
+	code := run([]string{"build", script}, nil, io.Discard, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	var stdout bytes.Buffer
+	code = run([]string{"run", strings.TrimSuffix(script, ".vx") + ".vxc"}, nil, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "built\n", stdout.String())
+}
+
+func TestProfileWithDefaultOutput(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.vx")
+	err := os.WriteFile(script, []byte("fn f() { return 1; }\nprint f();"), 0o600)
+	assert.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"run", "--profile=pprof", script}, nil, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Equal(t, "1\n", stdout.String())
+
+	data, err := os.ReadFile(strings.TrimSuffix(script, ".vx") + ".pprof")
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte{0x1f, 0x8b}), "the profile is gzipped")
+}
+
+func TestFormatInPlace(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.vx")
+	err := os.WriteFile(script, []byte("if (a) {print a;}  # a"), 0o600)
+	assert.NoError(t, err)
+
+	var stderr bytes.Buffer
+	code := run([]string{"fmt", "--write", script}, nil, io.Discard, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+
+	formatted, err := os.ReadFile(script)
+	assert.NoError(t, err)
+	assert.Equal(t, "if a {\n    print a;\n} # a\n", string(formatted))
+
+	var stdout bytes.Buffer
+	code = run([]string{"fmt", "--check", script}, nil, &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,115 @@
+package dap
+
+import "encoding/json"
+
+// threadID is the ID of the only thread of the scripts.
+const threadID = 1
+
+type (
+	// request is a message sent by the client.
+	request struct {
+		Seq       int             `json:"seq"`
+		Type      string          `json:"type"`
+		Command   string          `json:"command"`
+		Arguments json.RawMessage `json:"arguments,omitempty"`
+	}
+
+	// response is the message sent as the result of a request.
+	response struct {
+		Seq        int         `json:"seq"`
+		Type       string      `json:"type"`
+		RequestSeq int         `json:"request_seq"`
+		Success    bool        `json:"success"`
+		Command    string      `json:"command"`
+		Message    string      `json:"message,omitempty"`
+		Body       interface{} `json:"body,omitempty"`
+	}
+
+	// event is a message sent by the server on its own (eg: when the script is paused).
+	event struct {
+		Seq   int         `json:"seq"`
+		Type  string      `json:"type"`
+		Event string      `json:"event"`
+		Body  interface{} `json:"body,omitempty"`
+	}
+)
+
+type (
+	launchArguments struct {
+		Program     string `json:"program"`
+		StopOnEntry bool   `json:"stopOnEntry"`
+	}
+
+	setBreakpointsArguments struct {
+		Source      source `json:"source"`
+		Breakpoints []struct {
+			Line int `json:"line"`
+		} `json:"breakpoints"`
+	}
+
+	stackTraceArguments struct {
+		ThreadID int `json:"threadId"`
+	}
+
+	scopesArguments struct {
+		FrameID int `json:"frameId"`
+	}
+
+	variablesArguments struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+
+	capabilities struct {
+		SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	}
+
+	source struct {
+		Name string `json:"name,omitempty"`
+		Path string `json:"path"`
+	}
+
+	breakpoint struct {
+		Verified bool `json:"verified"`
+		Line     int  `json:"line"`
+	}
+
+	thread struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	stackFrame struct {
+		ID     int    `json:"id"`
+		Name   string `json:"name"`
+		Source source `json:"source"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+	}
+
+	scope struct {
+		Name               string `json:"name"`
+		VariablesReference int    `json:"variablesReference"`
+		Expensive          bool   `json:"expensive"`
+	}
+
+	variable struct {
+		Name               string `json:"name"`
+		Value              string `json:"value"`
+		VariablesReference int    `json:"variablesReference"`
+	}
+
+	stoppedEvent struct {
+		Reason            string `json:"reason"`
+		ThreadID          int    `json:"threadId"`
+		AllThreadsStopped bool   `json:"allThreadsStopped"`
+	}
+
+	outputEvent struct {
+		Category string `json:"category"`
+		Output   string `json:"output"`
+	}
+
+	exitedEvent struct {
+		ExitCode int `json:"exitCode"`
+	}
+)
@@ -0,0 +1,440 @@
+// This package contains the debug adapter of the language, so the editors can debug the scripts: set breakpoints,
+// step through the code, and inspect the active calls and the variables of their scopes.
+// It talks DAP (Debug Adapter Protocol), and the scripts are run by the tree-walking interpreter.
+package dap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/usecase/debugger"
+)
+
+// errNotPaused is the error of the requests that can only be handled while the script is paused.
+var errNotPaused = errors.New("the script is not paused")
+
+// resumeCommand is how a paused script goes on (or the error that stops it).
+type resumeCommand struct {
+	resume debugger.Resume
+	err    error
+}
+
+// Server is a debug adapter, that debugs one script (the one given in the launch request).
+type Server struct {
+	in     *bufio.Reader
+	out    io.Writer
+	errOut io.Writer
+	ctx    context.Context
+
+	writeMu sync.Mutex // the messages are written by the handlers, and by the script (eg: its output)
+	seq     int
+
+	program     string
+	source      string
+	run         func() error // runs the launched script
+	debugger    *debugger.Debugger
+	stopOnEntry bool
+	breakpoints map[string][]int // the breakpoints of each source (by its absolute path), until the launch
+	resumes     chan resumeCommand
+	done        chan struct{} // closed when the script ends (nil until it starts)
+
+	pauseMu    sync.Mutex // the pause is set by the script, and read by the handlers
+	paused     *debugger.Pause
+	references map[int][]debugger.Variable // variables of the scopes sent while paused (by reference)
+}
+
+// New is a constructor for a Server, that reads the messages from in and writes them to out.
+func New(in io.Reader, out io.Writer, errOut io.Writer) *Server {
+	return &Server{
+		in:          bufio.NewReader(in),
+		out:         out,
+		errOut:      errOut,
+		resumes:     make(chan resumeCommand, 1),
+		breakpoints: map[string][]int{},
+	}
+}
+
+// Run serves the requests until the client disconnects (or the input ends), stopping the script if it's running.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	s.ctx = ctx
+	defer func() {
+		cancel()
+		if s.done != nil {
+			<-s.done
+		}
+	}()
+
+	for ctx.Err() == nil {
+		data, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed reading a message: %w", err)
+		}
+
+		var req request
+		err = json.Unmarshal(data, &req)
+		if err != nil {
+			fmt.Fprintf(s.errOut, "failed decoding a message: %s\n", err)
+			continue
+		}
+
+		if req.Command == "disconnect" {
+			cancel()
+			if s.done != nil {
+				<-s.done
+			}
+			return s.respond(req, nil, nil)
+		}
+
+		body, err := s.handle(req)
+		err = s.respond(req, body, err)
+		if err != nil {
+			return err
+		}
+
+		// the events that follow the responses
+		switch req.Command {
+		case "initialize":
+			err = s.sendEvent("initialized", nil)
+		case "configurationDone":
+			s.start()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// handle handles a request, returning the body of its response.
+func (s *Server) handle(req request) (interface{}, error) {
+	switch req.Command {
+	case "initialize":
+		return capabilities{SupportsConfigurationDoneRequest: true}, nil
+	case "launch":
+		var args launchArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.launch(args)
+	case "configurationDone":
+		if s.run == nil {
+			return nil, errors.New("there is no script launched")
+		}
+		return nil, nil
+	case "setBreakpoints":
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.setBreakpoints(args), nil
+	case "threads":
+		return map[string]interface{}{"threads": []thread{{ID: threadID, Name: "main"}}}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		var args scopesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.scopes(args.FrameID)
+	case "variables":
+		var args variablesArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return s.variables(args.VariablesReference)
+	case "next":
+		return nil, s.resume(debugger.Next)
+	case "stepIn":
+		return nil, s.resume(debugger.Step)
+	case "stepOut":
+		return nil, s.resume(debugger.Finish)
+	case "continue":
+		return map[string]interface{}{"allThreadsContinued": true}, s.resume(debugger.Continue)
+	}
+	return nil, fmt.Errorf("unknown command %q", req.Command)
+}
+
+// launch compiles the script (that starts running once the configuration is done).
+func (s *Server) launch(args launchArguments) error {
+	if s.run != nil {
+		return errors.New("a script was already launched")
+	}
+
+	code, err := os.ReadFile(args.Program)
+	if err != nil {
+		return fmt.Errorf("failed when reading the file: %w", err)
+	}
+
+	i, statements, err := debugger.Compile(string(code), args.Program, outputWriter{server: s})
+	if err != nil {
+		return err
+	}
+
+	s.program, s.source, s.stopOnEntry = args.Program, string(code), args.StopOnEntry
+	s.debugger = debugger.New(i, s.pause)
+	if !args.StopOnEntry {
+		s.debugger.SetResume(debugger.Continue)
+	}
+	for _, line := range s.breakpoints[absPath(args.Program)] {
+		s.debugger.SetBreakpoint(line)
+	}
+
+	s.run = func() error {
+		return i.InterpretContext(s.ctx, statements)
+	}
+	return nil
+}
+
+// start runs the launched script in the background, sending the events of its end.
+func (s *Server) start() {
+	if s.done != nil {
+		return
+	}
+
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+
+		exitCode := 0
+		err := s.run()
+		if err != nil && !errors.Is(err, debugger.ErrQuit) && !errors.Is(err, interr.ErrCanceled) {
+			exitCode = 1
+			s.sendEvent("output", outputEvent{Category: "stderr", Output: debugger.WithExcerpt(err, s.source).Error() + "\n"})
+		}
+
+		s.sendEvent("exited", exitedEvent{ExitCode: exitCode})
+		s.sendEvent("terminated", nil)
+	}()
+}
+
+// setBreakpoints replaces the breakpoints of a source. Only the ones of the script can be stopped at, so once it's
+// launched, the breakpoints of the other sources (eg: the files it imports) are not verified, and they don't
+// change the ones of the script.
+func (s *Server) setBreakpoints(args setBreakpointsArguments) interface{} {
+	path := absPath(args.Source.Path)
+	verified := s.debugger == nil || path == absPath(s.program)
+
+	lines := []int{}
+	breakpoints := []breakpoint{}
+	for _, b := range args.Breakpoints {
+		lines = append(lines, b.Line)
+		breakpoints = append(breakpoints, breakpoint{Verified: verified, Line: b.Line})
+	}
+
+	if s.debugger == nil {
+		s.breakpoints[path] = lines
+	} else if verified {
+		s.debugger.ClearBreakpoints()
+		for _, line := range lines {
+			s.debugger.SetBreakpoint(line)
+		}
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}
+}
+
+// absPath returns the absolute path of a file, so the paths of the same file can be compared.
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// pause is called by the debugger (in the goroutine of the script) when the script is paused, and waits until a
+// request resumes it.
+func (s *Server) pause(pause debugger.Pause) (debugger.Resume, error) {
+	s.pauseMu.Lock()
+	first := s.references == nil
+	s.paused = &pause
+	s.references = map[int][]debugger.Variable{}
+	s.pauseMu.Unlock()
+
+	reason := "step"
+	if pause.Breakpoint {
+		reason = "breakpoint"
+	} else if first && s.stopOnEntry {
+		reason = "entry"
+	}
+
+	err := s.sendEvent("stopped", stoppedEvent{Reason: reason, ThreadID: threadID, AllThreadsStopped: true})
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case command := <-s.resumes:
+		return command.resume, command.err
+	case <-s.ctx.Done():
+		return 0, debugger.ErrQuit
+	}
+}
+
+// resume resumes the paused script.
+func (s *Server) resume(resume debugger.Resume) error {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.paused == nil {
+		return errNotPaused
+	}
+
+	s.paused = nil
+	s.resumes <- resumeCommand{resume: resume}
+	return nil
+}
+
+// stackTrace returns the frames of the paused script, from the innermost one.
+func (s *Server) stackTrace() (interface{}, error) {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.paused == nil {
+		return nil, errNotPaused
+	}
+
+	frames := []stackFrame{}
+	for i, frame := range s.paused.Frames {
+		frames = append(frames, stackFrame{
+			ID:     i + 1,
+			Name:   frame.Function,
+			Source: source{Name: filepath.Base(s.program), Path: s.program},
+			Line:   frame.Line,
+			Column: 1,
+		})
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+// scopes returns the scopes of a frame (from the innermost one to the global one).
+func (s *Server) scopes(frameID int) (interface{}, error) {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.paused == nil {
+		return nil, errNotPaused
+	}
+
+	if frameID < 1 || frameID > len(s.paused.Frames) {
+		return nil, fmt.Errorf("unknown frame %d", frameID)
+	}
+
+	scopes := []scope{}
+	for i, sc := range debugger.Scopes(s.paused.Frames[frameID-1].Env) {
+		name := "Locals"
+		if sc.Global {
+			name = "Globals"
+		} else if i > 0 {
+			name = "Enclosing locals"
+		}
+
+		reference := len(s.references) + 1
+		s.references[reference] = sc.Variables
+		scopes = append(scopes, scope{Name: name, VariablesReference: reference})
+	}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+// variables returns the variables of a scope.
+func (s *Server) variables(reference int) (interface{}, error) {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if s.paused == nil {
+		return nil, errNotPaused
+	}
+
+	vars, exists := s.references[reference]
+	if !exists {
+		return nil, fmt.Errorf("unknown variables reference %d", reference)
+	}
+
+	variables := []variable{}
+	for _, v := range vars {
+		variables = append(variables, variable{Name: v.Name, Value: v.Value})
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+// outputWriter sends the output of the script to the client.
+type outputWriter struct {
+	server *Server
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	err := w.server.sendEvent("output", outputEvent{Category: "stdout", Output: string(p)})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// respond sends the response of a request (that failed if err is not nil).
+func (s *Server) respond(req request, body interface{}, err error) error {
+	resp := response{Type: "response", RequestSeq: req.Seq, Command: req.Command, Success: err == nil, Body: body}
+	if err != nil {
+		resp.Message = err.Error()
+		resp.Body = nil
+	}
+	return s.write(func(seq int) interface{} {
+		resp.Seq = seq
+		return resp
+	})
+}
+
+// sendEvent sends an event.
+func (s *Server) sendEvent(name string, body interface{}) error {
+	return s.write(func(seq int) interface{} {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+// read reads the content of the next message (that follows its headers).
+func (s *Server) read() ([]byte, error) {
+	headers, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(headers) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", headers.Get("Content-Length"))
+	}
+
+	data := make([]byte, length)
+	_, err = io.ReadFull(s.in, data)
+	return data, err
+}
+
+// write writes a message (with its headers), built with the next sequence number.
+func (s *Server) write(message func(seq int) interface{}) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+	data, err := json.Marshal(message(s.seq))
+	if err != nil {
+		return fmt.Errorf("failed encoding a message: %w", err)
+	}
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	if err != nil {
+		return fmt.Errorf("failed writing a message: %w", err)
+	}
+	return nil
+}
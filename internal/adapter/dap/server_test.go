@@ -0,0 +1,326 @@
+package dap_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/avazquezcode/govetryx/internal/adapter/dap"
+	"github.com/stretchr/testify/assert"
+)
+
+const script = `fn inner(n) {
+    dec twice = n * 2;
+    return twice;
+}
+fn outer(a) {
+    dec b = inner(a);
+    return b + 1;
+}
+dec x = "a";
+print outer(2);
+print x;`
+
+// message is a message sent by the server (a response or an event).
+type message struct {
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Command    string          `json:"command"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client is a scripted DAP client, that sends the requests and waits for the messages of the server.
+type client struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan message
+	seq      int
+	done     chan error
+}
+
+func newClient(t *testing.T) *client {
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	c := &client{t: t, in: clientOut, messages: make(chan message, 100), done: make(chan error, 1)}
+	go func() {
+		c.done <- dap.New(serverIn, serverOut, io.Discard).Run(context.Background())
+		serverOut.Close()
+	}()
+
+	go func() {
+		defer close(c.messages)
+		reader := bufio.NewReader(clientIn)
+		for {
+			headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+			if err != nil {
+				return
+			}
+			length, _ := strconv.Atoi(headers.Get("Content-Length"))
+			data := make([]byte, length)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+
+			var m message
+			assert.NoError(t, json.Unmarshal(data, &m))
+			c.messages <- m
+		}
+	}()
+	return c
+}
+
+// request sends a request, and returns its response (the events received before it are checked with events).
+func (c *client) request(command string, arguments string, events ...string) message {
+	c.seq++
+	data := fmt.Sprintf(`{"seq":%d,"type":"request","command":%q,"arguments":%s}`, c.seq, command, arguments)
+	_, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	assert.NoError(c.t, err)
+
+	for {
+		m := c.next()
+		if m.Type == "response" && m.RequestSeq == c.seq {
+			assert.Equal(c.t, command, m.Command)
+			return m
+		}
+	}
+}
+
+// event waits for an event, returning its body.
+func (c *client) event(name string) json.RawMessage {
+	for {
+		m := c.next()
+		if m.Type == "event" && m.Event == name {
+			return m.Body
+		}
+	}
+}
+
+func (c *client) next() message {
+	select {
+	case m, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the server closed the connection")
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for a message")
+	}
+	return message{}
+}
+
+func TestServer(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "script.vx")
+	err := os.WriteFile(program, []byte(script), 0o600)
+	assert.NoError(t, err)
+
+	c := newClient(t)
+	resp := c.request("initialize", `{"adapterID":"vetryx","linesStartAt1":true}`)
+	assert.True(t, resp.Success)
+	assert.JSONEq(t, `{"supportsConfigurationDoneRequest":true}`, string(resp.Body))
+	c.event("initialized")
+
+	resp = c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[{"line":2}]}`, program))
+	assert.JSONEq(t, `{"breakpoints":[{"verified":true,"line":2}]}`, string(resp.Body))
+
+	resp = c.request("launch", fmt.Sprintf(`{"program":%q}`, program))
+	assert.True(t, resp.Success)
+
+	resp = c.request("stackTrace", `{"threadId":1}`)
+	assert.False(t, resp.Success)
+	assert.Equal(t, "the script is not paused", resp.Message)
+
+	c.request("configurationDone", `{}`)
+	assert.JSONEq(t, `{"reason":"breakpoint","threadId":1,"allThreadsStopped":true}`, string(c.event("stopped")))
+
+	resp = c.request("threads", `{}`)
+	assert.JSONEq(t, `{"threads":[{"id":1,"name":"main"}]}`, string(resp.Body))
+
+	resp = c.request("stackTrace", `{"threadId":1}`)
+	assert.JSONEq(t, fmt.Sprintf(`{"totalFrames":3,"stackFrames":[
+		{"id":1,"name":"inner","source":{"name":"script.vx","path":%[1]q},"line":2,"column":1},
+		{"id":2,"name":"outer","source":{"name":"script.vx","path":%[1]q},"line":6,"column":1},
+		{"id":3,"name":"<script>","source":{"name":"script.vx","path":%[1]q},"line":10,"column":1}
+	]}`, program), string(resp.Body))
+
+	resp = c.request("scopes", `{"frameId":2}`)
+	assert.JSONEq(t, `{"scopes":[
+		{"name":"Locals","variablesReference":1,"expensive":false},
+		{"name":"Globals","variablesReference":2,"expensive":false}
+	]}`, string(resp.Body))
+
+	resp = c.request("variables", `{"variablesReference":1}`)
+	assert.JSONEq(t, `{"variables":[{"name":"a","value":"2","variablesReference":0}]}`, string(resp.Body))
+
+	resp = c.request("variables", `{"variablesReference":2}`)
+	assert.JSONEq(t, `{"variables":[
+		{"name":"inner","value":"<fn inner>","variablesReference":0},
+		{"name":"outer","value":"<fn outer>","variablesReference":0},
+		{"name":"x","value":"\"a\"","variablesReference":0}
+	]}`, string(resp.Body))
+
+	resp = c.request("variables", `{"variablesReference":3}`)
+	assert.False(t, resp.Success)
+
+	c.request("next", `{"threadId":1}`)
+	assert.JSONEq(t, `{"reason":"step","threadId":1,"allThreadsStopped":true}`, string(c.event("stopped")))
+	assert.Equal(t, 3, c.line())
+
+	c.request("stepOut", `{"threadId":1}`)
+	c.event("stopped")
+	assert.Equal(t, 7, c.line())
+
+	c.request("stepIn", `{"threadId":1}`)
+	assert.JSONEq(t, `{"category":"stdout","output":"5\n"}`, string(c.event("output")))
+	c.event("stopped")
+	assert.Equal(t, 11, c.line())
+
+	resp = c.request("continue", `{"threadId":1}`)
+	assert.JSONEq(t, `{"allThreadsContinued":true}`, string(resp.Body))
+	assert.JSONEq(t, `{"category":"stdout","output":"a\n"}`, string(c.event("output")))
+	assert.JSONEq(t, `{"exitCode":0}`, string(c.event("exited")))
+	c.event("terminated")
+
+	resp = c.request("next", `{"threadId":1}`)
+	assert.False(t, resp.Success)
+
+	resp = c.request("disconnect", `{}`)
+	assert.True(t, resp.Success)
+	assert.NoError(t, <-c.done)
+}
+
+func TestServerStopsOnEntry(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "script.vx")
+	err := os.WriteFile(program, []byte("dec a = 1;\nprint a / 0;"), 0o600)
+	assert.NoError(t, err)
+
+	c := newClient(t)
+	c.request("initialize", `{}`)
+	c.request("launch", fmt.Sprintf(`{"program":%q,"stopOnEntry":true}`, program))
+	c.request("configurationDone", `{}`)
+	assert.JSONEq(t, `{"reason":"entry","threadId":1,"allThreadsStopped":true}`, string(c.event("stopped")))
+	assert.Equal(t, 1, c.line())
+
+	c.request("continue", `{"threadId":1}`)
+	assert.JSONEq(t, `{"category":"stderr","output":"runtime error occurred at line 2: division per zero\n2 | print a / 0;\n  |       ^^^^^\n"}`, string(c.event("output")))
+	assert.JSONEq(t, `{"exitCode":1}`, string(c.event("exited")))
+
+	c.request("disconnect", `{}`)
+	assert.NoError(t, <-c.done)
+}
+
+func TestServerStopsInEachIterationOfALoop(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "loop.vx")
+	err := os.WriteFile(program, []byte("dec i = 0;\nwhile i < 3 {\n    i = i + 1;\n}\nprint i;"), 0o600)
+	assert.NoError(t, err)
+
+	c := newClient(t)
+	c.request("initialize", `{}`)
+	c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[{"line":3}]}`, program))
+	c.request("launch", fmt.Sprintf(`{"program":%q}`, program))
+	c.request("configurationDone", `{}`)
+	assert.JSONEq(t, `{"reason":"breakpoint","threadId":1,"allThreadsStopped":true}`, string(c.event("stopped")))
+	assert.Equal(t, 3, c.line())
+
+	c.request("continue", `{"threadId":1}`)
+	assert.JSONEq(t, `{"reason":"breakpoint","threadId":1,"allThreadsStopped":true}`, string(c.event("stopped")))
+	assert.Equal(t, 3, c.line())
+
+	c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[]}`, program))
+	c.request("next", `{"threadId":1}`)
+	assert.JSONEq(t, `{"reason":"step","threadId":1,"allThreadsStopped":true}`, string(c.event("stopped")))
+	assert.Equal(t, 3, c.line())
+
+	c.request("next", `{"threadId":1}`)
+	c.event("stopped")
+	assert.Equal(t, 5, c.line())
+
+	c.request("continue", `{"threadId":1}`)
+	assert.JSONEq(t, `{"category":"stdout","output":"3\n"}`, string(c.event("output")))
+	assert.JSONEq(t, `{"exitCode":0}`, string(c.event("exited")))
+
+	c.request("disconnect", `{}`)
+	assert.NoError(t, <-c.done)
+}
+
+func TestServerKeepsTheBreakpointsOfEachSource(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "main.vx")
+	err := os.WriteFile(program, []byte("import \"lib.vx\" as lib;\nprint 1;\nprint 2;"), 0o600)
+	assert.NoError(t, err)
+	module := filepath.Join(dir, "lib.vx")
+	err = os.WriteFile(module, []byte("dec a = 1;\ndec b = 2;"), 0o600)
+	assert.NoError(t, err)
+
+	c := newClient(t)
+	c.request("initialize", `{}`)
+	c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[{"line":2}]}`, program))
+	c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[{"line":1}]}`, module))
+	c.request("launch", fmt.Sprintf(`{"program":%q}`, program))
+
+	resp := c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[{"line":3}]}`, module))
+	assert.JSONEq(t, `{"breakpoints":[{"verified":false,"line":3}]}`, string(resp.Body))
+
+	c.request("configurationDone", `{}`)
+	assert.JSONEq(t, `{"reason":"breakpoint","threadId":1,"allThreadsStopped":true}`, string(c.event("stopped")))
+	assert.Equal(t, 2, c.line())
+
+	resp = c.request("setBreakpoints", fmt.Sprintf(`{"source":{"path":%q},"breakpoints":[{"line":3}]}`, program))
+	assert.JSONEq(t, `{"breakpoints":[{"verified":true,"line":3}]}`, string(resp.Body))
+
+	c.request("continue", `{"threadId":1}`)
+	c.event("stopped")
+	assert.Equal(t, 3, c.line())
+
+	c.request("continue", `{"threadId":1}`)
+	assert.JSONEq(t, `{"exitCode":0}`, string(c.event("exited")))
+
+	c.request("disconnect", `{}`)
+	assert.NoError(t, <-c.done)
+}
+
+func TestServerDisconnectsWhilePaused(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "script.vx")
+	err := os.WriteFile(program, []byte("print 1;\nprint 2;"), 0o600)
+	assert.NoError(t, err)
+
+	c := newClient(t)
+	c.request("initialize", `{}`)
+	resp := c.request("launch", `{"program":"missing.vx"}`)
+	assert.False(t, resp.Success)
+	assert.Contains(t, resp.Message, "failed when reading the file")
+
+	c.request("launch", fmt.Sprintf(`{"program":%q,"stopOnEntry":true}`, program))
+	c.request("configurationDone", `{}`)
+	c.event("stopped")
+
+	resp = c.request("foo", `{}`)
+	assert.False(t, resp.Success)
+	assert.Equal(t, `unknown command "foo"`, resp.Message)
+
+	c.request("disconnect", `{}`)
+	assert.NoError(t, <-c.done)
+}
+
+// line returns the line where the script is paused.
+func (c *client) line() int {
+	var body struct {
+		StackFrames []struct {
+			Line int `json:"line"`
+		} `json:"stackFrames"`
+	}
+	resp := c.request("stackTrace", `{"threadId":1}`)
+	assert.NoError(c.t, json.Unmarshal(resp.Body, &body))
+	return body.StackFrames[0].Line
+}
@@ -0,0 +1,179 @@
+// This package contains the interactive debugger of the language, that reads the commands from the user each time
+// the script is paused.
+package debug
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/debugger"
+)
+
+const prompt = "(debug) "
+
+const help = `Commands:
+  break <line>  (b)  sets a breakpoint in a line
+  clear <line>       removes the breakpoint of a line
+  step          (s)  runs until the next line (entering the calls)
+  next          (n)  runs until the next line of the current function (stepping over the calls)
+  finish        (f)  runs until the current function returns
+  continue      (c)  runs until the next breakpoint
+  backtrace     (bt) prints the active calls
+  locals        (l)  prints the variables of the current scope, and of the ones that enclose it
+  help          (h)  shows this help
+  quit          (q)  stops the script
+`
+
+// Session is a debugging session of a script, where the commands are read from the input.
+type Session struct {
+	in     *bufio.Scanner
+	out    io.Writer
+	errOut io.Writer
+	lines  []string // lines of the script (shown when it's paused)
+
+	debugger *debugger.Debugger
+}
+
+// New is a constructor for a Session.
+func New(in io.Reader, out io.Writer, errOut io.Writer) *Session {
+	return &Session{
+		in:     bufio.NewScanner(in),
+		out:    out,
+		errOut: errOut,
+	}
+}
+
+// Run debugs a script, pausing it before its first statement.
+// The output of the script is written to the same output as the debugger.
+func (s *Session) Run(ctx context.Context, path string) error {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed when reading the file: %w", err)
+	}
+	source := string(code)
+	s.lines = strings.Split(source, "\n")
+
+	i, statements, err := debugger.Compile(source, path, s.out)
+	if err != nil {
+		return err
+	}
+
+	s.debugger = debugger.New(i, s.pause)
+	fmt.Fprintf(s.out, "debugging %s (type help to see the commands)\n", path)
+
+	err = i.InterpretContext(ctx, statements)
+	if errors.Is(err, debugger.ErrQuit) {
+		return nil
+	}
+	if err != nil {
+		return debugger.WithExcerpt(err, source)
+	}
+
+	fmt.Fprintln(s.out, "the script ended")
+	return nil
+}
+
+// pause shows where the script is paused, and reads the commands until one of them resumes it.
+func (s *Session) pause(pause debugger.Pause) (debugger.Resume, error) {
+	reason := "stopped"
+	if pause.Breakpoint {
+		reason = "breakpoint"
+	}
+	fmt.Fprintf(s.out, "%s at line %d: %s\n", reason, pause.Line, s.line(pause.Line))
+
+	for {
+		fmt.Fprint(s.out, prompt)
+		if !s.in.Scan() {
+			// the input ended
+			return 0, debugger.ErrQuit
+		}
+
+		fields := strings.Fields(s.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		resume, resumed, err := s.command(fields, pause)
+		if resumed || err != nil {
+			return resume, err
+		}
+	}
+}
+
+// command executes a command, returning how the script is resumed (if the command resumes it).
+func (s *Session) command(fields []string, pause debugger.Pause) (debugger.Resume, bool, error) {
+	switch fields[0] {
+	case "step", "s":
+		return debugger.Step, true, nil
+	case "next", "n":
+		return debugger.Next, true, nil
+	case "finish", "f":
+		return debugger.Finish, true, nil
+	case "continue", "c":
+		return debugger.Continue, true, nil
+	case "quit", "q":
+		return 0, false, debugger.ErrQuit
+	case "break", "b", "clear":
+		line, err := s.lineArgument(fields)
+		if err != nil {
+			fmt.Fprintln(s.errOut, err)
+			break
+		}
+
+		if fields[0] == "clear" {
+			s.debugger.ClearBreakpoint(line)
+			fmt.Fprintf(s.out, "breakpoint cleared at line %d\n", line)
+			break
+		}
+		s.debugger.SetBreakpoint(line)
+		fmt.Fprintf(s.out, "breakpoint set at line %d\n", line)
+	case "backtrace", "bt":
+		for i, frame := range pause.Frames {
+			fmt.Fprintf(s.out, "#%d %s at line %d\n", i, frame.Function, frame.Line)
+		}
+	case "locals", "l":
+		for _, scope := range debugger.Scopes(pause.Frames[0].Env) {
+			if scope.Global {
+				fmt.Fprintln(s.out, "globals:")
+			} else {
+				fmt.Fprintln(s.out, "locals:")
+			}
+
+			for _, variable := range scope.Variables {
+				fmt.Fprintf(s.out, "  %s = %s\n", variable.Name, variable.Value)
+			}
+		}
+	case "help", "h":
+		fmt.Fprint(s.out, help)
+	default:
+		fmt.Fprintf(s.errOut, "unknown command %q (type help to see the commands)\n", fields[0])
+	}
+	return 0, false, nil
+}
+
+// lineArgument returns the line given as argument of a command.
+func (s *Session) lineArgument(fields []string) (int, error) {
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("usage: %s <line>", fields[0])
+	}
+
+	line, err := strconv.Atoi(fields[1])
+	if err != nil || line < 1 || line > len(s.lines) {
+		return 0, fmt.Errorf("invalid line %q", fields[1])
+	}
+	return line, nil
+}
+
+// line returns the code of a line of the script.
+func (s *Session) line(n int) string {
+	if n < 1 || n > len(s.lines) {
+		return ""
+	}
+	return strings.TrimSpace(s.lines[n-1])
+}
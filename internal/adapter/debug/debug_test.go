@@ -0,0 +1,146 @@
+package debug_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/adapter/debug"
+	"github.com/stretchr/testify/assert"
+)
+
+const script = `fn inner(n) {
+    dec twice = n * 2;
+    return twice;
+}
+fn outer(a) {
+    dec b = inner(a);
+    return b + 1;
+}
+dec x = "a";
+print outer(2);
+try {
+    print outer(3);
+} catch (e) {
+    print e;
+}`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "script.vx")
+	err := os.WriteFile(path, []byte(script), 0o600)
+	assert.NoError(t, err)
+
+	broken := filepath.Join(dir, "broken.vx")
+	err = os.WriteFile(broken, []byte("dec a = 1;\nprint a / 0;"), 0o600)
+	assert.NoError(t, err)
+
+	loop := filepath.Join(dir, "loop.vx")
+	err = os.WriteFile(loop, []byte("dec i = 0;\nwhile i < 3 {\n    i = i + 1;\n}\nprint i;"), 0o600)
+	assert.NoError(t, err)
+
+	header := "debugging " + path + " (type help to see the commands)\nstopped at line 1: fn inner(n) {\n(debug) "
+	loopHeader := "debugging " + loop + " (type help to see the commands)\nstopped at line 1: dec i = 0;\n(debug) "
+
+	tests := map[string]struct {
+		path           string
+		input          string
+		expectedStdout string
+		expectedStderr string
+		expectedErr    string
+	}{
+		"continue until the end": {
+			input:          "c\n",
+			expectedStdout: header + "5\n7\nthe script ended\n",
+		},
+		"steps": {
+			input: "s\ns\ns\ns\ns\ns\nq\n",
+			expectedStdout: header +
+				"stopped at line 5: fn outer(a) {\n(debug) " +
+				"stopped at line 9: dec x = \"a\";\n(debug) " +
+				"stopped at line 10: print outer(2);\n(debug) " +
+				"stopped at line 6: dec b = inner(a);\n(debug) " +
+				"stopped at line 2: dec twice = n * 2;\n(debug) " +
+				"stopped at line 3: return twice;\n(debug) ",
+		},
+		"breakpoints, backtrace and locals": {
+			input: "break 2\nc\nbt\nl\nc\nclear 2\nc\n",
+			expectedStdout: header + "breakpoint set at line 2\n(debug) " +
+				"breakpoint at line 2: dec twice = n * 2;\n(debug) " +
+				"#0 inner at line 2\n#1 outer at line 6\n#2 <script> at line 10\n(debug) " +
+				"locals:\n  n = 2\nglobals:\n  inner = <fn inner>\n  outer = <fn outer>\n  x = \"a\"\n(debug) " +
+				"5\nbreakpoint at line 2: dec twice = n * 2;\n(debug) " +
+				"breakpoint cleared at line 2\n(debug) 7\nthe script ended\n",
+		},
+		"next steps over the calls": {
+			input:          "b 6\nc\nn\nn\nq\n",
+			expectedStdout: header + "breakpoint set at line 6\n(debug) breakpoint at line 6: dec b = inner(a);\n(debug) stopped at line 7: return b + 1;\n(debug) 5\nstopped at line 11: try {\n(debug) ",
+		},
+		"finish steps out of the calls": {
+			input:          "b 3\nc\nf\nf\nq\n",
+			expectedStdout: header + "breakpoint set at line 3\n(debug) breakpoint at line 3: return twice;\n(debug) stopped at line 7: return b + 1;\n(debug) 5\nstopped at line 11: try {\n(debug) ",
+		},
+		"breakpoints in a loop stop in each iteration": {
+			path:  loop,
+			input: "b 3\nc\nc\nl\nc\nc\n",
+			expectedStdout: loopHeader + "breakpoint set at line 3\n(debug) " +
+				"breakpoint at line 3: i = i + 1;\n(debug) " +
+				"breakpoint at line 3: i = i + 1;\n(debug) locals:\nglobals:\n  i = 1\n(debug) " +
+				"breakpoint at line 3: i = i + 1;\n(debug) 3\nthe script ended\n",
+		},
+		"steps in a loop stop in each iteration": {
+			path:  loop,
+			input: "s\ns\ns\nl\ns\ns\ns\n",
+			expectedStdout: loopHeader +
+				"stopped at line 2: while i < 3 {\n(debug) " +
+				"stopped at line 3: i = i + 1;\n(debug) " +
+				"stopped at line 3: i = i + 1;\n(debug) locals:\nglobals:\n  i = 1\n(debug) " +
+				"stopped at line 3: i = i + 1;\n(debug) " +
+				"stopped at line 5: print i;\n(debug) 3\nthe script ended\n",
+		},
+		"the quit can't be caught": {
+			input:          "b 12\nc\nq\n",
+			expectedStdout: header + "breakpoint set at line 12\n(debug) 5\nbreakpoint at line 12: print outer(3);\n(debug) ",
+		},
+		"the end of the input quits": {
+			input:          "",
+			expectedStdout: header,
+		},
+		"invalid commands": {
+			input:          "foo\nb\nb 100\nc\n",
+			expectedStdout: header + "(debug) (debug) (debug) 5\n7\nthe script ended\n",
+			expectedStderr: "unknown command \"foo\" (type help to see the commands)\nusage: b <line>\ninvalid line \"100\"\n",
+		},
+		"runtime error": {
+			path:        broken,
+			input:       "c\n",
+			expectedErr: "division per zero\n2 | print a / 0;\n  |       ^^^^^",
+		},
+		"missing file": {
+			path:        filepath.Join(dir, "missing.vx"),
+			expectedErr: "failed when reading the file",
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			if test.path == "" {
+				test.path = path
+			}
+
+			var stdout, stderr bytes.Buffer
+			err := debug.New(strings.NewReader(test.input), &stdout, &stderr).Run(context.Background(), test.path)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStdout, stdout.String())
+			assert.Equal(t, test.expectedStderr, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/formatter"
+)
+
+// FormatFile returns the code of a script in its canonical format, and whether it differs from the current one.
+func FormatFile(path string) (string, bool, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed when reading the file: %w", err)
+	}
+
+	formatted, err := formatter.Format(string(code))
+	if err != nil {
+		return "", false, err
+	}
+
+	return formatted, formatted != string(code), nil
+}
+
+// WriteFormattedFile formats a script in place (the file is only written when its format changes).
+func WriteFormattedFile(path string) error {
+	formatted, changed, err := FormatFile(path)
+	if err != nil || !changed {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed when reading the file: %w", err)
+	}
+
+	err = os.WriteFile(path, []byte(formatted), info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed when writing the file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/linter"
+)
+
+// LintFile returns the problems found in a script, by all the rules except the disabled ones.
+func LintFile(path string, disabled ...string) ([]linter.Diagnostic, error) {
+	l, err := linter.NewLinter(disabled...)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed when reading the file: %w", err)
+	}
+
+	return l.Lint(string(code))
+}
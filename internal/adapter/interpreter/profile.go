@@ -0,0 +1,63 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/profiler"
+	"github.com/avazquezcode/govetryx/vetryx"
+)
+
+// The formats of the profiles.
+const (
+	ProfileText  = "text"  // a report with the functions and the lines where the most time was spent
+	ProfilePprof = "pprof" // a profile that can be opened by "go tool pprof"
+)
+
+// ProfileOptions indicates how ProfileFile writes the profile.
+type ProfileOptions struct {
+	Output io.Writer
+	Format string // ProfileText or ProfilePprof
+	Top    int    // max quantity of functions and lines of the text report
+}
+
+// ProfileFile runs a script (in the tree backend) measuring where it spends its time, with the given options applied
+// on top of the defaults. The profile is written even when the script fails at runtime.
+func ProfileFile(path string, stdout io.Writer, profile ProfileOptions, opts ...vetryx.Option) error {
+	if filepath.Ext(path) == vetryx.CompiledExtension {
+		return fmt.Errorf("the compiled programs can't be profiled (only the scripts run by the %q backend)", vetryx.BackendTree)
+	}
+
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed when reading the file: %w", err)
+	}
+
+	p := profiler.New(time.Now)
+	opts = append([]vetryx.Option{vetryx.WithStdout(stdout), vetryx.WithStdin(os.Stdin), vetryx.WithBaseDir(filepath.Dir(path)), vetryx.WithMainFile(path)}, opts...)
+	runtime := vetryx.NewRuntime(append(opts, vetryx.WithBackend(vetryx.BackendTree), vetryx.WithProfiler(p))...)
+	program, err := runtime.Compile(string(code))
+	if err != nil {
+		return err
+	}
+
+	runErr := runtime.Run(context.Background(), program)
+
+	var writeErr error
+	switch profile.Format {
+	case ProfilePprof:
+		writeErr = p.Profile().WritePprof(profile.Output, path)
+	default:
+		writeErr = p.Profile().WriteTop(profile.Output, profile.Top, string(code))
+	}
+	if writeErr != nil {
+		writeErr = fmt.Errorf("failed when writing the profile: %w", writeErr)
+	}
+
+	return errors.Join(runErr, writeErr)
+}
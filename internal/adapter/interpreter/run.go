@@ -2,50 +2,91 @@ package interpreter
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
-	interpreterpkg "github.com/avazquezcode/govetryx/internal/usecase/interpreter"
-	"github.com/avazquezcode/govetryx/internal/usecase/parser"
-	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+	"github.com/avazquezcode/govetryx/vetryx"
 )
 
 // runCode triggers the interpreter to run the code.
-func runCode(code []rune, stdout io.Writer) error {
-	interpreter := interpreterpkg.NewInterpreter(stdout)
-	s := scanner.NewScanner(code)
-	tokens, err := s.Scan()
+func runCode(code string, opts ...vetryx.Option) error {
+	runtime := vetryx.NewRuntime(opts...)
+	program, err := runtime.Compile(code)
 	if err != nil {
-		return fmt.Errorf("failed on the lexer layer: %w", err)
+		return err
 	}
 
-	p := parser.NewParser(tokens)
-	statements, err := p.Parse()
+	return runtime.Run(context.Background(), program)
+}
+
+// RunFile runs a script, with the given options (eg: the backend) applied on top of the defaults.
+// The compiled programs (.vxc files) are loaded and run by the vm backend.
+func RunFile(path string, stdout io.Writer, opts ...vetryx.Option) error {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed when reading the file: %w", err)
+	}
+
+	opts = append([]vetryx.Option{vetryx.WithStdout(stdout), vetryx.WithStdin(os.Stdin), vetryx.WithBaseDir(filepath.Dir(path)), vetryx.WithMainFile(path)}, opts...)
+	if filepath.Ext(path) != vetryx.CompiledExtension {
+		return runCode(string(code), opts...)
+	}
+
+	runtime := vetryx.NewRuntime(append(opts, vetryx.WithBackend(vetryx.BackendVM))...)
+	program, err := runtime.Load(code)
 	if err != nil {
 		return err
 	}
 
-	resolver := interpreterpkg.NewResolver(interpreter)
-	err = resolver.Resolve(statements)
+	return runtime.Run(context.Background(), program)
+}
+
+// BuildFile compiles a script into bytecode, and writes it to the output file (in the format loaded by RunFile).
+func BuildFile(path string, output string, opts ...vetryx.Option) error {
+	code, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed resolving the statements: %w", err)
+		return fmt.Errorf("failed when reading the file: %w", err)
 	}
 
-	return interpreter.Interpret(statements)
+	program, err := vetryx.NewRuntime(append(opts, vetryx.WithBackend(vetryx.BackendVM))...).Compile(string(code))
+	if err != nil {
+		return err
+	}
+
+	data, err := program.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(output, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed when writing the file: %w", err)
+	}
+	return nil
 }
 
-func RunFile(path string, stdout io.Writer) error {
+// DumpFile compiles a script (without running it), and writes its AST to stdout (see vetryx.Program.Dump).
+func DumpFile(path string, stdout io.Writer, opts ...vetryx.Option) error {
 	code, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed when reading the file: %w", err)
 	}
-	return runCode(bytes.Runes(code), stdout)
+
+	program, err := vetryx.NewRuntime(append(opts, vetryx.WithBaseDir(filepath.Dir(path)))...).Compile(string(code))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(stdout, program.Dump())
+	return err
 }
 
 func RunCode(code string) (string, error) {
 	var stdout bytes.Buffer
-	err := runCode(bytes.Runes([]byte(code)), &stdout)
+	err := runCode(code, vetryx.WithStdout(&stdout))
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,298 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+)
+
+// document is a file opened in the editor, that is analyzed each time it changes.
+type document struct {
+	uri         string
+	text        string
+	lines       []int // offset where each line starts
+	statements  []ast.Statement
+	symbols     *interpreter.Symbols  // nil when the code can't be parsed
+	globals     []*interpreter.Symbol // the globals of the last version that could be parsed (used to complete)
+	diagnostics []diagnostic
+}
+
+// newDocument is a constructor for an analyzed document.
+func newDocument(uri string, text string) *document {
+	d := &document{uri: uri}
+	d.update(text)
+	return d
+}
+
+// update replaces the text of the document, and analyzes it again (with the scanner, the parser and the resolver).
+func (d *document) update(text string) {
+	d.text = text
+	d.lines = []int{0}
+	for i, char := range text {
+		if char == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+
+	d.statements = nil
+	d.symbols = nil
+	d.diagnostics = []diagnostic{}
+
+	tokens, err := scanner.NewScanner(bytes.Runes([]byte(text))).Scan()
+	var lexingErr *scanner.LexingErr
+	if errors.As(err, &lexingErr) {
+		for i, err := range lexingErr.Errors() {
+			// the errors of the scanner are prefixed with their position (that is already in the diagnostic)
+			if unwrapped := errors.Unwrap(err); unwrapped != nil {
+				err = unwrapped
+			}
+			d.report(lexingErr.Spans()[i], err.Error())
+		}
+		return
+	}
+
+	statements, err := parser.NewParser(tokens).Parse()
+	var parsingErr *parser.ParsingErr
+	if errors.As(err, &parsingErr) {
+		for i, err := range parsingErr.Errors() {
+			d.report(parsingErr.Spans()[i], err.Error())
+		}
+		return
+	}
+
+	resolver := interpreter.NewResolver(nil)
+	d.statements = statements
+	d.symbols = resolver.RecordSymbols()
+	err = resolver.Resolve(statements)
+	var resolverErr interpreter.ResolverErr
+	if errors.As(err, &resolverErr) {
+		d.report(resolverErr.Span, resolverErr.Message)
+	}
+
+	d.globals = nil
+	for _, symbol := range d.symbols.All() {
+		if symbol.Global {
+			d.globals = append(d.globals, symbol)
+		}
+	}
+}
+
+// report adds a diagnostic for an error found in the given span of the code.
+func (d *document) report(span token.Span, message string) {
+	d.diagnostics = append(d.diagnostics, diagnostic{
+		Range:    d.textRange(span),
+		Severity: errorSeverity,
+		Source:   "vetryx",
+		Message:  message,
+	})
+}
+
+// symbolAt returns the symbol whose name (in its declaration, or in a reference) is at the given position.
+// The symbol is nil if the name isn't declared in the code (eg: a native function).
+func (d *document) symbolAt(p position) (*interpreter.Symbol, *token.Token, bool) {
+	if d.symbols == nil {
+		return nil, nil, false
+	}
+
+	offset := d.offset(p)
+	for _, symbol := range d.symbols.All() {
+		for _, name := range append([]*token.Token{symbol.Name}, symbol.References...) {
+			if contains(name.Span, offset) {
+				return symbol, name, true
+			}
+		}
+	}
+
+	for _, name := range d.symbols.Unresolved() {
+		if contains(name.Span, offset) {
+			return nil, name, true
+		}
+	}
+	return nil, nil, false
+}
+
+// references returns the locations where a symbol is used (sorted by their position), optionally including the
+// location where it's declared.
+func (d *document) references(symbol *interpreter.Symbol, includeDeclaration bool) []location {
+	names := symbol.References
+	if includeDeclaration {
+		names = append([]*token.Token{symbol.Name}, names...)
+	}
+
+	sorted := make([]*token.Token, len(names))
+	copy(sorted, names)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Span.Start.Offset < sorted[j].Span.Start.Offset
+	})
+
+	locations := []location{}
+	for _, name := range sorted {
+		locations = append(locations, d.location(name.Span))
+	}
+	return locations
+}
+
+// outline returns the symbols declared at the top level of the document (with the methods of the classes).
+func (d *document) outline() []documentSymbol {
+	symbols := []documentSymbol{}
+	for _, statement := range d.statements {
+		switch s := statement.(type) {
+		case *ast.FunctionStatement:
+			symbols = append(symbols, d.documentSymbol(s.Name, functionSymbolKind, s.Position()))
+		case *ast.VariableStatement:
+			symbols = append(symbols, d.documentSymbol(s.Name, variableSymbolKind, s.Position()))
+		case *ast.ImportStatement:
+			symbols = append(symbols, d.documentSymbol(s.Name, moduleSymbolKind, s.Position()))
+		case *ast.ClassStatement:
+			class := d.documentSymbol(s.Name, classSymbolKind, s.Position())
+			for _, m := range s.Methods {
+				class.Children = append(class.Children, d.documentSymbol(m.Name, methodSymbolKind, m.Position()))
+			}
+			symbols = append(symbols, class)
+		}
+	}
+	return symbols
+}
+
+func (d *document) documentSymbol(name *token.Token, kind int, span token.Span) documentSymbol {
+	if !span.IsKnown() {
+		span = name.Span
+	}
+
+	return documentSymbol{
+		Name:           name.Lexeme,
+		Kind:           kind,
+		Range:          d.textRange(span),
+		SelectionRange: d.textRange(name.Span),
+	}
+}
+
+// completions returns the names that can be completed: the globals of the document, the native functions and the
+// reserved words (sorted by name).
+func (d *document) completions() []completionItem {
+	items := []completionItem{}
+	declared := map[string]bool{}
+	for _, symbol := range d.globals {
+		if declared[symbol.Name.Lexeme] {
+			continue
+		}
+		declared[symbol.Name.Lexeme] = true
+		items = append(items, completionItem{Label: symbol.Name.Lexeme, Kind: completionKinds[symbol.Kind]})
+	}
+
+	for name := range interpreter.Natives() {
+		if !declared[name] {
+			items = append(items, completionItem{Label: name, Kind: functionCompletionKind})
+		}
+	}
+
+	for word := range token.ReservedWordsMapper {
+		items = append(items, completionItem{Label: word, Kind: keywordCompletionKind})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+// completionKinds are the kinds of the completion items of each kind of symbol.
+var completionKinds = map[interpreter.SymbolKind]int{
+	interpreter.VariableSymbol:  variableCompletionKind,
+	interpreter.FunctionSymbol:  functionCompletionKind,
+	interpreter.ClassSymbol:     classCompletionKind,
+	interpreter.ParameterSymbol: variableCompletionKind,
+	interpreter.ImportSymbol:    moduleCompletionKind,
+}
+
+// describe returns the description of a symbol shown when hovering it (with its arity, if it can be called).
+// A nil symbol is a name that isn't declared in the code, so it can be a native function.
+func describe(symbol *interpreter.Symbol, name *token.Token) string {
+	if symbol == nil {
+		native, exists := interpreter.Natives()[name.Lexeme]
+		if !exists {
+			return fmt.Sprintf("undeclared `%s`", name.Lexeme)
+		}
+		return fmt.Sprintf("native function `%s`, %s", name.Lexeme, arity(native.Arity()))
+	}
+
+	switch symbol.Kind {
+	case interpreter.FunctionSymbol:
+		return fmt.Sprintf("function `%s`, %s", symbol.Name.Lexeme, arity(symbol.Arity))
+	case interpreter.ClassSymbol:
+		return fmt.Sprintf("class `%s`, %s", symbol.Name.Lexeme, arity(symbol.Arity))
+	case interpreter.ParameterSymbol:
+		return fmt.Sprintf("parameter `%s`", symbol.Name.Lexeme)
+	case interpreter.ImportSymbol:
+		return fmt.Sprintf("module `%s`", symbol.Name.Lexeme)
+	}
+
+	if symbol.Arity != interpreter.VariadicArity {
+		// a variable declared with a function (eg: dec double = (a) => a * 2;)
+		return fmt.Sprintf("variable `%s` (function), %s", symbol.Name.Lexeme, arity(symbol.Arity))
+	}
+	return fmt.Sprintf("variable `%s`", symbol.Name.Lexeme)
+}
+
+// arity returns the description of the number of arguments expected by a function.
+func arity(n int) string {
+	switch n {
+	case interpreter.VariadicArity:
+		return "accepts any number of arguments"
+	case 1:
+		return "expects 1 argument"
+	}
+	return fmt.Sprintf("expects %d arguments", n)
+}
+
+// Positions
+
+func (d *document) location(span token.Span) location {
+	return location{URI: d.uri, Range: d.textRange(span)}
+}
+
+func (d *document) textRange(span token.Span) textRange {
+	return textRange{Start: d.position(span.Start), End: d.position(span.End)}
+}
+
+// position converts a position of the code into a LSP position.
+func (d *document) position(p token.Position) position {
+	if p.Line < 1 || p.Line > len(d.lines) || p.Offset < d.lines[p.Line-1] || p.Offset > len(d.text) {
+		return position{}
+	}
+
+	prefix := d.text[d.lines[p.Line-1]:p.Offset]
+	return position{Line: p.Line - 1, Character: len(utf16.Encode([]rune(prefix)))}
+}
+
+// offset converts a LSP position into an offset of the code (in bytes).
+func (d *document) offset(p position) int {
+	if p.Line < 0 || p.Line >= len(d.lines) {
+		return -1
+	}
+
+	offset := d.lines[p.Line]
+	for character := 0; character < p.Character && offset < len(d.text); {
+		char, size := utf8.DecodeRuneInString(d.text[offset:])
+		if char == '\n' {
+			break
+		}
+		character += len(utf16.Encode([]rune{char}))
+		offset += size
+	}
+	return offset
+}
+
+// contains returns true if the offset is in the span, or right after it (eg: the cursor at the end of a name).
+func contains(span token.Span, offset int) bool {
+	return span.IsKnown() && span.Start.Offset <= offset && offset <= span.End.Offset
+}
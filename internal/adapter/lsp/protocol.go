@@ -0,0 +1,180 @@
+package lsp
+
+import "encoding/json"
+
+// The JSON-RPC error codes used by the server.
+const (
+	parseError     = -32700
+	invalidRequest = -32600
+	methodNotFound = -32601
+	invalidParams  = -32602
+)
+
+// The LSP kinds used by the server.
+const (
+	errorSeverity = 1
+
+	moduleSymbolKind   = 2
+	classSymbolKind    = 5
+	methodSymbolKind   = 6
+	functionSymbolKind = 12
+	variableSymbolKind = 13
+
+	functionCompletionKind = 3
+	variableCompletionKind = 6
+	classCompletionKind    = 7
+	moduleCompletionKind   = 9
+	keywordCompletionKind  = 14
+
+	fullSync = 1
+)
+
+type (
+	// request is a JSON-RPC message sent by the editor. The notifications are the requests without ID.
+	request struct {
+		ID     json.RawMessage `json:"id,omitempty"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params,omitempty"`
+	}
+
+	// response is the JSON-RPC message sent as the result of a request.
+	response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  interface{}     `json:"result"`
+	}
+
+	// errorResponse is the JSON-RPC message sent when a request fails.
+	errorResponse struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   *responseError  `json:"error"`
+	}
+
+	responseError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+
+	// notification is a JSON-RPC message sent by the server, that doesn't expect a response.
+	notification struct {
+		JSONRPC string      `json:"jsonrpc"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params"`
+	}
+)
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+type (
+	// position is a position in a document (the characters are counted in UTF-16 code units, and both start at 0).
+	position struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	}
+
+	textRange struct {
+		Start position `json:"start"`
+		End   position `json:"end"`
+	}
+
+	location struct {
+		URI   string    `json:"uri"`
+		Range textRange `json:"range"`
+	}
+
+	textDocumentIdentifier struct {
+		URI string `json:"uri"`
+	}
+
+	textDocumentItem struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	}
+
+	didOpenParams struct {
+		TextDocument textDocumentItem `json:"textDocument"`
+	}
+
+	didChangeParams struct {
+		TextDocument   textDocumentIdentifier `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+
+	didCloseParams struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+	}
+
+	textDocumentPositionParams struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+		Position     position               `json:"position"`
+	}
+
+	referenceParams struct {
+		textDocumentPositionParams
+		Context struct {
+			IncludeDeclaration bool `json:"includeDeclaration"`
+		} `json:"context"`
+	}
+
+	documentSymbolParams struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+	}
+
+	diagnostic struct {
+		Range    textRange `json:"range"`
+		Severity int       `json:"severity"`
+		Source   string    `json:"source"`
+		Message  string    `json:"message"`
+	}
+
+	publishDiagnosticsParams struct {
+		URI         string       `json:"uri"`
+		Diagnostics []diagnostic `json:"diagnostics"`
+	}
+
+	hover struct {
+		Contents markupContent `json:"contents"`
+		Range    textRange     `json:"range"`
+	}
+
+	markupContent struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+
+	documentSymbol struct {
+		Name           string           `json:"name"`
+		Kind           int              `json:"kind"`
+		Range          textRange        `json:"range"`
+		SelectionRange textRange        `json:"selectionRange"`
+		Children       []documentSymbol `json:"children,omitempty"`
+	}
+
+	completionItem struct {
+		Label string `json:"label"`
+		Kind  int    `json:"kind"`
+	}
+
+	initializeResult struct {
+		Capabilities serverCapabilities `json:"capabilities"`
+		ServerInfo   serverInfo         `json:"serverInfo"`
+	}
+
+	serverCapabilities struct {
+		TextDocumentSync       int      `json:"textDocumentSync"`
+		DefinitionProvider     bool     `json:"definitionProvider"`
+		ReferencesProvider     bool     `json:"referencesProvider"`
+		HoverProvider          bool     `json:"hoverProvider"`
+		DocumentSymbolProvider bool     `json:"documentSymbolProvider"`
+		CompletionProvider     struct{} `json:"completionProvider"`
+	}
+
+	serverInfo struct {
+		Name string `json:"name"`
+	}
+)
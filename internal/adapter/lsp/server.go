@@ -0,0 +1,257 @@
+// This package contains the language server of the language, so the editors can show the errors of the scripts, find
+// the definitions and references of the names, show their arity, list their symbols and complete them.
+// It talks LSP (Language Server Protocol) through JSON-RPC messages.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// errNotShutdown is returned when the editor asks the server to exit without shutting it down first.
+var errNotShutdown = errors.New("exited without being shut down")
+
+// Server is a language server, that analyzes the documents opened in the editor (with the scanner, the parser and
+// the resolver) each time they change.
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	errOut    io.Writer
+	documents map[string]*document
+	shutdown  bool // the editor asked to shut down the server (so it only expects the exit notification)
+}
+
+// New is a constructor for a Server, that reads the messages from in and writes them to out.
+func New(in io.Reader, out io.Writer, errOut io.Writer) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		errOut:    errOut,
+		documents: map[string]*document{},
+	}
+}
+
+// Run serves the messages until the exit notification is received (or the input ends).
+func (s *Server) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		data, err := s.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed reading a message: %w", err)
+		}
+
+		var req request
+		err = json.Unmarshal(data, &req)
+		if err != nil {
+			err = s.write(errorResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &responseError{Code: parseError, Message: err.Error()}})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errNotShutdown
+			}
+			return nil
+		}
+
+		err = s.handle(req)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// handle runs the method of a request, and responds to it (if it's not a notification).
+func (s *Server) handle(req request) error {
+	result, err := s.dispatch(req)
+	if req.ID == nil {
+		if err != nil {
+			fmt.Fprintf(s.errOut, "failed handling %q: %s\n", req.Method, err)
+		}
+		return nil
+	}
+
+	if err != nil {
+		var respErr *responseError
+		if !errors.As(err, &respErr) {
+			respErr = &responseError{Code: invalidParams, Message: err.Error()}
+		}
+		return s.write(errorResponse{JSONRPC: "2.0", ID: req.ID, Error: respErr})
+	}
+	return s.write(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+// dispatch runs the method of a request, returning its result.
+func (s *Server) dispatch(req request) (interface{}, error) {
+	if s.shutdown {
+		return nil, &responseError{Code: invalidRequest, Message: "the server is shut down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		result := initializeResult{ServerInfo: serverInfo{Name: "vetryx"}}
+		result.Capabilities = serverCapabilities{
+			TextDocumentSync:       fullSync,
+			DefinitionProvider:     true,
+			ReferencesProvider:     true,
+			HoverProvider:          true,
+			DocumentSymbolProvider: true,
+		}
+		return result, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			s.documents[params.TextDocument.URI] = newDocument(params.TextDocument.URI, params.TextDocument.Text)
+			return nil, s.publishDiagnostics(params.TextDocument.URI)
+		})
+	case "textDocument/didChange":
+		var params didChangeParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			d, exists := s.documents[params.TextDocument.URI]
+			if !exists || len(params.ContentChanges) == 0 {
+				return nil, nil
+			}
+
+			// the changes are always the full text of the document (see fullSync)
+			d.update(params.ContentChanges[len(params.ContentChanges)-1].Text)
+			return nil, s.publishDiagnostics(params.TextDocument.URI)
+		})
+	case "textDocument/didClose":
+		var params didCloseParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			delete(s.documents, params.TextDocument.URI)
+			return nil, s.write(notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}}})
+		})
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			d := s.documents[params.TextDocument.URI]
+			if d == nil {
+				return nil, nil
+			}
+
+			symbol, _, found := d.symbolAt(params.Position)
+			if !found || symbol == nil {
+				return nil, nil
+			}
+			return d.location(symbol.Name.Span), nil
+		})
+	case "textDocument/references":
+		var params referenceParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			d := s.documents[params.TextDocument.URI]
+			if d == nil {
+				return nil, nil
+			}
+
+			symbol, _, found := d.symbolAt(params.Position)
+			if !found || symbol == nil {
+				return nil, nil
+			}
+			return d.references(symbol, params.Context.IncludeDeclaration), nil
+		})
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			d := s.documents[params.TextDocument.URI]
+			if d == nil {
+				return nil, nil
+			}
+
+			symbol, name, found := d.symbolAt(params.Position)
+			if !found {
+				return nil, nil
+			}
+			return hover{Contents: markupContent{Kind: "markdown", Value: describe(symbol, name)}, Range: d.textRange(name.Span)}, nil
+		})
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			d := s.documents[params.TextDocument.URI]
+			if d == nil {
+				return nil, nil
+			}
+			return d.outline(), nil
+		})
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		return decode(req.Params, &params, func() (interface{}, error) {
+			d := s.documents[params.TextDocument.URI]
+			if d == nil {
+				return nil, nil
+			}
+			return d.completions(), nil
+		})
+	}
+
+	if req.ID == nil {
+		// the notifications that aren't supported are ignored (eg: initialized)
+		return nil, nil
+	}
+	return nil, &responseError{Code: methodNotFound, Message: fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+// decode decodes the params of a request, and then runs the method with them.
+func decode(data json.RawMessage, params interface{}, method func() (interface{}, error)) (interface{}, error) {
+	err := json.Unmarshal(data, params)
+	if err != nil {
+		return nil, &responseError{Code: invalidParams, Message: err.Error()}
+	}
+	return method()
+}
+
+// publishDiagnostics sends the errors found in a document to the editor.
+func (s *Server) publishDiagnostics(uri string) error {
+	params := publishDiagnosticsParams{URI: uri, Diagnostics: s.documents[uri].diagnostics}
+	return s.write(notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: params})
+}
+
+// read reads the content of the next message (that follows its headers).
+func (s *Server) read() ([]byte, error) {
+	headers, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(headers) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", headers.Get("Content-Length"))
+	}
+
+	data := make([]byte, length)
+	_, err = io.ReadFull(s.in, data)
+	return data, err
+}
+
+// write writes a message (with its headers).
+func (s *Server) write(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed encoding a message: %w", err)
+	}
+
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	if err != nil {
+		return fmt.Errorf("failed writing a message: %w", err)
+	}
+	return nil
+}
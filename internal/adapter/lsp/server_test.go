@@ -0,0 +1,240 @@
+package lsp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/adapter/lsp"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	validURI   = "file:///valid.vx"
+	invalidURI = "file:///invalid.vx"
+	validSrc   = "fn add(a, b) {\n  return a + b;\n}\nclass P {\n  init(x) {}\n  get() {}\n}\ndec r = add(1, 2);\nprint add(r, len(\"é\"));"
+	invalidSrc = "dec a = 1 & 2;\nprint ?;"
+)
+
+// session is the messages of a session with the server: the requests (with the responses, by ID) and the
+// notifications sent by the server.
+type session struct {
+	input         bytes.Buffer
+	responses     map[string]json.RawMessage
+	errors        map[string]json.RawMessage
+	notifications []json.RawMessage
+}
+
+func (s *session) send(id int, method string, params string) {
+	message := fmt.Sprintf(`{"jsonrpc":"2.0","method":%q,"params":%s}`, method, params)
+	if id != 0 {
+		message = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q,"params":%s}`, id, method, params)
+	}
+	fmt.Fprintf(&s.input, "Content-Length: %d\r\n\r\n%s", len(message), message)
+}
+
+func (s *session) run(t *testing.T) error {
+	var output bytes.Buffer
+	err := lsp.New(&s.input, &output, io.Discard).Run(context.Background())
+
+	s.responses = map[string]json.RawMessage{}
+	s.errors = map[string]json.RawMessage{}
+	reader := bufio.NewReader(&output)
+	for {
+		headers, readErr := textproto.NewReader(reader).ReadMIMEHeader()
+		if readErr != nil {
+			break
+		}
+		length, _ := strconv.Atoi(headers.Get("Content-Length"))
+		data := make([]byte, length)
+		_, readErr = io.ReadFull(reader, data)
+		assert.NoError(t, readErr)
+
+		var message struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}
+		assert.NoError(t, json.Unmarshal(data, &message))
+		switch {
+		case message.Method != "":
+			s.notifications = append(s.notifications, message.Params)
+		case message.Error != nil:
+			s.errors[string(message.ID)] = message.Error
+		default:
+			s.responses[string(message.ID)] = message.Result
+		}
+	}
+	return err
+}
+
+func position(uri string, line int, character int) string {
+	return fmt.Sprintf(`{"textDocument":{"uri":%q},"position":{"line":%d,"character":%d}}`, uri, line, character)
+}
+
+func TestServer(t *testing.T) {
+	s := &session{}
+	s.send(1, "initialize", `{"capabilities":{}}`)
+	s.send(0, "initialized", `{}`)
+	s.send(0, "textDocument/didOpen", fmt.Sprintf(`{"textDocument":{"uri":%q,"languageId":"vetryx","version":1,"text":%q}}`, validURI, validSrc))
+	s.send(0, "textDocument/didOpen", fmt.Sprintf(`{"textDocument":{"uri":%q,"languageId":"vetryx","version":1,"text":%q}}`, invalidURI, invalidSrc))
+	s.send(2, "textDocument/definition", position(validURI, 8, 7))
+	s.send(3, "textDocument/references", `{"textDocument":{"uri":"file:///valid.vx"},"position":{"line":0,"character":4},"context":{"includeDeclaration":true}}`)
+	s.send(4, "textDocument/hover", position(validURI, 7, 9))
+	s.send(5, "textDocument/hover", position(validURI, 8, 15))
+	s.send(6, "textDocument/documentSymbol", `{"textDocument":{"uri":"file:///valid.vx"}}`)
+	s.send(7, "textDocument/completion", position(validURI, 8, 0))
+	s.send(8, "textDocument/definition", position(validURI, 1, 0))
+	s.send(9, "textDocument/foo", `{}`)
+	s.send(0, "textDocument/didChange", fmt.Sprintf(`{"textDocument":{"uri":%q,"version":2},"contentChanges":[{"text":"print 1;"}]}`, invalidURI))
+	s.send(10, "textDocument/hover", `{"textDocument":{"uri":"file:///valid.vx"},"position":"foo"}`)
+	s.send(11, "shutdown", `null`)
+	s.send(12, "textDocument/hover", position(validURI, 7, 9))
+	s.send(0, "exit", `null`)
+
+	err := s.run(t)
+	assert.NoError(t, err)
+
+	var initialize struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	assert.NoError(t, json.Unmarshal(s.responses["1"], &initialize))
+	for _, capability := range []string{"definitionProvider", "referencesProvider", "hoverProvider", "documentSymbolProvider", "completionProvider"} {
+		assert.Contains(t, initialize.Capabilities, capability)
+	}
+
+	tests := map[string]struct {
+		got      json.RawMessage
+		expected string
+	}{
+		"no diagnostics in the valid document": {
+			got:      s.notifications[0],
+			expected: `{"uri":"file:///valid.vx","diagnostics":[]}`,
+		},
+		"all the lexical errors": {
+			got: s.notifications[1],
+			expected: `{"uri":"file:///invalid.vx","diagnostics":[
+				{"range":{"start":{"line":0,"character":10},"end":{"line":0,"character":11}},"severity":1,"source":"vetryx","message":"unexpected char '&' (the and operator is '&&')"},
+				{"range":{"start":{"line":1,"character":6},"end":{"line":1,"character":7}},"severity":1,"source":"vetryx","message":"unexpected char '?'"}
+			]}`,
+		},
+		"the diagnostics are updated after a change": {
+			got:      s.notifications[2],
+			expected: `{"uri":"file:///invalid.vx","diagnostics":[]}`,
+		},
+		"definition of a function": {
+			got:      s.responses["2"],
+			expected: `{"uri":"file:///valid.vx","range":{"start":{"line":0,"character":3},"end":{"line":0,"character":6}}}`,
+		},
+		"references of a function": {
+			got: s.responses["3"],
+			expected: `[
+				{"uri":"file:///valid.vx","range":{"start":{"line":0,"character":3},"end":{"line":0,"character":6}}},
+				{"uri":"file:///valid.vx","range":{"start":{"line":7,"character":8},"end":{"line":7,"character":11}}},
+				{"uri":"file:///valid.vx","range":{"start":{"line":8,"character":6},"end":{"line":8,"character":9}}}
+			]`,
+		},
+		"hover of a function": {
+			got:      s.responses["4"],
+			expected: "{\"contents\":{\"kind\":\"markdown\",\"value\":\"function `add`, expects 2 arguments\"},\"range\":{\"start\":{\"line\":7,\"character\":8},\"end\":{\"line\":7,\"character\":11}}}",
+		},
+		"hover of a native function": {
+			got:      s.responses["5"],
+			expected: "{\"contents\":{\"kind\":\"markdown\",\"value\":\"native function `len`, expects 1 argument\"},\"range\":{\"start\":{\"line\":8,\"character\":13},\"end\":{\"line\":8,\"character\":16}}}",
+		},
+		"document symbols": {
+			got: s.responses["6"],
+			expected: `[
+				{"name":"add","kind":12,"range":{"start":{"line":0,"character":0},"end":{"line":2,"character":1}},"selectionRange":{"start":{"line":0,"character":3},"end":{"line":0,"character":6}}},
+				{"name":"P","kind":5,"range":{"start":{"line":3,"character":0},"end":{"line":6,"character":1}},"selectionRange":{"start":{"line":3,"character":6},"end":{"line":3,"character":7}},"children":[
+					{"name":"init","kind":6,"range":{"start":{"line":4,"character":2},"end":{"line":4,"character":12}},"selectionRange":{"start":{"line":4,"character":2},"end":{"line":4,"character":6}}},
+					{"name":"get","kind":6,"range":{"start":{"line":5,"character":2},"end":{"line":5,"character":10}},"selectionRange":{"start":{"line":5,"character":2},"end":{"line":5,"character":5}}}
+				]},
+				{"name":"r","kind":13,"range":{"start":{"line":7,"character":0},"end":{"line":7,"character":18}},"selectionRange":{"start":{"line":7,"character":4},"end":{"line":7,"character":5}}}
+			]`,
+		},
+		"no definition out of a name": {
+			got:      s.responses["8"],
+			expected: `null`,
+		},
+		"error with a unknown method": {
+			got:      s.errors["9"],
+			expected: `{"code":-32601,"message":"unknown method \"textDocument/foo\""}`,
+		},
+		"error with invalid params": {
+			got:      s.errors["10"],
+			expected: `{"code":-32602,"message":"json: cannot unmarshal string into Go struct field textDocumentPositionParams.position of type lsp.position"}`,
+		},
+		"shutdown": {
+			got:      s.responses["11"],
+			expected: `null`,
+		},
+		"error after the shutdown": {
+			got:      s.errors["12"],
+			expected: `{"code":-32600,"message":"the server is shut down"}`,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			assert.JSONEq(t, test.expected, string(test.got))
+		})
+	}
+
+	var completions []struct {
+		Label string `json:"label"`
+		Kind  int    `json:"kind"`
+	}
+	assert.NoError(t, json.Unmarshal(s.responses["7"], &completions))
+	labels := []string{}
+	for _, c := range completions {
+		labels = append(labels, c.Label)
+	}
+	for _, expected := range []string{"add", "P", "r", "len", "clock", "dec", "while", "finally"} {
+		assert.Contains(t, labels, expected)
+	}
+	assert.NotContains(t, labels, "a") // the locals aren't completed
+}
+
+func TestServerWithInvalidMessages(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		expectedErr string
+	}{
+		"exit without shutdown": {
+			input:       "Content-Length: 33\r\n\r\n{\"jsonrpc\":\"2.0\",\"method\":\"exit\"}",
+			expectedErr: "exited without being shut down",
+		},
+		"invalid length": {
+			input:       "Content-Length: foo\r\n\r\n{}",
+			expectedErr: `invalid Content-Length header "foo"`,
+		},
+		"truncated message": {
+			input:       "Content-Length: 10\r\n\r\n{}",
+			expectedErr: "unexpected EOF",
+		},
+		"end of the input": {
+			input: "",
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			err := lsp.New(strings.NewReader(test.input), io.Discard, io.Discard).Run(context.Background())
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// This package contains the REPL (Read-Eval-Print Loop) of the language.
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	"github.com/avazquezcode/govetryx/vetryx"
+)
+
+const (
+	prompt             = ">> "
+	continuationPrompt = ".. "
+)
+
+const help = `Type any statement or expression to evaluate it. Commands:
+  :help        shows this help
+  :env         prints the globals
+  :load <file> runs a file in the current session
+  :reset       starts a new session (removing all the globals)
+  :quit        exits the REPL
+`
+
+// REPL is an interactive session, that keeps the same runtime (and so, the same globals) across the lines.
+type REPL struct {
+	runtime *vetryx.Runtime
+	in      *bufio.Reader // shared with the programs (eg: the input native fn)
+	out     io.Writer
+	errOut  io.Writer
+	dir     string // directory used to resolve the relative imports (the working directory)
+}
+
+// New is a constructor for a REPL.
+func New(in io.Reader, out io.Writer, errOut io.Writer) *REPL {
+	dir, err := os.Getwd()
+	if err != nil {
+		dir = "."
+	}
+
+	r := &REPL{
+		in:     bufio.NewReader(in),
+		out:    out,
+		errOut: errOut,
+		dir:    dir,
+	}
+	r.reset()
+	return r
+}
+
+// Run runs the REPL until the input ends (or the :quit command is executed).
+func (r *REPL) Run(ctx context.Context) error {
+	for {
+		source, err := r.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		trimmed := strings.TrimSpace(source)
+		if trimmed == "" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, ":") {
+			quit := r.command(ctx, trimmed)
+			if quit {
+				return nil
+			}
+			continue
+		}
+
+		r.eval(ctx, source)
+	}
+}
+
+// read reads the next input from the user, that can span many lines when the braces are not balanced.
+// It returns io.EOF when the input ended.
+func (r *REPL) read() (string, error) {
+	fmt.Fprint(r.out, prompt)
+
+	var source strings.Builder
+	for {
+		line, err := r.in.ReadString('\n')
+		if line != "" {
+			source.WriteString(strings.TrimRight(line, "\r\n"))
+			source.WriteString("\n")
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && source.Len() > 0 {
+				return source.String(), nil // the last input, without a line break
+			}
+			return "", err
+		}
+
+		if openBraces(source.String()) <= 0 {
+			return source.String(), nil
+		}
+		fmt.Fprint(r.out, continuationPrompt)
+	}
+}
+
+// eval evaluates the source code, printing the result when it is an expression.
+func (r *REPL) eval(ctx context.Context, source string) {
+	program, err := r.runtime.Compile(source)
+	if err != nil {
+		// allow to skip the semicolon at the end of a single statement/expression (eg: "1 + 2")
+		var retryErr error
+		program, retryErr = r.runtime.Compile(source + ";")
+		if retryErr != nil {
+			fmt.Fprintln(r.errOut, err)
+			return
+		}
+	}
+
+	value, err := r.runtime.Eval(ctx, program)
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		return
+	}
+
+	if value != nil {
+		fmt.Fprintln(r.out, corerule.PrintableValue(value))
+	}
+}
+
+// command executes a REPL command, returning true when the REPL should stop.
+func (r *REPL) command(ctx context.Context, input string) bool {
+	fields := strings.Fields(input)
+	switch fields[0] {
+	case ":help":
+		fmt.Fprint(r.out, help)
+	case ":env":
+		r.printEnv()
+	case ":load":
+		if len(fields) != 2 {
+			fmt.Fprintln(r.errOut, "usage: :load <file>")
+			return false
+		}
+		r.load(ctx, fields[1])
+	case ":reset":
+		r.reset()
+	case ":quit", ":exit":
+		return true
+	default:
+		fmt.Fprintf(r.errOut, "unknown command %q (type :help to see the available commands)\n", fields[0])
+	}
+	return false
+}
+
+// printEnv prints the globals (sorted by name).
+func (r *REPL) printEnv() {
+	globals := r.runtime.Globals()
+
+	names := make([]string, 0, len(globals))
+	for name := range globals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(r.out, "%s = %s\n", name, corerule.PrintableValue(globals[name]))
+	}
+}
+
+// load runs a file in the current session.
+func (r *REPL) load(ctx context.Context, path string) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(r.errOut, "failed when reading the file: %s\n", err)
+		return
+	}
+
+	// the imports of the file are resolved from its directory
+	dir := filepath.Dir(path)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.dir, dir)
+	}
+	r.runtime.SetBaseDir(dir)
+	defer r.runtime.SetBaseDir(r.dir)
+
+	program, err := r.runtime.Compile(string(code))
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+		return
+	}
+
+	err = r.runtime.Run(ctx, program)
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+}
+
+// reset starts a new session.
+func (r *REPL) reset() {
+	r.runtime = vetryx.NewRuntime(vetryx.WithStdout(r.out), vetryx.WithStderr(r.errOut), vetryx.WithStdin(r.in), vetryx.WithBaseDir(r.dir))
+}
+
+// openBraces returns the quantity of braces that are still open in the source (ignoring strings and comments).
+func openBraces(source string) int {
+	open := 0
+	inString := false
+	inComment := false
+
+	for _, char := range source {
+		switch {
+		case inComment:
+			inComment = char != '\n'
+		case inString:
+			inString = char != '"'
+		case char == '"':
+			inString = true
+		case char == '#':
+			inComment = true
+		case char == '{':
+			open++
+		case char == '}':
+			open--
+		}
+	}
+
+	return open
+}
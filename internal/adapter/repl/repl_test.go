@@ -0,0 +1,97 @@
+package repl_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/adapter/repl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	lib := filepath.Join(t.TempDir(), "lib.vx")
+	err := os.WriteFile(lib, []byte("fn double(n) { return n * 2; }"), 0o600)
+	assert.NoError(t, err)
+
+	importer := filepath.Join(filepath.Dir(lib), "importer.vx")
+	err = os.WriteFile(importer, []byte(`import "lib.vx" as l; dec four = l.double(2);`), 0o600)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		input          string
+		expectedStdout string
+		expectedStderr string
+	}{
+		"expression results are printed": {
+			input:          "1 + 2;\n",
+			expectedStdout: ">> 3\n>> ",
+		},
+		"semicolon can be skipped": {
+			input:          "1 + 2\n",
+			expectedStdout: ">> 3\n>> ",
+		},
+		"globals are kept across lines": {
+			input:          "dec a = 1;\na = a + 1;\nprint a;\n",
+			expectedStdout: ">> >> 2\n>> 2\n>> ",
+		},
+		"null results are not printed": {
+			input:          "fn a() {}\na();\n",
+			expectedStdout: ">> >> >> ",
+		},
+		"multi-line input": {
+			input:          "fn a(b) {\n  if b {\n    return 1; # }\n  }\n  return \"}\";\n}\na(true);\na(false);\n",
+			expectedStdout: ">> .. .. .. .. .. >> 1\n>> }\n>> ",
+		},
+		"errors do not stop the session": {
+			input:          "1 / 0;\nprint 1;\n",
+			expectedStdout: ">> >> 1\n>> ",
+			expectedStderr: "runtime error occurred at line 1: division per zero\n1 | 1 / 0;\n  | ^^^^^\n",
+		},
+		"env command": {
+			input:          "dec a = 1;\n:env\n",
+			expectedStdout: ">> >> a = 1\nclock = <native fn clock>\ndelete = <native fn delete>\nhas = <native fn has>\ninput = <native fn input>\nkeys = <native fn keys>\nlen = <native fn len>\nmax = <native fn max>\nmin = <native fn min>\npop = <native fn pop>\npush = <native fn push>\nsleep = <native fn sleep>\nslice = <native fn slice>\nvalues = <native fn values>\n>> ",
+		},
+		"reset command": {
+			input:          "dec a = 1;\n:reset\nprint a;\n",
+			expectedStdout: ">> >> >> >> ",
+			expectedStderr: "the variable a is not defined\n",
+		},
+		"load command": {
+			input:          ":load " + lib + "\ndouble(2);\n",
+			expectedStdout: ">> >> 4\n>> ",
+		},
+		"the imports of the loaded files are resolved from their directory": {
+			input:          ":load " + importer + "\nfour;\n",
+			expectedStdout: ">> >> 4\n>> ",
+		},
+		"the programs read the input of the session": {
+			input:          "dec a = input();\nhello\nprint a;\n",
+			expectedStdout: ">> >> hello\n>> ",
+		},
+		"quit command": {
+			input:          ":quit\nprint 1;\n",
+			expectedStdout: ">> ",
+		},
+		"unknown command": {
+			input:          ":foo\n",
+			expectedStdout: ">> >> ",
+			expectedStderr: "unknown command \":foo\" (type :help to see the available commands)\n",
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			r := repl.New(strings.NewReader(test.input), &stdout, &stderr)
+
+			err := r.Run(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStdout, stdout.String())
+			assert.Equal(t, test.expectedStderr, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+// dumpIndent is the indentation of the nested statements in the dump.
+const dumpIndent = "  "
+
+// dumper renders the AST as S-expressions (eg: (print (+ 1 2))), to inspect it.
+type dumper struct {
+	builder strings.Builder
+	depth   int
+}
+
+// Dump renders the statements as S-expressions, one statement per line (the nested statements are indented).
+func Dump(statements []Statement) string {
+	d := &dumper{}
+	for _, statement := range statements {
+		d.statement(statement)
+		d.builder.WriteString("\n")
+	}
+	return d.builder.String()
+}
+
+// statement writes a statement, starting with the indentation of its depth (without the trailing line break).
+func (d *dumper) statement(statement Statement) {
+	d.builder.WriteString(strings.Repeat(dumpIndent, d.depth))
+	_ = statement.Accept(d)
+}
+
+// nested writes statements in new lines, one level deeper.
+func (d *dumper) nested(statements ...Statement) {
+	d.depth++
+	for _, statement := range statements {
+		d.builder.WriteString("\n")
+		d.statement(statement)
+	}
+	d.depth--
+}
+
+func (d *dumper) expression(expression Expression) string {
+	if expression == nil {
+		return "_"
+	}
+	text, _ := expression.Accept(d)
+	return text.(string)
+}
+
+func (d *dumper) list(tag string, expressions ...Expression) string {
+	parts := []string{tag}
+	for _, expression := range expressions {
+		parts = append(parts, d.expression(expression))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func names(tokens []*token.Token) string {
+	lexemes := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		lexemes = append(lexemes, t.Lexeme)
+	}
+	return "(" + strings.Join(lexemes, " ") + ")"
+}
+
+// Statements
+
+func (d *dumper) VisitExpressionStatement(statement *ExpressionStatement) error {
+	d.builder.WriteString(d.list("expr", statement.Expression))
+	return nil
+}
+
+func (d *dumper) VisitPrintStatement(statement *PrintStatement) error {
+	d.builder.WriteString(d.list("print", statement.Expression))
+	return nil
+}
+
+func (d *dumper) VisitVariableStatement(statement *VariableStatement) error {
+	d.builder.WriteString(d.list("dec "+statement.Name.Lexeme, statement.Value))
+	return nil
+}
+
+func (d *dumper) VisitReturnStatement(statement *ReturnStatement) error {
+	if statement.Value == nil {
+		d.builder.WriteString("(return)")
+		return nil
+	}
+	d.builder.WriteString(d.list("return", statement.Value))
+	return nil
+}
+
+func (d *dumper) VisitFunctionStatement(statement *FunctionStatement) error {
+	fmt.Fprintf(&d.builder, "(fn %s %s", statement.Name.Lexeme, names(statement.Paremeters))
+	d.nested(statement.Body...)
+	d.builder.WriteString(")")
+	return nil
+}
+
+func (d *dumper) VisitClassStatement(statement *ClassStatement) error {
+	fmt.Fprintf(&d.builder, "(class %s", statement.Name.Lexeme)
+	if statement.Superclass != nil {
+		fmt.Fprintf(&d.builder, " < %s", statement.Superclass.Name.Lexeme)
+	}
+
+	methods := make([]Statement, 0, len(statement.Methods))
+	for _, method := range statement.Methods {
+		methods = append(methods, method)
+	}
+	d.nested(methods...)
+	d.builder.WriteString(")")
+	return nil
+}
+
+func (d *dumper) VisitImportStatement(statement *ImportStatement) error {
+	fmt.Fprintf(&d.builder, "(import %q %s)", statement.Path.Literal, statement.Name.Lexeme)
+	return nil
+}
+
+func (d *dumper) VisitBlockStatement(statement *BlockStatement) error {
+	d.builder.WriteString("(block")
+	d.nested(statement.Statements...)
+	d.builder.WriteString(")")
+	return nil
+}
+
+func (d *dumper) VisitIfStatement(statement *IfStatement) error {
+	d.builder.WriteString("(if " + d.expression(statement.Condition))
+	if statement.ElseBlock == nil {
+		d.nested(statement.ThenBlock)
+	} else {
+		d.nested(statement.ThenBlock, statement.ElseBlock)
+	}
+	d.builder.WriteString(")")
+	return nil
+}
+
+func (d *dumper) VisitWhileStatement(statement *WhileStatement) error {
+	d.builder.WriteString("(while " + d.expression(statement.Condition))
+	d.nested(statement.Body)
+	d.builder.WriteString(")")
+	return nil
+}
+
+func (d *dumper) VisitForStatement(statement *ForStatement) error {
+	d.builder.WriteString("(for")
+	if statement.Initializer == nil {
+		d.builder.WriteString(" _")
+	} else {
+		// the initializer is rendered inline
+		inline := &dumper{}
+		_ = statement.Initializer.Accept(inline)
+		d.builder.WriteString(" " + inline.builder.String())
+	}
+	d.builder.WriteString(" " + d.expression(statement.Condition) + " " + d.expression(statement.Post))
+	d.nested(statement.Body)
+	d.builder.WriteString(")")
+	return nil
+}
+
+func (d *dumper) VisitForInStatement(statement *ForInStatement) error {
+	fmt.Fprintf(&d.builder, "(for-in %s %s", names(statement.Variables), d.expression(statement.Iterable))
+	d.nested(statement.Body)
+	d.builder.WriteString(")")
+	return nil
+}
+
+func (d *dumper) VisitBreakStatement(statement *BreakStatement) error {
+	d.builder.WriteString("(break)")
+	return nil
+}
+
+func (d *dumper) VisitContinueStatement(statement *ContinueStatement) error {
+	d.builder.WriteString("(continue)")
+	return nil
+}
+
+func (d *dumper) VisitThrowStatement(statement *ThrowStatement) error {
+	d.builder.WriteString(d.list("throw", statement.Value))
+	return nil
+}
+
+func (d *dumper) VisitTryStatement(statement *TryStatement) error {
+	d.builder.WriteString("(try")
+	d.nested(statement.Body)
+
+	d.depth++
+	if statement.CatchBody != nil {
+		fmt.Fprintf(&d.builder, "\n%s(catch %s", strings.Repeat(dumpIndent, d.depth), statement.CatchName.Lexeme)
+		d.nested(statement.CatchBody)
+		d.builder.WriteString(")")
+	}
+	if statement.FinallyBody != nil {
+		fmt.Fprintf(&d.builder, "\n%s(finally", strings.Repeat(dumpIndent, d.depth))
+		d.nested(statement.FinallyBody)
+		d.builder.WriteString(")")
+	}
+	d.depth--
+
+	d.builder.WriteString(")")
+	return nil
+}
+
+// Expressions
+
+func (d *dumper) VisitLiteralExpression(expression *LiteralExpression) (interface{}, error) {
+	if value, isString := expression.Value.(string); isString {
+		return fmt.Sprintf("%q", value), nil
+	}
+	return corerule.PrintableValue(expression.Value), nil
+}
+
+func (d *dumper) VisitGroupingExpression(expression *GroupingExpression) (interface{}, error) {
+	return d.list("group", expression.Expression), nil
+}
+
+func (d *dumper) VisitUnaryExpression(expression *UnaryExpression) (interface{}, error) {
+	return d.list(expression.Operator.Lexeme, expression.Expression), nil
+}
+
+func (d *dumper) VisitBinaryExpression(expression *BinaryExpression) (interface{}, error) {
+	return d.list(expression.Operator.Lexeme, expression.Left, expression.Right), nil
+}
+
+func (d *dumper) VisitLogicalExpression(expression *LogicalExpression) (interface{}, error) {
+	return d.list(expression.Operator.Lexeme, expression.Left, expression.Right), nil
+}
+
+func (d *dumper) VisitVariableExpression(expression *VariableExpression) (interface{}, error) {
+	return expression.Name.Lexeme, nil
+}
+
+func (d *dumper) VisitAssignmentExpression(expression *AssignmentExpression) (interface{}, error) {
+	return d.list("= "+expression.Name.Lexeme, expression.Value), nil
+}
+
+func (d *dumper) VisitCallExpression(expression *CallExpression) (interface{}, error) {
+	return d.list("call", append([]Expression{expression.Callee}, expression.Arguments...)...), nil
+}
+
+func (d *dumper) VisitFunctionExpression(expression *FunctionExpression) (interface{}, error) {
+	// the body is rendered in the following lines, one level deeper than the statement
+	body := &dumper{depth: d.depth}
+	body.nested(expression.Body...)
+	return fmt.Sprintf("(fn %s%s)", names(expression.Parameters), body.builder.String()), nil
+}
+
+func (d *dumper) VisitListExpression(expression *ListExpression) (interface{}, error) {
+	return d.list("list", expression.Elements...), nil
+}
+
+func (d *dumper) VisitMapExpression(expression *MapExpression) (interface{}, error) {
+	parts := []string{"map"}
+	for index := range expression.Keys {
+		parts = append(parts, d.list(d.expression(expression.Keys[index]), expression.Values[index]))
+	}
+	return "(" + strings.Join(parts, " ") + ")", nil
+}
+
+func (d *dumper) VisitIndexExpression(expression *IndexExpression) (interface{}, error) {
+	return d.list("index", expression.Object, expression.Index), nil
+}
+
+func (d *dumper) VisitIndexAssignmentExpression(expression *IndexAssignmentExpression) (interface{}, error) {
+	return d.list("index=", expression.Object, expression.Index, expression.Value), nil
+}
+
+func (d *dumper) VisitGetExpression(expression *GetExpression) (interface{}, error) {
+	return d.list("get "+expression.Name.Lexeme, expression.Object), nil
+}
+
+func (d *dumper) VisitSetExpression(expression *SetExpression) (interface{}, error) {
+	return d.list("set "+expression.Name.Lexeme, expression.Object, expression.Value), nil
+}
+
+func (d *dumper) VisitThisExpression(expression *ThisExpression) (interface{}, error) {
+	return "this", nil
+}
+
+func (d *dumper) VisitSuperExpression(expression *SuperExpression) (interface{}, error) {
+	return "(super " + expression.Method.Lexeme + ")", nil
+}
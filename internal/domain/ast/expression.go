@@ -5,12 +5,14 @@ import "github.com/avazquezcode/govetryx/internal/domain/token"
 type (
 	// AssignmentExpression is the struct used for assignments.
 	AssignmentExpression struct {
+		Node
 		Name  *token.Token
 		Value Expression
 	}
 
 	// BinaryExpression is the struct used for binary expressions.
 	BinaryExpression struct {
+		Node
 		Left     Expression
 		Operator *token.Token
 		Right    Expression
@@ -18,29 +20,43 @@ type (
 
 	// CallExpression is the struct used for calls (eg: a function call).
 	CallExpression struct {
+		Node
 		Line      int
 		Callee    Expression
 		Arguments []Expression
 	}
 
+	// FunctionExpression is the struct used for anonymous functions (eg: fn(a) { return a; }, or (a) => a).
+	FunctionExpression struct {
+		Node
+		Line       int
+		Parameters []*token.Token
+		Body       []Statement
+		Syntax     FunctionSyntax
+	}
+
 	// GroupingExpression is the struct used for grouping (eg: wrapping an expression with parentheses to indicate a group).
 	GroupingExpression struct {
+		Node
 		Expression Expression
 	}
 
 	// LiteralExpression is the struct used for literals.
 	LiteralExpression struct {
+		Node
 		Value interface{}
 	}
 
 	// UnaryExpression is the struct used for unary expressions.
 	UnaryExpression struct {
+		Node
 		Operator   *token.Token
 		Expression Expression
 	}
 
 	// LogicalExpression is the struct used for logical expressions (eg: if condition).
 	LogicalExpression struct {
+		Node
 		Left     Expression
 		Operator *token.Token
 		Right    Expression
@@ -48,8 +64,80 @@ type (
 
 	// VariableExpression is the struct used for variable expressions.
 	VariableExpression struct {
+		Node
 		Name *token.Token
 	}
+
+	// GetExpression is the struct used to get a property of an object (eg: a.b).
+	GetExpression struct {
+		Node
+		Object Expression
+		Name   *token.Token
+	}
+
+	// SetExpression is the struct used to set a property of an object (eg: a.b = 1).
+	SetExpression struct {
+		Node
+		Object Expression
+		Name   *token.Token
+		Value  Expression
+	}
+
+	// ThisExpression is the struct used for the "this" keyword (the instance a method is bound to).
+	ThisExpression struct {
+		Node
+		Keyword *token.Token
+	}
+
+	// SuperExpression is the struct used to access a method of the superclass (eg: super.a).
+	SuperExpression struct {
+		Node
+		Keyword *token.Token
+		Method  *token.Token
+	}
+
+	// ListExpression is the struct used for list literals (eg: [1, 2, 3]).
+	ListExpression struct {
+		Node
+		Elements []Expression
+	}
+
+	// MapExpression is the struct used for map literals (eg: {"a": 1, "b": 2}).
+	MapExpression struct {
+		Node
+		Line   int
+		Keys   []Expression
+		Values []Expression
+	}
+
+	// IndexExpression is the struct used to get an element by its index or key (eg: list[0], map["a"]).
+	IndexExpression struct {
+		Node
+		Line   int
+		Object Expression
+		Index  Expression
+	}
+
+	// IndexAssignmentExpression is the struct used to set an element by its index or key (eg: list[0] = 1, map["a"] = 1).
+	IndexAssignmentExpression struct {
+		Node
+		Line   int
+		Object Expression
+		Index  Expression
+		Value  Expression
+	}
+)
+
+// FunctionSyntax is the syntax used to write an anonymous function.
+type FunctionSyntax int
+
+const (
+	// FnSyntax is the syntax of the functions declared with the fn keyword (eg: fn(a) { return a; }).
+	FnSyntax FunctionSyntax = iota
+	// ArrowBlockSyntax is the syntax of the arrow functions with a block body (eg: (a) => { return a; }).
+	ArrowBlockSyntax
+	// ArrowExpressionSyntax is the syntax of the arrow functions with an expression body (eg: (a) => a).
+	ArrowExpressionSyntax
 )
 
 func NewAssignmentExpression(name *token.Token, val Expression) *AssignmentExpression {
@@ -87,6 +175,18 @@ func (e *CallExpression) Accept(visitor ExpressionVisitor) (interface{}, error)
 	return visitor.VisitCallExpression(e)
 }
 
+func NewFunctionExpression(line int, params []*token.Token, body []Statement) *FunctionExpression {
+	return &FunctionExpression{
+		Line:       line,
+		Parameters: params,
+		Body:       body,
+	}
+}
+
+func (e *FunctionExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitFunctionExpression(e)
+}
+
 func NewGroupingExpression(expression Expression) *GroupingExpression {
 	return &GroupingExpression{
 		Expression: expression,
@@ -139,3 +239,94 @@ func NewVariableExpression(name *token.Token) *VariableExpression {
 func (e *VariableExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
 	return visitor.VisitVariableExpression(e)
 }
+
+func NewListExpression(elements []Expression) *ListExpression {
+	return &ListExpression{
+		Elements: elements,
+	}
+}
+
+func (e *ListExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitListExpression(e)
+}
+
+func NewMapExpression(line int, keys []Expression, values []Expression) *MapExpression {
+	return &MapExpression{
+		Line:   line,
+		Keys:   keys,
+		Values: values,
+	}
+}
+
+func (e *MapExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitMapExpression(e)
+}
+
+func NewIndexExpression(line int, object Expression, index Expression) *IndexExpression {
+	return &IndexExpression{
+		Line:   line,
+		Object: object,
+		Index:  index,
+	}
+}
+
+func (e *IndexExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitIndexExpression(e)
+}
+
+func NewIndexAssignmentExpression(line int, object Expression, index Expression, value Expression) *IndexAssignmentExpression {
+	return &IndexAssignmentExpression{
+		Line:   line,
+		Object: object,
+		Index:  index,
+		Value:  value,
+	}
+}
+
+func (e *IndexAssignmentExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitIndexAssignmentExpression(e)
+}
+
+func NewGetExpression(object Expression, name *token.Token) *GetExpression {
+	return &GetExpression{
+		Object: object,
+		Name:   name,
+	}
+}
+
+func (e *GetExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitGetExpression(e)
+}
+
+func NewSetExpression(object Expression, name *token.Token, value Expression) *SetExpression {
+	return &SetExpression{
+		Object: object,
+		Name:   name,
+		Value:  value,
+	}
+}
+
+func (e *SetExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitSetExpression(e)
+}
+
+func NewThisExpression(keyword *token.Token) *ThisExpression {
+	return &ThisExpression{
+		Keyword: keyword,
+	}
+}
+
+func (e *ThisExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitThisExpression(e)
+}
+
+func NewSuperExpression(keyword *token.Token, method *token.Token) *SuperExpression {
+	return &SuperExpression{
+		Keyword: keyword,
+		Method:  method,
+	}
+}
+
+func (e *SuperExpression) Accept(visitor ExpressionVisitor) (interface{}, error) {
+	return visitor.VisitSuperExpression(e)
+}
@@ -2,14 +2,20 @@
 // The AST is implemented using the Visitor pattern.
 package ast
 
+import "github.com/avazquezcode/govetryx/internal/domain/token"
+
 // Expression is an interface for an expression.
 type Expression interface {
 	Accept(visitor ExpressionVisitor) (interface{}, error)
+	Position() token.Span
+	SetPosition(span token.Span)
 }
 
 // Statement is an interface for a statement.
 type Statement interface {
 	Accept(visitor StatementVisitor) error
+	Position() token.Span
+	SetPosition(span token.Span)
 }
 
 // ExpressionVisitor ...
@@ -22,6 +28,15 @@ type ExpressionVisitor interface {
 	VisitLogicalExpression(expression *LogicalExpression) (interface{}, error)
 	VisitLiteralExpression(expression *LiteralExpression) (interface{}, error)
 	VisitCallExpression(expression *CallExpression) (interface{}, error)
+	VisitFunctionExpression(expression *FunctionExpression) (interface{}, error)
+	VisitListExpression(expression *ListExpression) (interface{}, error)
+	VisitMapExpression(expression *MapExpression) (interface{}, error)
+	VisitIndexExpression(expression *IndexExpression) (interface{}, error)
+	VisitIndexAssignmentExpression(expression *IndexAssignmentExpression) (interface{}, error)
+	VisitGetExpression(expression *GetExpression) (interface{}, error)
+	VisitSetExpression(expression *SetExpression) (interface{}, error)
+	VisitThisExpression(expression *ThisExpression) (interface{}, error)
+	VisitSuperExpression(expression *SuperExpression) (interface{}, error)
 }
 
 // StatementVisitor ...
@@ -30,10 +45,16 @@ type StatementVisitor interface {
 	VisitReturnStatement(statement *ReturnStatement) error
 	VisitVariableStatement(statement *VariableStatement) error
 	VisitFunctionStatement(statement *FunctionStatement) error
+	VisitClassStatement(statement *ClassStatement) error
+	VisitImportStatement(statement *ImportStatement) error
 	VisitIfStatement(statement *IfStatement) error
 	VisitPrintStatement(statement *PrintStatement) error
 	VisitBlockStatement(statement *BlockStatement) error
 	VisitWhileStatement(statement *WhileStatement) error
+	VisitForStatement(statement *ForStatement) error
+	VisitForInStatement(statement *ForInStatement) error
 	VisitBreakStatement(statement *BreakStatement) error
 	VisitContinueStatement(statement *ContinueStatement) error
+	VisitThrowStatement(statement *ThrowStatement) error
+	VisitTryStatement(statement *TryStatement) error
 }
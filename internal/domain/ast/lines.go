@@ -0,0 +1,7 @@
+package ast
+
+// LineRange is the range of lines of a statement in the source code (from its first token to its last one).
+type LineRange struct {
+	Start int
+	End   int
+}
@@ -0,0 +1,19 @@
+package ast
+
+import "github.com/avazquezcode/govetryx/internal/domain/token"
+
+// Node is embedded in all the expressions and statements, with their position in the source code.
+// The position is set by the parser (the nodes built in another way, eg: by the optimizer, might not have it).
+type Node struct {
+	Span token.Span
+}
+
+// Position returns the span of the node in the source code.
+func (n *Node) Position() token.Span {
+	return n.Span
+}
+
+// SetPosition sets the span of the node in the source code.
+func (n *Node) SetPosition(span token.Span) {
+	n.Span = span
+}
@@ -5,58 +5,124 @@ import "github.com/avazquezcode/govetryx/internal/domain/token"
 type (
 	// BlockStatement is the struct used to represent block statements (eg: the block of a while loop).
 	BlockStatement struct {
+		Node
 		Statements []Statement
 	}
 
+	// ClassStatement is the struct used to represent a class declaration.
+	ClassStatement struct {
+		Node
+		Name       *token.Token
+		Superclass *VariableExpression
+		Methods    []*FunctionStatement
+	}
+
 	// ExpressionStatement is the struct used to represent an expression statement.
 	ExpressionStatement struct {
+		Node
 		Expression Expression
 	}
 
 	// FunctionStatement is the struct used to represent a function statement.
 	FunctionStatement struct {
+		Node
 		Name       *token.Token
 		Paremeters []*token.Token
 		Body       []Statement
 	}
 
+	// ImportStatement is the struct used to represent an import (eg: import "lib/strings.vx" as s;).
+	ImportStatement struct {
+		Node
+		Line int
+		Path *token.Token
+		Name *token.Token
+	}
+
 	// PrintStatement is the struct used to represent the print statement.
 	PrintStatement struct {
+		Node
 		Expression Expression
 	}
 
 	// ReturnStatement is the struct used to represent the return statement.
 	ReturnStatement struct {
+		Node
 		Line  int
 		Value Expression
 	}
 
 	// WhileStatement is the struct used to represent the while statement.
 	WhileStatement struct {
+		Node
+		Line      int
 		Condition Expression
 		Body      Statement
 	}
 
+	// ForStatement is the struct used to represent a C-style for statement (eg: for i := 0; i < 3; i = i + 1 {}).
+	// The initializer, the condition and the post expression are optional.
+	ForStatement struct {
+		Node
+		Line        int
+		Initializer Statement
+		Condition   Expression
+		Post        Expression
+		Body        Statement
+	}
+
+	// ForInStatement is the struct used to represent a range-based for statement (eg: for k, v in map {}).
+	ForInStatement struct {
+		Node
+		Line      int
+		Variables []*token.Token // one or two loop variables
+		Iterable  Expression
+		Body      Statement
+	}
+
 	// IfStatement is the struct used to represent an if condition statement.
 	IfStatement struct {
+		Node
 		Condition Expression
 		ThenBlock Statement
 		ElseBlock Statement
 	}
 
+	// ThrowStatement is the struct used to represent the throw statement.
+	ThrowStatement struct {
+		Node
+		Line  int
+		Value Expression
+	}
+
+	// TryStatement is the struct used to represent a try statement (eg: try {} catch (e) {} finally {}).
+	// The catch or the finally can be omitted (but not both).
+	TryStatement struct {
+		Node
+		Line        int
+		Body        Statement
+		CatchName   *token.Token // variable where the caught error is set
+		CatchBody   Statement
+		FinallyBody Statement
+	}
+
 	// VariableStatement is the struct used to represent a variable statement.
 	VariableStatement struct {
+		Node
 		Name  *token.Token
 		Value Expression
+		Short bool // declared with the short declarator (eg: a := 1;)
 	}
 
 	// BreakStatement is the struct used to represent the break statement.
 	BreakStatement struct {
+		Node
 		Line int
 	}
 
 	// ContinueStatement is the struct used to represent the continue statement.
 	ContinueStatement struct {
+		Node
 		Line int
 	}
 )
@@ -71,6 +137,18 @@ func (s *BlockStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitBlockStatement(s)
 }
 
+func NewClassStatement(name *token.Token, superclass *VariableExpression, methods []*FunctionStatement) *ClassStatement {
+	return &ClassStatement{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}
+}
+
+func (s *ClassStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitClassStatement(s)
+}
+
 func NewExpressionStatement(expression Expression) *ExpressionStatement {
 	return &ExpressionStatement{
 		Expression: expression,
@@ -93,6 +171,33 @@ func (s *FunctionStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitFunctionStatement(s)
 }
 
+func NewForStatement(line int, initializer Statement, condition Expression, post Expression, body Statement) *ForStatement {
+	return &ForStatement{
+		Line:        line,
+		Initializer: initializer,
+		Condition:   condition,
+		Post:        post,
+		Body:        body,
+	}
+}
+
+func (s *ForStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitForStatement(s)
+}
+
+func NewForInStatement(line int, variables []*token.Token, iterable Expression, body Statement) *ForInStatement {
+	return &ForInStatement{
+		Line:      line,
+		Variables: variables,
+		Iterable:  iterable,
+		Body:      body,
+	}
+}
+
+func (s *ForInStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitForInStatement(s)
+}
+
 func NewIfStatement(condition Expression, thenBranch Statement, elseBranch Statement) *IfStatement {
 	return &IfStatement{
 		Condition: condition,
@@ -105,6 +210,18 @@ func (s *IfStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitIfStatement(s)
 }
 
+func NewImportStatement(line int, path *token.Token, name *token.Token) *ImportStatement {
+	return &ImportStatement{
+		Line: line,
+		Path: path,
+		Name: name,
+	}
+}
+
+func (s *ImportStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitImportStatement(s)
+}
+
 func NewPrintStatement(expression Expression) *PrintStatement {
 	return &PrintStatement{
 		Expression: expression,
@@ -137,8 +254,9 @@ func (s *VariableStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitVariableStatement(s)
 }
 
-func NewWhileStatement(condition Expression, body Statement) *WhileStatement {
+func NewWhileStatement(line int, condition Expression, body Statement) *WhileStatement {
 	return &WhileStatement{
+		Line:      line,
 		Condition: condition,
 		Body:      body,
 	}
@@ -167,3 +285,28 @@ func NewContinueStatement(line int) *ContinueStatement {
 func (s *ContinueStatement) Accept(visitor StatementVisitor) error {
 	return visitor.VisitContinueStatement(s)
 }
+
+func NewThrowStatement(line int, value Expression) *ThrowStatement {
+	return &ThrowStatement{
+		Line:  line,
+		Value: value,
+	}
+}
+
+func (s *ThrowStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitThrowStatement(s)
+}
+
+func NewTryStatement(line int, body Statement, catchName *token.Token, catchBody Statement, finallyBody Statement) *TryStatement {
+	return &TryStatement{
+		Line:        line,
+		Body:        body,
+		CatchName:   catchName,
+		CatchBody:   catchBody,
+		FinallyBody: finallyBody,
+	}
+}
+
+func (s *TryStatement) Accept(visitor StatementVisitor) error {
+	return visitor.VisitTryStatement(s)
+}
@@ -0,0 +1,100 @@
+package bytecode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Disassemble returns a human readable listing of the instructions of all the functions of the program.
+func Disassemble(program *Program) string {
+	var listing strings.Builder
+	for index, function := range program.Functions {
+		if index > 0 {
+			listing.WriteString("\n")
+		}
+		fmt.Fprintf(&listing, "== %s ==\n", functionName(program, index))
+
+		for offset := 0; offset < len(function.Code); {
+			offset = disassembleInstruction(&listing, program, function, offset)
+		}
+	}
+	return listing.String()
+}
+
+// disassembleInstruction writes the instruction at the offset, and returns the offset of the next one.
+func disassembleInstruction(listing *strings.Builder, program *Program, function *Function, offset int) int {
+	fmt.Fprintf(listing, "%04d ", offset)
+	if offset > 0 && function.Lines[offset] == function.Lines[offset-1] {
+		listing.WriteString("   | ")
+	} else {
+		fmt.Fprintf(listing, "%4d ", function.Lines[offset])
+	}
+
+	op := OpCode(function.Code[offset])
+	next := offset + op.Width()
+	if next > len(function.Code) {
+		fmt.Fprintf(listing, "%s (truncated)\n", op)
+		return len(function.Code)
+	}
+
+	switch op {
+	case OpConstant, OpDefineGlobal, OpGetGlobal, OpSetGlobal, OpMethod, OpGetProperty, OpSetProperty, OpGetSuper, OpImport:
+		index := function.ReadUint16(offset + 1)
+		fmt.Fprintf(listing, "%-20s %4d %s\n", op, index, constantString(program, index))
+	case OpClass:
+		index := function.ReadUint16(offset + 1)
+		fmt.Fprintf(listing, "%-20s %4d %s (superclass: %t)\n", op, index, constantString(program, index), function.Code[offset+3] == 1)
+	case OpGetLocal, OpSetLocal, OpGetUpvalue, OpSetUpvalue, OpCloseUpvalues, OpCall, OpIterInit:
+		fmt.Fprintf(listing, "%-20s %4d\n", op, function.Code[offset+1])
+	case OpList, OpMap:
+		fmt.Fprintf(listing, "%-20s %4d\n", op, function.ReadUint16(offset+1))
+	case OpJump, OpJumpIfFalse, OpIterNext:
+		fmt.Fprintf(listing, "%-20s %4d -> %d\n", op, offset, next+function.ReadUint16(offset+1))
+	case OpLoop:
+		fmt.Fprintf(listing, "%-20s %4d -> %d\n", op, offset, next-function.ReadUint16(offset+1))
+	case OpTry:
+		fmt.Fprintf(listing, "%-20s catch: %s, finally: %s\n", op,
+			handlerTarget(next, function.ReadUint16(offset+1)), handlerTarget(next, function.ReadUint16(offset+3)))
+	case OpClosure:
+		index := function.ReadUint16(offset + 1)
+		fmt.Fprintf(listing, "%-20s %4d %s\n", op, index, functionName(program, index))
+	default:
+		fmt.Fprintf(listing, "%s\n", op)
+	}
+
+	return next
+}
+
+// functionName returns the name used to refer to a function in the listing.
+func functionName(program *Program, index int) string {
+	if index == 0 {
+		return "<script>"
+	}
+	if index >= len(program.Functions) {
+		return "<invalid function>"
+	}
+	if program.Functions[index].Name == "" {
+		return "<fn anonymous>"
+	}
+	return fmt.Sprintf("<fn %s>", program.Functions[index].Name)
+}
+
+// constantString returns the representation of a constant used in the listing (strings are quoted).
+func constantString(program *Program, index int) string {
+	if index >= len(program.Constants) {
+		return "<invalid constant>"
+	}
+	if str, isString := program.Constants[index].(string); isString {
+		return strconv.Quote(str)
+	}
+	return fmt.Sprintf("%v", program.Constants[index])
+}
+
+// handlerTarget returns the target of an exception handler (or "none", when the handler is missing).
+func handlerTarget(next int, offset int) string {
+	if offset == 0 {
+		return "none"
+	}
+	return strconv.Itoa(next + offset)
+}
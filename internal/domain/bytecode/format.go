@@ -0,0 +1,404 @@
+package bytecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// FormatVersion is the version of the binary format of the compiled programs.
+// It must be increased on any change of the format or of the instructions, since the programs are only
+// loaded by the same version that wrote them.
+const FormatVersion = 1
+
+// magic identifies the files with compiled programs.
+var magic = [4]byte{'V', 'X', 'C', 0}
+
+// headerSize is the size of the header: magic, version (u16), payload size (u32) and payload checksum (u32).
+const headerSize = 4 + 2 + 4 + 4
+
+// Tags of the constants.
+const (
+	numberTag byte = 1
+	stringTag byte = 2
+)
+
+var (
+	// ErrInvalidFormat is the error returned when the data is not a compiled program.
+	ErrInvalidFormat = errors.New("the data is not a valid compiled program")
+	// ErrVersionMismatch is the error returned when the program was compiled with another version of the format.
+	ErrVersionMismatch = errors.New("the compiled program has an unsupported version")
+	// ErrChecksumMismatch is the error returned when the content of the program doesn't match its checksum.
+	ErrChecksumMismatch = errors.New("the compiled program is corrupted")
+)
+
+// Encode serializes a program into its binary format.
+//
+// The format is a header (the magic "VXC\0", the version, the size of the payload and its CRC-32 checksum),
+// followed by the payload: the constant pool, and the function table (with the name, the arity, the upvalues,
+// the code and the line table of each function). All the integers are big endian.
+func Encode(program *Program) ([]byte, error) {
+	var payload bytes.Buffer
+	w := &writer{buf: &payload}
+
+	w.uint32(len(program.Constants))
+	for _, constant := range program.Constants {
+		switch value := constant.(type) {
+		case float64:
+			w.byte(numberTag)
+			w.uint64(math.Float64bits(value))
+		case string:
+			w.byte(stringTag)
+			w.string(value)
+		default:
+			return nil, fmt.Errorf("cannot encode a constant of type %T", constant)
+		}
+	}
+
+	w.uint32(len(program.Functions))
+	for _, function := range program.Functions {
+		w.string(function.Name)
+		w.uint16(function.Arity)
+
+		w.uint16(len(function.Upvalues))
+		for _, upvalue := range function.Upvalues {
+			isLocal := byte(0)
+			if upvalue.IsLocal {
+				isLocal = 1
+			}
+			w.byte(isLocal)
+			w.byte(byte(upvalue.Index))
+		}
+
+		w.uint32(len(function.Code))
+		w.buf.Write(function.Code)
+
+		// the line table is run-length encoded, since consecutive bytes usually share the line
+		runs := lineRuns(function.Lines)
+		w.uint32(len(runs))
+		for _, run := range runs {
+			w.uint32(run.line)
+			w.uint32(run.count)
+		}
+	}
+
+	data := make([]byte, headerSize, headerSize+payload.Len())
+	copy(data, magic[:])
+	binary.BigEndian.PutUint16(data[4:], FormatVersion)
+	binary.BigEndian.PutUint32(data[6:], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(data[10:], crc32.ChecksumIEEE(payload.Bytes()))
+	return append(data, payload.Bytes()...), nil
+}
+
+// Decode deserializes a program from its binary format, failing with ErrInvalidFormat, ErrVersionMismatch
+// or ErrChecksumMismatch when the data can't be loaded.
+// The code of the functions is verified (see Verify), but not its stack usage, so a program crafted by hand can
+// still be rejected by the VM while it runs (with a runtime error that wraps ErrInvalidFormat).
+func Decode(data []byte) (*Program, error) {
+	if len(data) < headerSize || !bytes.Equal(data[:4], magic[:]) {
+		return nil, ErrInvalidFormat
+	}
+
+	version := binary.BigEndian.Uint16(data[4:])
+	if version != FormatVersion {
+		return nil, fmt.Errorf("%w (got %d, expected %d)", ErrVersionMismatch, version, FormatVersion)
+	}
+
+	size := binary.BigEndian.Uint32(data[6:])
+	payload := data[headerSize:]
+	if uint64(len(payload)) != uint64(size) || crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[10:]) {
+		return nil, ErrChecksumMismatch
+	}
+
+	program, err := decodePayload(&reader{data: payload})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, err)
+	}
+
+	err = program.Verify()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, err)
+	}
+
+	return program, nil
+}
+
+func decodePayload(r *reader) (*Program, error) {
+	program := &Program{}
+
+	constants := r.uint32()
+	for i := 0; i < constants && r.err == nil; i++ {
+		switch tag := r.byte(); tag {
+		case numberTag:
+			program.Constants = append(program.Constants, math.Float64frombits(r.uint64()))
+		case stringTag:
+			program.Constants = append(program.Constants, r.string())
+		default:
+			return nil, fmt.Errorf("unknown constant tag %d", tag)
+		}
+	}
+
+	functions := r.uint32()
+	for i := 0; i < functions && r.err == nil; i++ {
+		function := &Function{
+			Name:  r.string(),
+			Arity: r.uint16(),
+		}
+
+		upvalues := r.uint16()
+		for j := 0; j < upvalues && r.err == nil; j++ {
+			function.Upvalues = append(function.Upvalues, Upvalue{IsLocal: r.byte() == 1, Index: int(r.byte())})
+		}
+
+		function.Code = r.bytes(r.uint32())
+
+		runs := r.uint32()
+		for j := 0; j < runs && r.err == nil; j++ {
+			line, count := r.uint32(), r.uint32()
+			if len(function.Lines)+count > len(function.Code) {
+				return nil, fmt.Errorf("the line table of the function #%d is larger than its code", i)
+			}
+			for k := 0; k < count; k++ {
+				function.Lines = append(function.Lines, line)
+			}
+		}
+
+		program.Functions = append(program.Functions, function)
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if r.offset != len(r.data) {
+		return nil, fmt.Errorf("unexpected data after the function table")
+	}
+
+	return program, nil
+}
+
+// Verify checks that the code of the functions is well-formed: the instructions are known and complete,
+// the constants and functions they refer to exist, and the jumps land on instructions of the code.
+// The slots of the locals and the depth of the stack are not checked (they are checked by the VM).
+func (p *Program) Verify() error {
+	if len(p.Functions) == 0 {
+		return fmt.Errorf("the program has no functions")
+	}
+
+	for index, function := range p.Functions {
+		err := p.verifyFunction(function)
+		if err != nil {
+			return fmt.Errorf("function #%d: %w", index, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Program) verifyFunction(f *Function) error {
+	if len(f.Lines) != len(f.Code) {
+		return fmt.Errorf("the line table doesn't match the code")
+	}
+
+	if len(f.Code) == 0 || OpCode(f.Code[len(f.Code)-1]) != OpReturn {
+		return fmt.Errorf("the code must end with a return")
+	}
+
+	starts := map[int]bool{} // offsets where the instructions start
+	jumps := map[int][]int{} // targets of the jumps, by the offset of their instructions
+	for offset := 0; offset < len(f.Code); {
+		op := OpCode(f.Code[offset])
+		if _, ok := definitions[op]; !ok {
+			return fmt.Errorf("unknown instruction %d at %d", op, offset)
+		}
+
+		next := offset + op.Width()
+		if next > len(f.Code) {
+			return fmt.Errorf("incomplete instruction %s at %d", op, offset)
+		}
+
+		err := p.verifyOperands(f, op, offset+1, next)
+		if err != nil {
+			return fmt.Errorf("%s at %d: %w", op, offset, err)
+		}
+
+		starts[offset] = true
+		jumps[offset] = jumpTargets(f, op, offset+1, next)
+		offset = next
+	}
+
+	for offset, targets := range jumps {
+		for _, target := range targets {
+			if !starts[target] {
+				return fmt.Errorf("%s at %d: the jump doesn't land on an instruction", OpCode(f.Code[offset]), offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+// jumpTargets returns the offsets where an instruction can make the code continue, besides the next instruction.
+func jumpTargets(f *Function, op OpCode, operand int, next int) []int {
+	switch op {
+	case OpJump, OpJumpIfFalse, OpIterNext:
+		return []int{next + f.ReadUint16(operand)}
+	case OpLoop:
+		return []int{next - f.ReadUint16(operand)}
+	case OpTry:
+		var targets []int
+		for _, handler := range []int{f.ReadUint16(operand), f.ReadUint16(operand + 2)} {
+			if handler != 0 { // the catch or the finally is missing
+				targets = append(targets, next+handler)
+			}
+		}
+		return targets
+	}
+	return nil
+}
+
+func (p *Program) verifyOperands(f *Function, op OpCode, operand int, next int) error {
+	switch op {
+	case OpConstant:
+		if f.ReadUint16(operand) >= len(p.Constants) {
+			return fmt.Errorf("unknown constant")
+		}
+	case OpDefineGlobal, OpGetGlobal, OpSetGlobal, OpClass, OpMethod, OpGetProperty, OpSetProperty, OpGetSuper, OpImport:
+		index := f.ReadUint16(operand)
+		if index >= len(p.Constants) {
+			return fmt.Errorf("unknown constant")
+		}
+		if _, isString := p.Constants[index].(string); !isString {
+			return fmt.Errorf("the name must be a string")
+		}
+	case OpClosure:
+		index := f.ReadUint16(operand)
+		if index == 0 || index >= len(p.Functions) {
+			return fmt.Errorf("unknown function")
+		}
+	case OpJump, OpJumpIfFalse, OpIterNext:
+		if next+f.ReadUint16(operand) > len(f.Code) {
+			return fmt.Errorf("the jump lands outside the code")
+		}
+	case OpLoop:
+		if next-f.ReadUint16(operand) < 0 {
+			return fmt.Errorf("the jump lands outside the code")
+		}
+	case OpTry:
+		if next+f.ReadUint16(operand) > len(f.Code) || next+f.ReadUint16(operand+2) > len(f.Code) {
+			return fmt.Errorf("the handler lands outside the code")
+		}
+	case OpGetUpvalue, OpSetUpvalue:
+		if int(f.Code[operand]) >= len(f.Upvalues) {
+			return fmt.Errorf("unknown upvalue")
+		}
+	}
+
+	return nil
+}
+
+// lineRun is a run of consecutive bytes of code in the same line.
+type lineRun struct {
+	line  int
+	count int
+}
+
+func lineRuns(lines []int) []lineRun {
+	var runs []lineRun
+	for _, line := range lines {
+		if len(runs) > 0 && runs[len(runs)-1].line == line {
+			runs[len(runs)-1].count++
+			continue
+		}
+		runs = append(runs, lineRun{line: line, count: 1})
+	}
+	return runs
+}
+
+// writer writes the big endian integers and the strings of the payload.
+type writer struct {
+	buf *bytes.Buffer
+}
+
+func (w *writer) byte(b byte) {
+	w.buf.WriteByte(b)
+}
+
+func (w *writer) uint16(n int) {
+	w.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+}
+
+func (w *writer) uint32(n int) {
+	w.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+}
+
+func (w *writer) uint64(n uint64) {
+	w.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+}
+
+func (w *writer) string(s string) {
+	w.uint32(len(s))
+	w.buf.WriteString(s)
+}
+
+// reader reads the payload, keeping the first error (so the reads can be chained, and checked once).
+type reader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(r.data)-r.offset {
+		r.err = fmt.Errorf("unexpected end of data")
+		return nil
+	}
+
+	b := make([]byte, n)
+	copy(b, r.data[r.offset:])
+	r.offset += n
+	return b
+}
+
+func (r *reader) byte() byte {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) uint16() int {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(b))
+}
+
+func (r *reader) uint32() int {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(b))
+}
+
+func (r *reader) uint64() uint64 {
+	b := r.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (r *reader) string() string {
+	return string(r.bytes(r.uint32()))
+}
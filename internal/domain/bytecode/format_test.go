@@ -0,0 +1,173 @@
+package bytecode_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	"github.com/stretchr/testify/assert"
+)
+
+// newProgram returns a program that prints a constant, and returns a closure of another function.
+func newProgram() *bytecode.Program {
+	main := &bytecode.Function{}
+	main.WriteInstruction(1, bytecode.OpConstant, 0)
+	main.WriteInstruction(1, bytecode.OpPrint)
+	main.WriteInstruction(2, bytecode.OpClosure, 1)
+	main.WriteInstruction(3, bytecode.OpReturn)
+
+	fn := &bytecode.Function{Name: "f", Arity: 1, Upvalues: []bytecode.Upvalue{{IsLocal: true, Index: 1}}}
+	fn.WriteInstruction(2, bytecode.OpGetUpvalue, 0)
+	fn.WriteInstruction(2, bytecode.OpReturn)
+
+	return &bytecode.Program{
+		Constants: []interface{}{"hello", 1.5, math.Copysign(0, -1)},
+		Functions: []*bytecode.Function{main, fn},
+	}
+}
+
+func TestEncodeAndDecode(t *testing.T) {
+	program := newProgram()
+
+	data, err := bytecode.Encode(program)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("VXC\x00"), data[:4])
+	assert.Equal(t, uint16(bytecode.FormatVersion), binary.BigEndian.Uint16(data[4:]))
+
+	decoded, err := bytecode.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, program, decoded)
+	assert.True(t, math.Signbit(decoded.Constants[2].(float64)))
+}
+
+func TestEncodeFailsWithInvalidConstants(t *testing.T) {
+	program := newProgram()
+	program.Constants = append(program.Constants, true)
+
+	_, err := bytecode.Encode(program)
+	assert.Error(t, err)
+}
+
+func TestDecodeFails(t *testing.T) {
+	valid, err := bytecode.Encode(newProgram())
+	assert.NoError(t, err)
+
+	invalidCode := newProgram()
+	invalidCode.Functions[0].Code[2] = 9 // the constant doesn't exist
+	invalidCodeData, err := bytecode.Encode(invalidCode)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		data        func() []byte
+		expectedErr error
+	}{
+		"empty data": {
+			data:        func() []byte { return nil },
+			expectedErr: bytecode.ErrInvalidFormat,
+		},
+		"wrong magic": {
+			data: func() []byte {
+				data := append([]byte{}, valid...)
+				data[0] = 'X'
+				return data
+			},
+			expectedErr: bytecode.ErrInvalidFormat,
+		},
+		"another version": {
+			data: func() []byte {
+				data := append([]byte{}, valid...)
+				binary.BigEndian.PutUint16(data[4:], bytecode.FormatVersion+1)
+				return data
+			},
+			expectedErr: bytecode.ErrVersionMismatch,
+		},
+		"corrupted payload": {
+			data: func() []byte {
+				data := append([]byte{}, valid...)
+				data[len(data)-5] ^= 0xff
+				return data
+			},
+			expectedErr: bytecode.ErrChecksumMismatch,
+		},
+		"truncated payload": {
+			data: func() []byte {
+				return valid[:len(valid)-1]
+			},
+			expectedErr: bytecode.ErrChecksumMismatch,
+		},
+		"invalid code": {
+			data:        func() []byte { return invalidCodeData },
+			expectedErr: bytecode.ErrInvalidFormat,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			_, err := bytecode.Decode(test.data())
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := map[string]struct {
+		modify func(program *bytecode.Program)
+	}{
+		"no functions": {
+			modify: func(program *bytecode.Program) { program.Functions = nil },
+		},
+		"unknown instruction": {
+			modify: func(program *bytecode.Program) { program.Functions[0].Code[3] = 0xff },
+		},
+		"incomplete instruction": {
+			modify: func(program *bytecode.Program) {
+				f := program.Functions[0]
+				f.Code, f.Lines = f.Code[:len(f.Code)-1], f.Lines[:len(f.Lines)-1]
+				f.Write(byte(bytecode.OpConstant), 3)
+			},
+		},
+		"unknown function": {
+			modify: func(program *bytecode.Program) { program.Functions[0].Code[6] = 7 },
+		},
+		"unknown upvalue": {
+			modify: func(program *bytecode.Program) { program.Functions[1].Upvalues = nil },
+		},
+		"missing return": {
+			modify: func(program *bytecode.Program) {
+				f := program.Functions[1]
+				f.Code, f.Lines = f.Code[:len(f.Code)-1], f.Lines[:len(f.Lines)-1]
+			},
+		},
+		"jump inside an instruction": {
+			modify: func(program *bytecode.Program) {
+				f := &bytecode.Function{}
+				f.WriteInstruction(1, bytecode.OpJump, 1)
+				f.WriteInstruction(1, bytecode.OpConstant, 0)
+				f.WriteInstruction(1, bytecode.OpReturn)
+				program.Functions[0] = f
+			},
+		},
+		"handler inside an instruction": {
+			modify: func(program *bytecode.Program) {
+				f := &bytecode.Function{}
+				f.WriteInstruction(1, bytecode.OpTry, 0, 2)
+				f.WriteInstruction(1, bytecode.OpConstant, 0)
+				f.WriteInstruction(1, bytecode.OpReturn)
+				program.Functions[0] = f
+			},
+		},
+		"line table doesn't match the code": {
+			modify: func(program *bytecode.Program) { program.Functions[1].Lines = nil },
+		},
+	}
+
+	assert.NoError(t, newProgram().Verify())
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			program := newProgram()
+			test.modify(program)
+			assert.Error(t, program.Verify())
+		})
+	}
+}
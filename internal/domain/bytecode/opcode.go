@@ -0,0 +1,156 @@
+// Package bytecode contains the instructions run by the virtual machine, and the compiled programs.
+package bytecode
+
+// OpCode is the operation code of an instruction.
+// The operands of an instruction follow its operation code (the u16 operands are big endian).
+type OpCode byte
+
+const (
+	// Constants and literals
+	OpConstant OpCode = iota // u16 constant index
+	OpNull
+	OpTrue
+	OpFalse
+	OpPop
+
+	// Variables
+	OpDefineGlobal // u16 name constant index
+	OpGetGlobal    // u16 name constant index
+	OpSetGlobal    // u16 name constant index
+	OpGetLocal     // u8 slot
+	OpSetLocal     // u8 slot
+	OpGetUpvalue   // u8 upvalue index
+	OpSetUpvalue   // u8 upvalue index
+	OpCloseUpvalue
+	OpCloseUpvalues // u8 slot (closes the upvalues from the slot, without popping them)
+
+	// Operators
+	OpEqual
+	OpNotEqual
+	OpGreater
+	OpGreaterOrEqual
+	OpLower
+	OpLowerOrEqual
+	OpAdd
+	OpSubtract
+	OpMultiply
+	OpDivide
+	OpModulus
+	OpNot
+	OpNegate
+
+	// Statements
+	OpPrint
+	OpJump        // u16 forward offset
+	OpJumpIfFalse // u16 forward offset (the condition is not popped)
+	OpLoop        // u16 backward offset
+
+	// Functions
+	OpCall    // u8 quantity of arguments
+	OpClosure // u16 function index
+	OpReturn
+	OpStoreResult // pops the value being returned (while the finally bodies run)
+	OpLoadResult  // pushes the value being returned
+
+	// Collections
+	OpList // u16 quantity of elements
+	OpMap  // u16 quantity of entries
+	OpGetIndex
+	OpSetIndex
+	OpIterInit // u8 quantity of loop variables
+	OpIterNext // u16 forward offset (to jump when the iteration ends)
+
+	// Classes
+	OpClass       // u16 name constant index, u8 1 if the superclass is on the stack
+	OpMethod      // u16 name constant index
+	OpGetProperty // u16 name constant index
+	OpSetProperty // u16 name constant index
+	OpGetSuper    // u16 name constant index
+
+	// Exceptions
+	OpTry // u16 forward offset of the catch, u16 forward offset of the finally (0 when missing)
+	OpPopHandler
+	OpThrow
+	OpRethrow
+
+	// Modules
+	OpImport // u16 path constant index
+)
+
+// definition describes an instruction: its name, and the width (in bytes) of its operands.
+type definition struct {
+	name     string
+	operands []int
+}
+
+var definitions = map[OpCode]definition{
+	OpConstant:       {"OP_CONSTANT", []int{2}},
+	OpNull:           {"OP_NULL", nil},
+	OpTrue:           {"OP_TRUE", nil},
+	OpFalse:          {"OP_FALSE", nil},
+	OpPop:            {"OP_POP", nil},
+	OpDefineGlobal:   {"OP_DEFINE_GLOBAL", []int{2}},
+	OpGetGlobal:      {"OP_GET_GLOBAL", []int{2}},
+	OpSetGlobal:      {"OP_SET_GLOBAL", []int{2}},
+	OpGetLocal:       {"OP_GET_LOCAL", []int{1}},
+	OpSetLocal:       {"OP_SET_LOCAL", []int{1}},
+	OpGetUpvalue:     {"OP_GET_UPVALUE", []int{1}},
+	OpSetUpvalue:     {"OP_SET_UPVALUE", []int{1}},
+	OpCloseUpvalue:   {"OP_CLOSE_UPVALUE", nil},
+	OpCloseUpvalues:  {"OP_CLOSE_UPVALUES", []int{1}},
+	OpEqual:          {"OP_EQUAL", nil},
+	OpNotEqual:       {"OP_NOT_EQUAL", nil},
+	OpGreater:        {"OP_GREATER", nil},
+	OpGreaterOrEqual: {"OP_GREATER_OR_EQUAL", nil},
+	OpLower:          {"OP_LOWER", nil},
+	OpLowerOrEqual:   {"OP_LOWER_OR_EQUAL", nil},
+	OpAdd:            {"OP_ADD", nil},
+	OpSubtract:       {"OP_SUBTRACT", nil},
+	OpMultiply:       {"OP_MULTIPLY", nil},
+	OpDivide:         {"OP_DIVIDE", nil},
+	OpModulus:        {"OP_MODULUS", nil},
+	OpNot:            {"OP_NOT", nil},
+	OpNegate:         {"OP_NEGATE", nil},
+	OpPrint:          {"OP_PRINT", nil},
+	OpJump:           {"OP_JUMP", []int{2}},
+	OpJumpIfFalse:    {"OP_JUMP_IF_FALSE", []int{2}},
+	OpLoop:           {"OP_LOOP", []int{2}},
+	OpCall:           {"OP_CALL", []int{1}},
+	OpClosure:        {"OP_CLOSURE", []int{2}},
+	OpReturn:         {"OP_RETURN", nil},
+	OpStoreResult:    {"OP_STORE_RESULT", nil},
+	OpLoadResult:     {"OP_LOAD_RESULT", nil},
+	OpList:           {"OP_LIST", []int{2}},
+	OpMap:            {"OP_MAP", []int{2}},
+	OpGetIndex:       {"OP_GET_INDEX", nil},
+	OpSetIndex:       {"OP_SET_INDEX", nil},
+	OpIterInit:       {"OP_ITER_INIT", []int{1}},
+	OpIterNext:       {"OP_ITER_NEXT", []int{2}},
+	OpClass:          {"OP_CLASS", []int{2, 1}},
+	OpMethod:         {"OP_METHOD", []int{2}},
+	OpGetProperty:    {"OP_GET_PROPERTY", []int{2}},
+	OpSetProperty:    {"OP_SET_PROPERTY", []int{2}},
+	OpGetSuper:       {"OP_GET_SUPER", []int{2}},
+	OpTry:            {"OP_TRY", []int{2, 2}},
+	OpPopHandler:     {"OP_POP_HANDLER", nil},
+	OpThrow:          {"OP_THROW", nil},
+	OpRethrow:        {"OP_RETHROW", nil},
+	OpImport:         {"OP_IMPORT", []int{2}},
+}
+
+// String returns the name of the operation code.
+func (o OpCode) String() string {
+	if definition, ok := definitions[o]; ok {
+		return definition.name
+	}
+	return "OP_UNKNOWN"
+}
+
+// Width returns the quantity of bytes of the instruction (including its operands).
+func (o OpCode) Width() int {
+	width := 1
+	for _, operand := range definitions[o].operands {
+		width += operand
+	}
+	return width
+}
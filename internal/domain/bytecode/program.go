@@ -0,0 +1,71 @@
+package bytecode
+
+import "github.com/avazquezcode/govetryx/internal/domain/token"
+
+type (
+	// Program is the result of compiling a file: the functions (the first one is the top-level code
+	// of the file), and the constants used by them.
+	Program struct {
+		Constants []interface{} // numbers and strings
+		Functions []*Function
+	}
+
+	// Function is a compiled function (the prototype of the closures created at runtime).
+	Function struct {
+		Name     string // empty for anonymous functions and the top-level code
+		Arity    int
+		Upvalues []Upvalue // variables of the enclosing functions captured by the function
+		Code     []byte
+		Lines    []int        // line of the source code of each byte of the code
+		Spans    []token.Span // span of the source code of each byte of the code (not kept in the binary format)
+	}
+
+	// Upvalue describes a variable captured by a function.
+	Upvalue struct {
+		IsLocal bool // true if it is a local of the enclosing function, false if it is an upvalue of it
+		Index   int  // slot of the local, or index of the upvalue, in the enclosing function
+	}
+)
+
+// Main returns the function with the top-level code of the program.
+func (p *Program) Main() *Function {
+	return p.Functions[0]
+}
+
+// Write appends a byte to the code of the function.
+func (f *Function) Write(b byte, line int) {
+	f.Code = append(f.Code, b)
+	f.Lines = append(f.Lines, line)
+}
+
+// Span returns the span of the source code of the byte at the given offset of the code
+// (the loaded programs don't have it, since they don't have source code).
+func (f *Function) Span(offset int) token.Span {
+	if offset >= len(f.Spans) {
+		return token.Span{}
+	}
+	return f.Spans[offset]
+}
+
+// ReadUint16 reads the u16 operand at the given offset of the code.
+func (f *Function) ReadUint16(offset int) int {
+	return int(f.Code[offset])<<8 | int(f.Code[offset+1])
+}
+
+// WriteInstruction appends an instruction (with its operands) to the code of the function, returning its offset.
+func (f *Function) WriteInstruction(line int, op OpCode, operands ...int) int {
+	offset := len(f.Code)
+	f.Write(byte(op), line)
+	for index, width := range definitions[op].operands {
+		operand := 0
+		if index < len(operands) {
+			operand = operands[index]
+		}
+
+		if width == 2 {
+			f.Write(byte(operand>>8), line)
+		}
+		f.Write(byte(operand), line)
+	}
+	return offset
+}
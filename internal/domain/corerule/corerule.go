@@ -1,6 +1,12 @@
 package corerule
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+)
 
 // IsTrue is the rule used to determine whether something should be evaluated to true or false.
 func IsTrue(value interface{}) bool {
@@ -17,16 +23,70 @@ func IsTrue(value interface{}) bool {
 }
 
 // PrintableValue converts an interface into a printable value.
+// A list or a map that contains itself (directly or not) is printed as [...] or {...} inside itself.
 func PrintableValue(value interface{}) string {
+	return printable(value, map[interface{}]bool{})
+}
+
+// printable converts a value into a printable value, skipping the collections that are being printed (visiting).
+func printable(value interface{}, visiting map[interface{}]bool) string {
 	if value == nil {
 		// in this language, nil is represented as "null"
 		return "null"
 	}
+
+	if list, isList := value.(*types.List); isList {
+		if visiting[list] {
+			return "[...]"
+		}
+		visiting[list] = true
+		defer delete(visiting, list)
+
+		elements := make([]string, 0, list.Len())
+		for _, element := range list.Elements {
+			elements = append(elements, printableElement(element, visiting))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+
+	if m, isMap := value.(*types.Map); isMap {
+		if visiting[m] {
+			return "{...}"
+		}
+		visiting[m] = true
+		defer delete(visiting, m)
+
+		entries := make([]string, 0, m.Len())
+		for _, key := range m.Keys() {
+			value, _ := m.Get(key)
+			entries = append(entries, printableElement(key, visiting)+": "+printableElement(value, visiting))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+
 	return fmt.Sprintf("%v", value)
 }
 
+// printableElement converts an element of a collection into a printable value.
+// Strings are quoted, so they can be distinguished from other values (eg: "1" vs 1).
+func printableElement(value interface{}, visiting map[interface{}]bool) string {
+	if str, isString := value.(string); isString {
+		return strconv.Quote(str)
+	}
+	return printable(value, visiting)
+}
+
 // IsEqual is the rule used to determine whether two values are equal.
+// Lists are equal when they have the same elements, in the same order.
+// Maps are equal when they have the same keys, with equal values (no matter the order).
+// A collection is always equal to itself, and the ones that contain themselves are compared without recursing forever.
 func IsEqual(a interface{}, b interface{}) bool {
+	return isEqual(a, b, map[[2]interface{}]bool{})
+}
+
+// isEqual compares two values, assuming that the pairs of collections being compared (visiting) are equal, so the
+// comparison of the collections that contain themselves ends (if they differ, it's found elsewhere).
+func isEqual(a interface{}, b interface{}, visiting map[[2]interface{}]bool) bool {
 	if a == nil && b == nil {
 		return true
 	}
@@ -35,5 +95,69 @@ func IsEqual(a interface{}, b interface{}) bool {
 		return false
 	}
 
+	listA, isList := a.(*types.List)
+	if isList {
+		listB, isList := b.(*types.List)
+		return isList && isEqualList(listA, listB, visiting)
+	}
+
+	mapA, isMap := a.(*types.Map)
+	if isMap {
+		mapB, isMap := b.(*types.Map)
+		return isMap && isEqualMap(mapA, mapB, visiting)
+	}
+
 	return a == b
 }
+
+func isEqualList(a *types.List, b *types.List, visiting map[[2]interface{}]bool) bool {
+	if a == b {
+		return true
+	}
+
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	pair := [2]interface{}{a, b}
+	if visiting[pair] {
+		return true
+	}
+	visiting[pair] = true
+	defer delete(visiting, pair)
+
+	for i := range a.Elements {
+		if !isEqual(a.Get(i), b.Get(i), visiting) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isEqualMap(a *types.Map, b *types.Map, visiting map[[2]interface{}]bool) bool {
+	if a == b {
+		return true
+	}
+
+	if a.Len() != b.Len() {
+		return false
+	}
+
+	pair := [2]interface{}{a, b}
+	if visiting[pair] {
+		return true
+	}
+	visiting[pair] = true
+	defer delete(visiting, pair)
+
+	for _, key := range a.Keys() {
+		valueA, _ := a.Get(key)
+		valueB, exists := b.Get(key)
+		if !exists || !isEqual(valueA, valueB, visiting) {
+			return false
+		}
+	}
+
+	return true
+}
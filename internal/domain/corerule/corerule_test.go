@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	"github.com/avazquezcode/govetryx/internal/domain/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,16 +79,91 @@ func TestIsEqual(t *testing.T) {
 			b:        "2",
 			expected: false,
 		},
+		"list = list": {
+			a:        types.NewList([]interface{}{1.0, "a", types.NewList([]interface{}{true})}),
+			b:        types.NewList([]interface{}{1.0, "a", types.NewList([]interface{}{true})}),
+			expected: true,
+		},
+		"list <> list (different elements)": {
+			a:        types.NewList([]interface{}{1.0, "a"}),
+			b:        types.NewList([]interface{}{1.0, "b"}),
+			expected: false,
+		},
+		"list <> list (different length)": {
+			a:        types.NewList([]interface{}{1.0}),
+			b:        types.NewList([]interface{}{1.0, 2.0}),
+			expected: false,
+		},
+		"map = map (different order)": {
+			a:        newMap("a", 1.0, "b", types.NewList(nil)),
+			b:        newMap("b", types.NewList(nil), "a", 1.0),
+			expected: true,
+		},
+		"map <> map (different values)": {
+			a:        newMap("a", 1.0),
+			b:        newMap("a", 2.0),
+			expected: false,
+		},
+		"map <> map (different keys)": {
+			a:        newMap("a", 1.0),
+			b:        newMap("b", 1.0),
+			expected: false,
+		},
+		"map <> map (different length)": {
+			a:        newMap("a", 1.0),
+			b:        newMap("a", 1.0, "b", 1.0),
+			expected: false,
+		},
+		"list that contains itself = itself": {
+			a:        selfList(1.0),
+			b:        nil,
+			expected: true,
+		},
+		"lists that contain themselves = (same elements)": {
+			a:        selfList(1.0),
+			b:        selfList(1.0),
+			expected: true,
+		},
+		"lists that contain themselves <> (different elements)": {
+			a:        selfList(1.0),
+			b:        selfList(2.0),
+			expected: false,
+		},
+		"map that contains itself = itself": {
+			a:        selfMap("a", 1.0),
+			b:        nil,
+			expected: true,
+		},
+		"maps that contain themselves = (same entries)": {
+			a:        selfMap("a", 1.0),
+			b:        selfMap("a", 1.0),
+			expected: true,
+		},
+		"maps that contain themselves <> (different entries)": {
+			a:        selfMap("a", 1.0),
+			b:        selfMap("a", 2.0),
+			expected: false,
+		},
+		"list <> something else": {
+			a:        types.NewList(nil),
+			b:        "[]",
+			expected: false,
+		},
 	}
 
 	for desc, test := range tests {
 		t.Run(desc, func(t *testing.T) {
+			if test.b == nil && test.a != nil {
+				test.b = test.a // compared with itself
+			}
 			assert.Equal(t, test.expected, corerule.IsEqual(test.a, test.b))
 		})
 	}
 }
 
 func TestPrintableValue(t *testing.T) {
+	shared := types.NewList([]interface{}{1.0})
+
 	tests := map[string]struct {
 		value    interface{}
 		expected string
@@ -124,6 +200,38 @@ func TestPrintableValue(t *testing.T) {
 			value:    1.751,
 			expected: "1.751",
 		},
+		"empty list": {
+			value:    types.NewList(nil),
+			expected: "[]",
+		},
+		"empty map": {
+			value:    types.NewMap(),
+			expected: "{}",
+		},
+		"map with entries": {
+			value:    newMap("b", 1.0, 2.0, "two", true, types.NewList(nil)),
+			expected: `{"b": 1, 2: "two", true: []}`,
+		},
+		"list with elements": {
+			value:    types.NewList([]interface{}{1.0, "a", nil, types.NewList([]interface{}{false})}),
+			expected: `[1, "a", null, [false]]`,
+		},
+		"list that contains itself": {
+			value:    selfList(1.0),
+			expected: "[1, [...]]",
+		},
+		"map that contains itself": {
+			value:    selfMap("a", 1.0),
+			expected: `{"a": 1, "self": {...}}`,
+		},
+		"list and map that contain each other": {
+			value:    types.NewList([]interface{}{selfMap("a", 1.0), newMap("l", selfList())}),
+			expected: `[{"a": 1, "self": {...}}, {"l": [[...]]}]`,
+		},
+		"list that contains the same list twice": {
+			value:    types.NewList([]interface{}{shared, shared}),
+			expected: "[[1], [1]]",
+		},
 	}
 
 	for desc, test := range tests {
@@ -132,3 +240,25 @@ func TestPrintableValue(t *testing.T) {
 		})
 	}
 }
+
+func newMap(entries ...interface{}) *types.Map {
+	m := types.NewMap()
+	for i := 0; i < len(entries); i += 2 {
+		m.Set(entries[i], entries[i+1])
+	}
+	return m
+}
+
+// selfList returns a list with the given elements, followed by the list itself.
+func selfList(elements ...interface{}) *types.List {
+	list := types.NewList(elements)
+	list.Push(list)
+	return list
+}
+
+// selfMap returns a map with the given entries, followed by the key "self" with the map itself.
+func selfMap(entries ...interface{}) *types.Map {
+	m := newMap(entries...)
+	m.Set("self", m)
+	return m
+}
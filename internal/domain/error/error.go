@@ -1,12 +1,50 @@
 package error
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+var (
+	// ErrCanceled is the error returned when the execution is canceled.
+	ErrCanceled = errors.New("the execution was canceled")
+	// ErrDeadlineExceeded is the error returned when the execution exceeds its deadline.
+	ErrDeadlineExceeded = errors.New("the execution exceeded its deadline")
+
+	// ErrStepLimitExceeded is the error returned when the execution exceeds the max quantity of steps.
+	ErrStepLimitExceeded = errors.New("the execution exceeded the max quantity of steps")
+	// ErrCallDepthExceeded is the error returned when the execution exceeds the max call depth.
+	ErrCallDepthExceeded = errors.New("the execution exceeded the max call depth")
+	// ErrStringTooLong is the error returned when a string exceeds the max string length.
+	ErrStringTooLong = errors.New("the string exceeds the max length")
+	// ErrBindingsLimitExceeded is the error returned when the execution exceeds the max quantity of bindings.
+	ErrBindingsLimitExceeded = errors.New("the execution exceeded the max quantity of bindings")
 )
 
+// maxPrintedFrames is the max quantity of frames of a stack trace that are printed (eg: a runaway recursion
+// can leave thousands of them).
+const maxPrintedFrames = 20
+
 type RuntimeError struct {
 	Message string
 	Line    int
+	File    string     // the imported file where the error happened (empty for the program being run, see InFile)
+	Span    token.Span // the code where the error happened (if known)
+	Excerpt string     // the excerpt of the source code where the error happened (see WithExcerpt)
+	Trace   []Frame    // the calls that the error went out of, from the innermost one (see WithFrame)
+	Err     error      // the underlying error (if any)
+
+	placed bool // whether the file where the error happened is already set
+}
+
+// Frame is a call of a function that was running when a runtime error happened.
+type Frame struct {
+	Function string
+	Line     int    // line of the call
+	File     string // imported file of the call (empty for the program being run)
 }
 
 func NewRuntimeError(message string, line int) RuntimeError {
@@ -16,10 +54,139 @@ func NewRuntimeError(message string, line int) RuntimeError {
 	}
 }
 
+// WrapRuntimeError is a constructor for a runtime error caused by another error.
+// If the error is already a runtime error that points to a line, it is kept as it is
+// (so the line is always the one where the error happened, and the message is not repeated).
+func WrapRuntimeError(err error, line int) RuntimeError {
+	var runtimeErr RuntimeError
+	if errors.As(err, &runtimeErr) {
+		if runtimeErr.Line == 0 {
+			runtimeErr.Line = line
+		}
+		return runtimeErr
+	}
+
+	return RuntimeError{
+		Message: err.Error(),
+		Line:    line,
+		Err:     err,
+	}
+}
+
+// WithFrame returns the error as a runtime error (see WrapRuntimeError), adding the call of a function to the
+// end of its stack trace. The frames are added while the error goes out of the calls, so the innermost one is
+// the first of the trace.
+func WithFrame(err error, frame Frame) RuntimeError {
+	runtimeErr := WrapRuntimeError(err, frame.Line)
+	runtimeErr.Trace = append(runtimeErr.Trace, frame)
+	return runtimeErr
+}
+
+// InFile sets the file where a runtime error happened (empty for the program being run), unless it's already set.
+// Like the span (see Locate), the file of the innermost code that fails is the one that is kept.
+func InFile(err error, file string) error {
+	runtimeErr, ok := err.(RuntimeError)
+	if !ok || runtimeErr.placed {
+		return err
+	}
+
+	runtimeErr.File = file
+	runtimeErr.placed = true
+	return runtimeErr
+}
+
+// Locate sets the span of the code where a runtime error happened, unless it already has one.
+// Since the innermost node that fails is the first one to see the error, that is the span that is kept.
+// The span is only set if it contains the line of the error (eg: a multi-line call fails at its closing parentheses).
+func Locate(err error, span token.Span) error {
+	runtimeErr, ok := err.(RuntimeError)
+	if !ok || runtimeErr.Span.IsKnown() || !span.IsKnown() {
+		return err
+	}
+
+	if runtimeErr.Line < span.Start.Line || runtimeErr.Line > span.End.Line {
+		return err
+	}
+
+	runtimeErr.Span = span
+	return runtimeErr
+}
+
+// WithExcerpt returns the error with the excerpt of the source code of the program being run where it happened
+// (the span is underlined when it's known, otherwise the whole line is shown).
+func (r RuntimeError) WithExcerpt(source string) RuntimeError {
+	return r.WithFileExcerpt("", source)
+}
+
+// WithFileExcerpt is like WithExcerpt, for the source code of a file (see InFile). The error is kept as it is if
+// it happened in another file, or if it already has an excerpt.
+func (r RuntimeError) WithFileExcerpt(file string, source string) RuntimeError {
+	if r.File != file || r.Excerpt != "" {
+		return r
+	}
+
+	span := r.Span
+	if !span.IsKnown() {
+		span = token.Span{Start: token.Position{Line: r.Line}}
+	}
+	r.Excerpt = Excerpt(source, span)
+	return r
+}
+
 func (r RuntimeError) Error() string {
+	message := r.Message
 	if r.Line != 0 {
-		return fmt.Sprintf("runtime error occurred at line %d: %s", r.Line, r.Message)
+		message = fmt.Sprintf("runtime error occurred at %s: %s", location(r.Line, r.File), r.Message)
+	} else if r.File != "" {
+		message = fmt.Sprintf("runtime error occurred in %s: %s", r.File, r.Message)
+	}
+
+	if r.Excerpt != "" {
+		message = message + "\n" + r.Excerpt
 	}
 
-	return r.Message
+	if len(r.Trace) > 0 {
+		message = message + "\n" + r.trace()
+	}
+	return message
+}
+
+// trace returns the printable stack trace, from the innermost call (the consecutive calls from the same line
+// are collapsed, eg: in a recursion).
+func (r RuntimeError) trace() string {
+	var b strings.Builder
+	b.WriteString("stack trace:")
+	printed := 0
+	for i := 0; i < len(r.Trace); i++ {
+		if printed == maxPrintedFrames {
+			fmt.Fprintf(&b, "\n  ... %d more", len(r.Trace)-i)
+			break
+		}
+
+		frame := r.Trace[i]
+		fmt.Fprintf(&b, "\n  at %s (called at %s)", frame.Function, location(frame.Line, frame.File))
+		printed++
+
+		repeated := 0
+		for i+1 < len(r.Trace) && r.Trace[i+1] == frame {
+			repeated++
+			i++
+		}
+		if repeated > 0 {
+			fmt.Fprintf(&b, "\n  ... repeated %d more times", repeated)
+		}
+	}
+	return b.String()
+}
+
+// location returns the printable line of a file (the file is omitted for the program being run).
+func location(line int, file string) string {
+	if file == "" {
+		return fmt.Sprintf("line %d", line)
+	}
+	return fmt.Sprintf("line %d of %s", line, file)
+}
+
+func (r RuntimeError) Unwrap() error {
+	return r.Err
 }
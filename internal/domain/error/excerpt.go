@@ -0,0 +1,64 @@
+package error
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+// Excerpt returns the line of the source code where a span starts, with the span underlined by carets. Eg:
+//
+//	1 | print 1 + null;
+//	  |       ^^^^^^^^
+//
+// A span that ends in another line is underlined until the end of its first line, and a span without column
+// (eg: one that only knows its line) is not underlined at all.
+// It returns an empty string if the span is not in the source code (eg: it points to the code of an imported module).
+func Excerpt(source string, span token.Span) string {
+	lines := strings.Split(source, "\n")
+	if source == "" || span.Start.Line < 1 || span.Start.Line > len(lines) {
+		return ""
+	}
+
+	line := strings.TrimRight(lines[span.Start.Line-1], "\r")
+	number := fmt.Sprint(span.Start.Line)
+	gutter := strings.Repeat(" ", len(number))
+	excerpt := fmt.Sprintf("%s | %s", number, line)
+	if span.Start.Column < 1 {
+		return excerpt
+	}
+
+	chars := []rune(line)
+	if span.Start.Column-1 > len(chars) || offset(lines, span.Start) != span.Start.Offset {
+		return ""
+	}
+
+	start := span.Start.Column - 1
+	end := len(chars)
+	if span.End.Line == span.Start.Line {
+		end = min(span.End.Column-1, len(chars))
+	}
+
+	// the tabs are kept, so the carets are aligned with the code
+	var padding strings.Builder
+	for _, char := range chars[:start] {
+		if char == '\t' {
+			padding.WriteRune('\t')
+			continue
+		}
+		padding.WriteByte(' ')
+	}
+
+	carets := strings.Repeat("^", max(end-start, 1))
+	return fmt.Sprintf("%s\n%s | %s%s", excerpt, gutter, padding.String(), carets)
+}
+
+// offset returns the offset (in bytes) of the line and column of a position.
+func offset(lines []string, position token.Position) int {
+	offset := 0
+	for _, line := range lines[:position.Line-1] {
+		offset += len(line) + 1 // the line break
+	}
+	return offset + len(string([]rune(lines[position.Line-1])[:position.Column-1]))
+}
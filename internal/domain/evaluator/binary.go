@@ -78,7 +78,7 @@ type (
 )
 
 func (a *Different) Evaluate() (interface{}, error) {
-	return a.left != a.right, nil
+	return !corerule.IsEqual(a.left, a.right), nil
 }
 
 func (a *Equal) Evaluate() (interface{}, error) {
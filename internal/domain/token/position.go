@@ -0,0 +1,19 @@
+package token
+
+// Position is a position in the source code.
+type Position struct {
+	Line   int // starts at 1
+	Column int // starts at 1 (counted in chars, so a multi-byte char takes one column)
+	Offset int // starts at 0 (counted in bytes)
+}
+
+// Span is a range of the source code, from the start position (included) to the end position (excluded).
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// IsKnown returns true if the span points to the source code (eg: the nodes built without the parser have no span).
+func (s Span) IsKnown() bool {
+	return s.Start.Line != 0
+}
@@ -7,6 +7,7 @@ type Token struct {
 	Lexeme  string
 	Literal interface{}
 	Line    int
+	Span    Span // position of the token in the source code (set by the scanner)
 }
 
 // NewToken is a constructor for a new token.
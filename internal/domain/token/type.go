@@ -6,6 +6,8 @@ type Type int
 const (
 	// Loop
 	While Type = iota
+	For
+	In
 	Break
 	Continue
 
@@ -21,6 +23,21 @@ const (
 	Fn
 	Return
 
+	// Classes
+	Class
+	This
+	Super
+
+	// Modules
+	Import
+	As
+
+	// Exceptions
+	Throw
+	Try
+	Catch
+	Finally
+
 	// Types
 	Identifier
 	Number
@@ -42,7 +59,11 @@ const (
 	RightBrace
 	LeftParentheses
 	RightParentheses
+	LeftBracket
+	RightBracket
 	Comma
+	Dot
+	Colon
 	Slash
 	Hashtag
 	Star
@@ -54,10 +75,14 @@ const (
 	Greater
 	GreaterOrEqual
 	Semicolon
+	Arrow
 
 	// Inbuilt functions
 	Print
 
+	// Invalid code (eg: an unexpected char, or a string that is not closed)
+	Illegal
+
 	// End of file
 	EOF
 )
@@ -71,9 +96,20 @@ var ReservedWordsMapper = map[string]Type{
 	"if":       If,
 	"else":     Else,
 	"while":    While,
+	"for":      For,
+	"in":       In,
 	"break":    Break,
 	"continue": Continue,
 	"print":    Print,
 	"return":   Return,
 	"null":     Null,
+	"class":    Class,
+	"this":     This,
+	"super":    Super,
+	"import":   Import,
+	"as":       As,
+	"throw":    Throw,
+	"try":      Try,
+	"catch":    Catch,
+	"finally":  Finally,
 }
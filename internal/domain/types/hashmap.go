@@ -6,6 +6,12 @@ func (h HashMap) Get(key interface{}) interface{} {
 	return h[key]
 }
 
+// Lookup returns the value of the key, and whether it exists (in a single access to the map).
+func (h HashMap) Lookup(key interface{}) (interface{}, bool) {
+	value, exists := h[key]
+	return value, exists
+}
+
 func (h HashMap) Exists(key interface{}) bool {
 	_, exists := h[key]
 	return exists
@@ -0,0 +1,48 @@
+package types
+
+// List is the type used to represent the lists of the language.
+// It is used through a pointer, so all the references to the same list see its changes.
+type List struct {
+	Elements []interface{}
+}
+
+// NewList is a constructor for a list.
+func NewList(elements []interface{}) *List {
+	return &List{
+		Elements: elements,
+	}
+}
+
+func (l *List) Len() int {
+	return len(l.Elements)
+}
+
+func (l *List) Get(index int) interface{} {
+	return l.Elements[index]
+}
+
+func (l *List) Set(index int, value interface{}) {
+	l.Elements[index] = value
+}
+
+func (l *List) Push(value interface{}) {
+	l.Elements = append(l.Elements, value)
+}
+
+// Pop removes the last element of the list, and returns it.
+func (l *List) Pop() interface{} {
+	if l.Len() == 0 {
+		return nil // nothing to pop
+	}
+
+	last := l.Elements[l.Len()-1]
+	l.Elements = l.Elements[:l.Len()-1]
+	return last
+}
+
+// Slice returns a new list, with the elements between start (inclusive) and end (exclusive).
+func (l *List) Slice(start int, end int) *List {
+	elements := make([]interface{}, end-start)
+	copy(elements, l.Elements[start:end])
+	return NewList(elements)
+}
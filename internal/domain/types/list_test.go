@@ -0,0 +1,110 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListPush(t *testing.T) {
+	tests := map[string]struct {
+		list     *types.List
+		element  interface{}
+		expected *types.List
+	}{
+		"empty list": {
+			list:     types.NewList(nil),
+			element:  1,
+			expected: types.NewList([]interface{}{1}),
+		},
+		"list with elements": {
+			list:     types.NewList([]interface{}{1, 2}),
+			element:  "a",
+			expected: types.NewList([]interface{}{1, 2, "a"}),
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			test.list.Push(test.element)
+			assert.Equal(t, test.expected, test.list)
+		})
+	}
+}
+
+func TestListPop(t *testing.T) {
+	tests := map[string]struct {
+		list            *types.List
+		expectedElement interface{}
+		expected        *types.List
+	}{
+		"empty list": {
+			list:            types.NewList(nil),
+			expectedElement: nil,
+			expected:        types.NewList(nil),
+		},
+		"list with elements": {
+			list:            types.NewList([]interface{}{1, 2}),
+			expectedElement: 2,
+			expected:        types.NewList([]interface{}{1}),
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			assert.Equal(t, test.expectedElement, test.list.Pop())
+			assert.Equal(t, test.expected, test.list)
+		})
+	}
+}
+
+func TestListGetAndSet(t *testing.T) {
+	list := types.NewList([]interface{}{1, 2, 3})
+	list.Set(1, "b")
+
+	assert.Equal(t, 3, list.Len())
+	assert.Equal(t, 1, list.Get(0))
+	assert.Equal(t, "b", list.Get(1))
+}
+
+func TestListSlice(t *testing.T) {
+	tests := map[string]struct {
+		list     *types.List
+		start    int
+		end      int
+		expected *types.List
+	}{
+		"full slice": {
+			list:     types.NewList([]interface{}{1, 2, 3}),
+			start:    0,
+			end:      3,
+			expected: types.NewList([]interface{}{1, 2, 3}),
+		},
+		"partial slice": {
+			list:     types.NewList([]interface{}{1, 2, 3}),
+			start:    1,
+			end:      2,
+			expected: types.NewList([]interface{}{2}),
+		},
+		"empty slice": {
+			list:     types.NewList([]interface{}{1, 2, 3}),
+			start:    1,
+			end:      1,
+			expected: types.NewList([]interface{}{}),
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			slice := test.list.Slice(test.start, test.end)
+			assert.Equal(t, test.expected, slice)
+
+			// the slice is a copy, so changing it does not change the original list
+			if slice.Len() > 0 {
+				slice.Set(0, "changed")
+				assert.NotEqual(t, "changed", test.list.Get(test.start))
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package types
+
+// Map is the type used to represent the maps of the language.
+// It keeps the insertion order of its keys, so it can be iterated (and printed) deterministically.
+// It is used through a pointer, so all the references to the same map see its changes.
+type Map struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+// NewMap is a constructor for an empty map.
+func NewMap() *Map {
+	return &Map{
+		values: map[interface{}]interface{}{},
+	}
+}
+
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+func (m *Map) Get(key interface{}) (interface{}, bool) {
+	value, exists := m.values[key]
+	return value, exists
+}
+
+func (m *Map) Exists(key interface{}) bool {
+	_, exists := m.values[key]
+	return exists
+}
+
+// Set sets the value of a key (new keys are added at the end of the map).
+func (m *Map) Set(key interface{}, value interface{}) {
+	if !m.Exists(key) {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Delete removes a key from the map, returning true if the key existed.
+func (m *Map) Delete(key interface{}) bool {
+	if !m.Exists(key) {
+		return false
+	}
+
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Keys returns the keys of the map, in insertion order.
+func (m *Map) Keys() []interface{} {
+	keys := make([]interface{}, len(m.keys))
+	copy(keys, m.keys)
+	return keys
+}
+
+// Values returns the values of the map, in the insertion order of their keys.
+func (m *Map) Values() []interface{} {
+	values := make([]interface{}, 0, len(m.keys))
+	for _, key := range m.keys {
+		values = append(values, m.values[key])
+	}
+	return values
+}
@@ -0,0 +1,70 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapSet(t *testing.T) {
+	tests := map[string]struct {
+		entries        [][2]interface{}
+		expectedKeys   []interface{}
+		expectedValues []interface{}
+	}{
+		"empty map": {
+			expectedKeys:   []interface{}{},
+			expectedValues: []interface{}{},
+		},
+		"keys keep the insertion order": {
+			entries:        [][2]interface{}{{"b", 1}, {"a", 2}, {1.0, 3}},
+			expectedKeys:   []interface{}{"b", "a", 1.0},
+			expectedValues: []interface{}{1, 2, 3},
+		},
+		"updating a key keeps its position": {
+			entries:        [][2]interface{}{{"b", 1}, {"a", 2}, {"b", 3}},
+			expectedKeys:   []interface{}{"b", "a"},
+			expectedValues: []interface{}{3, 2},
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			m := types.NewMap()
+			for _, entry := range test.entries {
+				m.Set(entry[0], entry[1])
+			}
+
+			assert.Equal(t, test.expectedKeys, m.Keys())
+			assert.Equal(t, test.expectedValues, m.Values())
+			assert.Equal(t, len(test.expectedKeys), m.Len())
+		})
+	}
+}
+
+func TestMapGet(t *testing.T) {
+	m := types.NewMap()
+	m.Set("a", 1)
+
+	value, exists := m.Get("a")
+	assert.Equal(t, 1, value)
+	assert.True(t, exists)
+
+	value, exists = m.Get("b")
+	assert.Nil(t, value)
+	assert.False(t, exists)
+}
+
+func TestMapDelete(t *testing.T) {
+	m := types.NewMap()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	assert.True(t, m.Delete("b"))
+	assert.False(t, m.Delete("b"))
+	assert.False(t, m.Exists("b"))
+	assert.Equal(t, []interface{}{"a", "c"}, m.Keys())
+	assert.Equal(t, []interface{}{1, 3}, m.Values())
+}
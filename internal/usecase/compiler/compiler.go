@@ -0,0 +1,373 @@
+// Package compiler compiles the AST into the bytecode run by the virtual machine.
+// The statements must be resolved first (the compiler relies on the static checks of the resolver).
+package compiler
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+const (
+	maxLocals    = 256     // the slots of the locals are u8 operands
+	maxUpvalues  = 256     // the indexes of the upvalues are u8 operands
+	maxArguments = 255     // the quantity of arguments is an u8 operand
+	maxConstants = 1 << 16 // the indexes of the constants are u16 operands
+	maxJump      = 1<<16 - 1
+)
+
+// numberKey is the key used to find the index of a number constant.
+type numberKey uint64
+
+// functionType indicates the type of function being compiled.
+type functionType int
+
+const (
+	script functionType = iota
+	function
+	method
+	initializer
+)
+
+type (
+	// Compiler compiles the statements of a file into a program.
+	Compiler struct {
+		program   *bytecode.Program
+		constants map[interface{}]int // indexes of the constants already added to the program
+		current   *functionCompiler
+		line      int        // line of the node being compiled
+		span      token.Span // span of the node being compiled (set along with its line)
+	}
+
+	// functionCompiler keeps the state of a function being compiled.
+	functionCompiler struct {
+		enclosing  *functionCompiler
+		function   *bytecode.Function
+		fnType     functionType
+		locals     []local // locals in the stack (the first slot is the callee, or "this" in methods)
+		scopeDepth int
+		loops      []*loop     // loops enclosing the code being compiled
+		tries      []*tryBlock // try statements enclosing the code being compiled
+	}
+
+	// local is a variable that lives in the stack.
+	local struct {
+		name     string
+		depth    int
+		captured bool // indicates if a closure captured the local (so its upvalue must be closed when it goes out of scope)
+	}
+
+	// loop keeps the jumps of the break and continue statements of a loop.
+	loop struct {
+		start         int // offset where a continue jumps to (-1 when it is not known yet)
+		localCount    int // locals alive when the body starts (the ones declared in the body are discarded by a break or continue)
+		tryCount      int // quantity of try statements enclosing the loop
+		breakJumps    []int
+		continueJumps []int
+	}
+
+	// tryBlock is a try statement being compiled.
+	tryBlock struct {
+		localCount int // locals alive when the try starts
+		scopeDepth int
+		loopCount  int // quantity of loops enclosing the try
+		tryCount   int // quantity of try statements enclosing the try
+		finally    ast.Statement
+	}
+)
+
+// Compile compiles the statements into a program.
+// When the last statement is an expression statement, the program returns its value (eg: for the REPL).
+func Compile(statements []ast.Statement) (*bytecode.Program, error) {
+	c := &Compiler{
+		program:   &bytecode.Program{},
+		constants: map[interface{}]int{},
+		line:      1, // some nodes don't have a line (eg: literals), so they take the one of the previous nodes
+	}
+	c.beginFunction("", script)
+
+	for index, statement := range statements {
+		last, isExpression := statement.(*ast.ExpressionStatement)
+		if isExpression && index == len(statements)-1 {
+			_, err := last.Expression.Accept(c)
+			if err != nil {
+				return nil, err
+			}
+			c.emit(bytecode.OpReturn)
+			return c.program, nil
+		}
+
+		err := statement.Accept(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.emitReturn()
+	return c.program, nil
+}
+
+// Functions
+
+// beginFunction starts compiling a new function, returning its index in the program.
+func (c *Compiler) beginFunction(name string, fnType functionType) int {
+	c.program.Functions = append(c.program.Functions, &bytecode.Function{Name: name})
+
+	// the first slot is reserved: it has the instance in methods, and the callee otherwise
+	slot := local{}
+	if fnType == method || fnType == initializer {
+		slot.name = "this"
+	}
+
+	c.current = &functionCompiler{
+		enclosing: c.current,
+		function:  c.program.Functions[len(c.program.Functions)-1],
+		fnType:    fnType,
+		locals:    []local{slot},
+	}
+
+	return len(c.program.Functions) - 1
+}
+
+// compileFunction compiles a function, and emits the instruction to create a closure of it.
+func (c *Compiler) compileFunction(name string, params []*token.Token, body []ast.Statement, fnType functionType) error {
+	index := c.beginFunction(name, fnType)
+	c.current.function.Arity = len(params)
+	c.beginScope()
+
+	for _, param := range params {
+		err := c.addLocal(param.Lexeme)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, statement := range body {
+		err := statement.Accept(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.emitReturn()
+	c.current = c.current.enclosing
+	c.emit(bytecode.OpClosure, index)
+	return nil
+}
+
+// emitReturn emits the implicit return at the end of a function (initializers always return "this").
+func (c *Compiler) emitReturn() {
+	if c.current.fnType == initializer {
+		c.emit(bytecode.OpGetLocal, 0)
+	} else {
+		c.emit(bytecode.OpNull)
+	}
+	c.emit(bytecode.OpReturn)
+}
+
+// Emission
+
+// emit emits an instruction, returning its offset.
+func (c *Compiler) emit(op bytecode.OpCode, operands ...int) int {
+	function := c.current.function
+	offset := function.WriteInstruction(c.line, op, operands...)
+	for len(function.Spans) < len(function.Code) {
+		function.Spans = append(function.Spans, c.span)
+	}
+	return offset
+}
+
+// emitConstant emits an instruction which operand is the index of a constant.
+func (c *Compiler) emitConstant(op bytecode.OpCode, value interface{}) error {
+	index, err := c.makeConstant(value)
+	if err != nil {
+		return err
+	}
+	c.emit(op, index)
+	return nil
+}
+
+// makeConstant adds a constant to the program (unless it was already added), returning its index.
+func (c *Compiler) makeConstant(value interface{}) (int, error) {
+	key := value
+	if number, isNumber := value.(float64); isNumber {
+		// the numbers are compared by their bits, so 0 and -0 are different constants (and NaN can be reused)
+		key = numberKey(math.Float64bits(number))
+	}
+
+	if index, ok := c.constants[key]; ok {
+		return index, nil
+	}
+
+	if len(c.program.Constants) >= maxConstants {
+		return 0, fmt.Errorf("too many constants in the program")
+	}
+
+	c.program.Constants = append(c.program.Constants, value)
+	c.constants[key] = len(c.program.Constants) - 1
+	return len(c.program.Constants) - 1, nil
+}
+
+// emitJump emits a forward jump, returning the offset of its operand (to patch it once the target is known).
+func (c *Compiler) emitJump(op bytecode.OpCode) int {
+	return c.emit(op, maxJump) + 1
+}
+
+// patchJump makes the jump with the operand at the given offset, jump to the current end of the code.
+func (c *Compiler) patchJump(operand int) error {
+	return c.patchOffset(operand, operand+2)
+}
+
+// patchOffset sets the operand at the given offset, with the distance from the base to the current end of the code.
+func (c *Compiler) patchOffset(operand int, base int) error {
+	code := c.current.function.Code
+	jump := len(code) - base
+	if jump > maxJump {
+		return fmt.Errorf("too much code to jump over")
+	}
+
+	code[operand] = byte(jump >> 8)
+	code[operand+1] = byte(jump)
+	return nil
+}
+
+// emitLoop emits a backward jump to the given offset.
+func (c *Compiler) emitLoop(start int) error {
+	offset := len(c.current.function.Code) + bytecode.OpLoop.Width() - start
+	if offset > maxJump {
+		return fmt.Errorf("the loop body is too large")
+	}
+
+	c.emit(bytecode.OpLoop, offset)
+	return nil
+}
+
+// Scopes and variables
+
+func (c *Compiler) beginScope() {
+	c.current.scopeDepth++
+}
+
+// endScope ends a scope, discarding its locals.
+func (c *Compiler) endScope() {
+	f := c.current
+	f.scopeDepth--
+
+	count := len(f.locals)
+	for count > 0 && f.locals[count-1].depth > f.scopeDepth {
+		count--
+	}
+
+	c.popLocals(len(f.locals), count)
+	f.locals = f.locals[:count]
+}
+
+// popLocals emits the instructions to discard the locals from the given height down to the given count
+// (closing the upvalues of the captured ones). The locals are not removed from the compiler.
+func (c *Compiler) popLocals(height int, count int) {
+	for index := height - 1; index >= count; index-- {
+		if c.current.locals[index].captured {
+			c.emit(bytecode.OpCloseUpvalue)
+		} else {
+			c.emit(bytecode.OpPop)
+		}
+	}
+}
+
+// addLocal adds a local, which value is the one at the top of the stack.
+func (c *Compiler) addLocal(name string) error {
+	f := c.current
+	if len(f.locals) >= maxLocals {
+		return fmt.Errorf("too many local variables in a function")
+	}
+
+	f.locals = append(f.locals, local{name: name, depth: f.scopeDepth})
+	return nil
+}
+
+// defineVariable defines a variable, which value is the one at the top of the stack.
+// At the top level, the variables are globals (and locals otherwise).
+func (c *Compiler) defineVariable(name string) error {
+	if c.current.scopeDepth > 0 {
+		return c.addLocal(name)
+	}
+	return c.emitConstant(bytecode.OpDefineGlobal, name)
+}
+
+// getVariable emits the instruction to push the value of a variable.
+func (c *Compiler) getVariable(name string) error {
+	return c.accessVariable(name, bytecode.OpGetLocal, bytecode.OpGetUpvalue, bytecode.OpGetGlobal)
+}
+
+// setVariable emits the instruction to assign the value at the top of the stack to a variable.
+func (c *Compiler) setVariable(name string) error {
+	return c.accessVariable(name, bytecode.OpSetLocal, bytecode.OpSetUpvalue, bytecode.OpSetGlobal)
+}
+
+// accessVariable emits the instruction to access a variable, that can be a local, an upvalue or a global.
+func (c *Compiler) accessVariable(name string, localOp bytecode.OpCode, upvalueOp bytecode.OpCode, globalOp bytecode.OpCode) error {
+	if slot := c.current.resolveLocal(name); slot != -1 {
+		c.emit(localOp, slot)
+		return nil
+	}
+
+	upvalue, err := c.current.resolveUpvalue(name)
+	if err != nil {
+		return err
+	}
+	if upvalue != -1 {
+		c.emit(upvalueOp, upvalue)
+		return nil
+	}
+
+	return c.emitConstant(globalOp, name)
+}
+
+// resolveLocal returns the slot of a local of the function (or -1, if it is not a local).
+func (f *functionCompiler) resolveLocal(name string) int {
+	for index := len(f.locals) - 1; index >= 0; index-- {
+		if f.locals[index].name == name {
+			return index
+		}
+	}
+	return -1
+}
+
+// resolveUpvalue returns the index of the upvalue of a variable of an enclosing function
+// (or -1, if it is not a local of any enclosing function).
+func (f *functionCompiler) resolveUpvalue(name string) (int, error) {
+	if f.enclosing == nil {
+		return -1, nil
+	}
+
+	if slot := f.enclosing.resolveLocal(name); slot != -1 {
+		f.enclosing.locals[slot].captured = true
+		return f.addUpvalue(true, slot)
+	}
+
+	upvalue, err := f.enclosing.resolveUpvalue(name)
+	if err != nil || upvalue == -1 {
+		return -1, err
+	}
+	return f.addUpvalue(false, upvalue)
+}
+
+// addUpvalue adds an upvalue to the function (unless it was already added), returning its index.
+func (f *functionCompiler) addUpvalue(isLocal bool, index int) (int, error) {
+	upvalue := bytecode.Upvalue{IsLocal: isLocal, Index: index}
+	for existing, candidate := range f.function.Upvalues {
+		if candidate == upvalue {
+			return existing, nil
+		}
+	}
+
+	if len(f.function.Upvalues) >= maxUpvalues {
+		return -1, fmt.Errorf("too many closure variables in a function")
+	}
+
+	f.function.Upvalues = append(f.function.Upvalues, upvalue)
+	return len(f.function.Upvalues) - 1, nil
+}
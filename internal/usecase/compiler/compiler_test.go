@@ -0,0 +1,159 @@
+package compiler_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	"github.com/avazquezcode/govetryx/internal/usecase/compiler"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	tests := map[string]struct {
+		src                 string
+		expectedDisassembly string
+		expectedErr         bool
+	}{
+		"global variable": {
+			src: "dec a = 1; print a + 1;",
+			expectedDisassembly: `
+== <script> ==
+0000    1 OP_CONSTANT             0 1
+0003    | OP_DEFINE_GLOBAL        1 "a"
+0006    | OP_GET_GLOBAL           1 "a"
+0009    | OP_CONSTANT             0 1
+0012    | OP_ADD
+0013    | OP_PRINT
+0014    | OP_NULL
+0015    | OP_RETURN
+`,
+		},
+		"the last expression statement is returned": {
+			src: "1 + 2;",
+			expectedDisassembly: `
+== <script> ==
+0000    1 OP_CONSTANT             0 1
+0003    | OP_CONSTANT             1 2
+0006    | OP_ADD
+0007    | OP_RETURN
+`,
+		},
+		"local variables in a block": {
+			src: "{ dec a = \"x\"; print a; }",
+			expectedDisassembly: `
+== <script> ==
+0000    1 OP_CONSTANT             0 "x"
+0003    | OP_GET_LOCAL            1
+0005    | OP_PRINT
+0006    | OP_POP
+0007    | OP_NULL
+0008    | OP_RETURN
+`,
+		},
+		"while loop": {
+			src: "while false { print 1; }",
+			expectedDisassembly: `
+== <script> ==
+0000    1 OP_FALSE
+0001    | OP_JUMP_IF_FALSE        1 -> 12
+0004    | OP_POP
+0005    | OP_CONSTANT             0 1
+0008    | OP_PRINT
+0009    | OP_LOOP                 9 -> 0
+0012    | OP_POP
+0013    | OP_NULL
+0014    | OP_RETURN
+`,
+		},
+		"closure capturing a local": {
+			src: "fn counter() { dec c = 0; return () => c; }",
+			expectedDisassembly: `
+== <script> ==
+0000    1 OP_CLOSURE              1 <fn counter>
+0003    | OP_DEFINE_GLOBAL        1 "counter"
+0006    | OP_NULL
+0007    | OP_RETURN
+
+== <fn counter> ==
+0000    1 OP_CONSTANT             0 0
+0003    | OP_CLOSURE              2 <fn anonymous>
+0006    | OP_RETURN
+0007    | OP_NULL
+0008    | OP_RETURN
+
+== <fn anonymous> ==
+0000    1 OP_GET_UPVALUE          0
+0002    | OP_RETURN
+0003    | OP_NULL
+0004    | OP_RETURN
+`,
+		},
+		"try with catch and finally": {
+			src: "try { throw 1; } catch (e) { print e; } finally { print 2; }",
+			expectedDisassembly: `
+== <script> ==
+0000    1 OP_TRY               catch: 17, finally: 29
+0005    | OP_CONSTANT             0 1
+0008    | OP_THROW
+0009    | OP_POP_HANDLER
+0010    | OP_CONSTANT             1 2
+0013    | OP_PRINT
+0014    | OP_JUMP                14 -> 34
+0017    | OP_GET_LOCAL            1
+0019    | OP_PRINT
+0020    | OP_POP
+0021    | OP_POP_HANDLER
+0022    | OP_CONSTANT             1 2
+0025    | OP_PRINT
+0026    | OP_JUMP                26 -> 34
+0029    | OP_CONSTANT             1 2
+0032    | OP_PRINT
+0033    | OP_RETHROW
+0034    | OP_NULL
+0035    | OP_RETURN
+`,
+		},
+		"too many arguments": {
+			src:         "fn f() {} f(" + strings.Repeat("1, ", 255) + "1);",
+			expectedErr: true,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			program, err := compile(t, test.src)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimPrefix(test.expectedDisassembly, "\n"), bytecode.Disassemble(program))
+		})
+	}
+}
+
+func TestCompileDeduplicatesConstants(t *testing.T) {
+	program, err := compile(t, `print 1; print 1; print "a"; print "a"; print 0; print -0;`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1.0, "a", 0.0}, program.Constants)
+}
+
+// compile scans, parses, resolves and compiles the code.
+func compile(t *testing.T, src string) (*bytecode.Program, error) {
+	tokens, err := scanner.NewScanner(bytes.Runes([]byte(src))).Scan()
+	assert.NoError(t, err)
+
+	statements, err := parser.NewParser(tokens).Parse()
+	assert.NoError(t, err)
+
+	err = interpreter.NewResolver(nil).Resolve(statements)
+	assert.NoError(t, err)
+
+	return compiler.Compile(statements)
+}
@@ -0,0 +1,292 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+// binaryOperators maps the binary operators into the instructions that evaluate them.
+var binaryOperators = map[token.Type]bytecode.OpCode{
+	token.EqualEqual:     bytecode.OpEqual,
+	token.NotEqual:       bytecode.OpNotEqual,
+	token.Greater:        bytecode.OpGreater,
+	token.GreaterOrEqual: bytecode.OpGreaterOrEqual,
+	token.Lower:          bytecode.OpLower,
+	token.LowerOrEqual:   bytecode.OpLowerOrEqual,
+	token.Plus:           bytecode.OpAdd,
+	token.Minus:          bytecode.OpSubtract,
+	token.Star:           bytecode.OpMultiply,
+	token.Slash:          bytecode.OpDivide,
+	token.Modulus:        bytecode.OpModulus,
+}
+
+func (c *Compiler) VisitLiteralExpression(expression *ast.LiteralExpression) (interface{}, error) {
+	switch value := expression.Value.(type) {
+	case nil:
+		c.emit(bytecode.OpNull)
+	case bool:
+		if value {
+			c.emit(bytecode.OpTrue)
+		} else {
+			c.emit(bytecode.OpFalse)
+		}
+	default:
+		return nil, c.emitConstant(bytecode.OpConstant, value)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitGroupingExpression(expression *ast.GroupingExpression) (interface{}, error) {
+	return expression.Expression.Accept(c)
+}
+
+func (c *Compiler) VisitUnaryExpression(expression *ast.UnaryExpression) (interface{}, error) {
+	_, err := expression.Expression.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.line = expression.Operator.Line
+	c.span = expression.Position()
+	switch expression.Operator.Type {
+	case token.Minus:
+		c.emit(bytecode.OpNegate)
+	case token.Bang:
+		c.emit(bytecode.OpNot)
+	default:
+		return nil, fmt.Errorf("the operator %q is not valid", expression.Operator.Lexeme)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitBinaryExpression(expression *ast.BinaryExpression) (interface{}, error) {
+	_, err := expression.Left.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = expression.Right.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := binaryOperators[expression.Operator.Type]
+	if !ok {
+		return nil, fmt.Errorf("the operator %q is not valid", expression.Operator.Lexeme)
+	}
+
+	c.line = expression.Operator.Line
+	c.span = expression.Position()
+	c.emit(op)
+	return nil, nil
+}
+
+func (c *Compiler) VisitLogicalExpression(expression *ast.LogicalExpression) (interface{}, error) {
+	_, err := expression.Left.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Implementation of short circuit (the value of the expression is the one of the last operand evaluated)
+	c.line = expression.Operator.Line
+	c.span = expression.Position()
+	endJump := c.emitJump(bytecode.OpJumpIfFalse)
+	if expression.Operator.Type == token.Or {
+		rightJump := endJump
+		endJump = c.emitJump(bytecode.OpJump)
+		err := c.patchJump(rightJump)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c.emit(bytecode.OpPop)
+
+	_, err = expression.Right.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.patchJump(endJump)
+}
+
+func (c *Compiler) VisitVariableExpression(expression *ast.VariableExpression) (interface{}, error) {
+	c.line = expression.Name.Line
+	c.span = expression.Position()
+	return nil, c.getVariable(expression.Name.Lexeme)
+}
+
+func (c *Compiler) VisitAssignmentExpression(expression *ast.AssignmentExpression) (interface{}, error) {
+	_, err := expression.Value.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.line = expression.Name.Line
+	c.span = expression.Position()
+	return nil, c.setVariable(expression.Name.Lexeme)
+}
+
+func (c *Compiler) VisitCallExpression(expression *ast.CallExpression) (interface{}, error) {
+	_, err := expression.Callee.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(expression.Arguments) > maxArguments {
+		return nil, fmt.Errorf("cannot have more than %d arguments", maxArguments)
+	}
+
+	for _, argument := range expression.Arguments {
+		_, err := argument.Accept(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.line = expression.Line
+	c.span = expression.Position()
+	c.emit(bytecode.OpCall, len(expression.Arguments))
+	return nil, nil
+}
+
+func (c *Compiler) VisitFunctionExpression(expression *ast.FunctionExpression) (interface{}, error) {
+	c.line = expression.Line
+	c.span = expression.Position()
+	return nil, c.compileFunction("", expression.Parameters, expression.Body, function)
+}
+
+// Collections
+
+func (c *Compiler) VisitListExpression(expression *ast.ListExpression) (interface{}, error) {
+	if len(expression.Elements) > maxJump {
+		return nil, fmt.Errorf("too many elements in a list literal")
+	}
+
+	for _, element := range expression.Elements {
+		_, err := element.Accept(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.emit(bytecode.OpList, len(expression.Elements))
+	return nil, nil
+}
+
+func (c *Compiler) VisitMapExpression(expression *ast.MapExpression) (interface{}, error) {
+	if len(expression.Keys) > maxJump {
+		return nil, fmt.Errorf("too many entries in a map literal")
+	}
+
+	for index := range expression.Keys {
+		_, err := expression.Keys[index].Accept(c)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = expression.Values[index].Accept(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.line = expression.Line
+	c.span = expression.Position()
+	c.emit(bytecode.OpMap, len(expression.Keys))
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexExpression(expression *ast.IndexExpression) (interface{}, error) {
+	_, err := expression.Object.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = expression.Index.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.line = expression.Line
+	c.span = expression.Position()
+	c.emit(bytecode.OpGetIndex)
+	return nil, nil
+}
+
+func (c *Compiler) VisitIndexAssignmentExpression(expression *ast.IndexAssignmentExpression) (interface{}, error) {
+	_, err := expression.Object.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = expression.Index.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = expression.Value.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.line = expression.Line
+	c.span = expression.Position()
+	c.emit(bytecode.OpSetIndex)
+	return nil, nil
+}
+
+// Classes
+
+func (c *Compiler) VisitGetExpression(expression *ast.GetExpression) (interface{}, error) {
+	_, err := expression.Object.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.line = expression.Name.Line
+	c.span = expression.Position()
+	return nil, c.emitConstant(bytecode.OpGetProperty, expression.Name.Lexeme)
+}
+
+func (c *Compiler) VisitSetExpression(expression *ast.SetExpression) (interface{}, error) {
+	_, err := expression.Object.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = expression.Value.Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.line = expression.Name.Line
+	c.span = expression.Position()
+	return nil, c.emitConstant(bytecode.OpSetProperty, expression.Name.Lexeme)
+}
+
+func (c *Compiler) VisitThisExpression(expression *ast.ThisExpression) (interface{}, error) {
+	c.line = expression.Keyword.Line
+	c.span = expression.Position()
+	return nil, c.getVariable("this")
+}
+
+func (c *Compiler) VisitSuperExpression(expression *ast.SuperExpression) (interface{}, error) {
+	c.line = expression.Keyword.Line
+	c.span = expression.Position()
+	err := c.getVariable("this")
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.getVariable("super")
+	if err != nil {
+		return nil, err
+	}
+
+	c.line = expression.Method.Line
+	c.span = expression.Position()
+	return nil, c.emitConstant(bytecode.OpGetSuper, expression.Method.Lexeme)
+}
@@ -0,0 +1,639 @@
+package compiler
+
+import (
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+)
+
+func (c *Compiler) VisitExpressionStatement(statement *ast.ExpressionStatement) error {
+	_, err := statement.Expression.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	c.emit(bytecode.OpPop)
+	return nil
+}
+
+func (c *Compiler) VisitPrintStatement(statement *ast.PrintStatement) error {
+	_, err := statement.Expression.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	c.emit(bytecode.OpPrint)
+	return nil
+}
+
+func (c *Compiler) VisitVariableStatement(statement *ast.VariableStatement) error {
+	c.line = statement.Name.Line
+	c.span = statement.Position()
+	if statement.Value != nil {
+		_, err := statement.Value.Accept(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.line = statement.Name.Line
+	c.span = statement.Position()
+	if statement.Value == nil {
+		c.emit(bytecode.OpNull)
+	}
+
+	return c.defineVariable(statement.Name.Lexeme)
+}
+
+func (c *Compiler) VisitFunctionStatement(statement *ast.FunctionStatement) error {
+	c.line = statement.Name.Line
+	c.span = statement.Position()
+
+	if c.current.scopeDepth > 0 {
+		// the local is added before compiling the body, so the function can call itself
+		err := c.addLocal(statement.Name.Lexeme)
+		if err != nil {
+			return err
+		}
+		return c.compileFunction(statement.Name.Lexeme, statement.Paremeters, statement.Body, function)
+	}
+
+	err := c.compileFunction(statement.Name.Lexeme, statement.Paremeters, statement.Body, function)
+	if err != nil {
+		return err
+	}
+	return c.emitConstant(bytecode.OpDefineGlobal, statement.Name.Lexeme)
+}
+
+func (c *Compiler) VisitReturnStatement(statement *ast.ReturnStatement) error {
+	c.line = statement.Line
+	c.span = statement.Position()
+
+	switch {
+	case statement.Value != nil:
+		_, err := statement.Value.Accept(c)
+		if err != nil {
+			return err
+		}
+		c.line = statement.Line
+		c.span = statement.Position()
+	case c.current.fnType == initializer:
+		c.emit(bytecode.OpGetLocal, 0)
+	default:
+		c.emit(bytecode.OpNull)
+	}
+
+	if len(c.current.tries) == 0 {
+		c.emit(bytecode.OpReturn)
+		return nil
+	}
+
+	// the value is kept aside while the enclosing try statements are left (running their finally bodies)
+	c.emit(bytecode.OpStoreResult)
+	_, err := c.unwindTries(0)
+	if err != nil {
+		return err
+	}
+
+	c.line = statement.Line
+	c.span = statement.Position()
+	c.emit(bytecode.OpLoadResult)
+	c.emit(bytecode.OpReturn)
+	return nil
+}
+
+func (c *Compiler) VisitBlockStatement(statement *ast.BlockStatement) error {
+	c.beginScope()
+	for _, statement := range statement.Statements {
+		err := statement.Accept(c)
+		if err != nil {
+			return err
+		}
+	}
+	c.endScope()
+	return nil
+}
+
+func (c *Compiler) VisitIfStatement(statement *ast.IfStatement) error {
+	_, err := statement.Condition.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	elseJump := c.emitJump(bytecode.OpJumpIfFalse)
+	c.emit(bytecode.OpPop)
+
+	err = statement.ThenBlock.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	endJump := c.emitJump(bytecode.OpJump)
+	err = c.patchJump(elseJump)
+	if err != nil {
+		return err
+	}
+	c.emit(bytecode.OpPop)
+
+	if statement.ElseBlock != nil {
+		err = statement.ElseBlock.Accept(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.patchJump(endJump)
+}
+
+// Loops
+
+func (c *Compiler) VisitWhileStatement(statement *ast.WhileStatement) error {
+	c.line = statement.Line
+	c.span = statement.Position()
+	start := len(c.current.function.Code)
+	_, err := statement.Condition.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	exitJump := c.emitJump(bytecode.OpJumpIfFalse)
+	c.emit(bytecode.OpPop)
+
+	c.beginLoop(start)
+	err = statement.Body.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	c.line = statement.Line
+	c.span = statement.Position()
+	err = c.emitLoop(start)
+	if err != nil {
+		return err
+	}
+
+	err = c.patchJump(exitJump)
+	if err != nil {
+		return err
+	}
+	c.emit(bytecode.OpPop)
+
+	return c.endLoop()
+}
+
+func (c *Compiler) VisitForStatement(statement *ast.ForStatement) error {
+	// the variables declared in the initializer live in their own scope (that wraps the body)
+	c.beginScope()
+	c.line = statement.Line
+	c.span = statement.Position()
+	localCount := len(c.current.locals)
+
+	if statement.Initializer != nil {
+		err := statement.Initializer.Accept(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	start := len(c.current.function.Code)
+	exitJump := -1
+	if statement.Condition != nil {
+		_, err := statement.Condition.Accept(c)
+		if err != nil {
+			return err
+		}
+		exitJump = c.emitJump(bytecode.OpJumpIfFalse)
+		c.emit(bytecode.OpPop)
+	}
+
+	loop := c.beginLoop(-1)
+	err := statement.Body.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	for _, jump := range loop.continueJumps {
+		err := c.patchJump(jump)
+		if err != nil {
+			return err
+		}
+	}
+
+	// each iteration gets its own copy of the loop variables, so the closures created
+	// in one iteration are not affected by the next ones
+	c.line = statement.Line
+	c.span = statement.Position()
+	for _, local := range c.current.locals[localCount:] {
+		if local.captured {
+			c.emit(bytecode.OpCloseUpvalues, localCount)
+			break
+		}
+	}
+
+	if statement.Post != nil {
+		_, err := statement.Post.Accept(c)
+		if err != nil {
+			return err
+		}
+		c.emit(bytecode.OpPop)
+	}
+
+	c.line = statement.Line
+	c.span = statement.Position()
+	err = c.emitLoop(start)
+	if err != nil {
+		return err
+	}
+
+	if exitJump != -1 {
+		err = c.patchJump(exitJump)
+		if err != nil {
+			return err
+		}
+		c.emit(bytecode.OpPop)
+	}
+
+	err = c.endLoop()
+	if err != nil {
+		return err
+	}
+
+	c.endScope()
+	return nil
+}
+
+func (c *Compiler) VisitForInStatement(statement *ast.ForInStatement) error {
+	// the iterable is evaluated outside the scope of the loop variables
+	c.line = statement.Line
+	c.span = statement.Position()
+	_, err := statement.Iterable.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	// the iterator lives in a hidden local, in the scope of the loop
+	c.beginScope()
+	c.line = statement.Line
+	c.span = statement.Position()
+	c.emit(bytecode.OpIterInit, len(statement.Variables))
+	err = c.addLocal("")
+	if err != nil {
+		return err
+	}
+
+	start := len(c.current.function.Code)
+	exitJump := c.emitJump(bytecode.OpIterNext)
+	c.beginLoop(start)
+
+	// each iteration defines the loop variables in a new scope
+	c.beginScope()
+	for _, variable := range statement.Variables {
+		err := c.addLocal(variable.Lexeme)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = statement.Body.Accept(c)
+	if err != nil {
+		return err
+	}
+	c.endScope()
+
+	c.line = statement.Line
+	c.span = statement.Position()
+	err = c.emitLoop(start)
+	if err != nil {
+		return err
+	}
+
+	err = c.patchJump(exitJump)
+	if err != nil {
+		return err
+	}
+
+	err = c.endLoop()
+	if err != nil {
+		return err
+	}
+
+	c.endScope()
+	return nil
+}
+
+func (c *Compiler) VisitBreakStatement(statement *ast.BreakStatement) error {
+	c.line = statement.Line
+	c.span = statement.Position()
+	loop := c.current.loops[len(c.current.loops)-1]
+
+	err := c.exitLoop(loop)
+	if err != nil {
+		return err
+	}
+
+	loop.breakJumps = append(loop.breakJumps, c.emitJump(bytecode.OpJump))
+	return nil
+}
+
+func (c *Compiler) VisitContinueStatement(statement *ast.ContinueStatement) error {
+	c.line = statement.Line
+	c.span = statement.Position()
+	loop := c.current.loops[len(c.current.loops)-1]
+
+	err := c.exitLoop(loop)
+	if err != nil {
+		return err
+	}
+
+	if loop.start == -1 {
+		loop.continueJumps = append(loop.continueJumps, c.emitJump(bytecode.OpJump))
+		return nil
+	}
+	return c.emitLoop(loop.start)
+}
+
+// beginLoop starts a loop, which body starts right after this point.
+func (c *Compiler) beginLoop(start int) *loop {
+	f := c.current
+	loop := &loop{
+		start:      start,
+		localCount: len(f.locals),
+		tryCount:   len(f.tries),
+	}
+	f.loops = append(f.loops, loop)
+	return loop
+}
+
+// endLoop ends the last loop, making its break statements jump to this point.
+func (c *Compiler) endLoop() error {
+	f := c.current
+	loop := f.loops[len(f.loops)-1]
+	f.loops = f.loops[:len(f.loops)-1]
+
+	for _, jump := range loop.breakJumps {
+		err := c.patchJump(jump)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exitLoop emits the instructions to leave the body of a loop (for a break or a continue): the try statements
+// inside the loop are left, and the locals declared in the body are discarded.
+func (c *Compiler) exitLoop(loop *loop) error {
+	height, err := c.unwindTries(loop.tryCount)
+	if err != nil {
+		return err
+	}
+
+	c.popLocals(height, loop.localCount)
+	return nil
+}
+
+// Exceptions
+
+func (c *Compiler) VisitThrowStatement(statement *ast.ThrowStatement) error {
+	c.line = statement.Line
+	c.span = statement.Position()
+	_, err := statement.Value.Accept(c)
+	if err != nil {
+		return err
+	}
+
+	c.line = statement.Line
+	c.span = statement.Position()
+	c.emit(bytecode.OpThrow)
+	return nil
+}
+
+// VisitTryStatement compiles a try statement.
+// The handler pushed by OpTry makes the VM jump to the catch (with the caught value in the stack), or to the
+// finally (with the pending error in the stack, that is re-thrown at the end) when an error is not caught.
+// When the try or the catch body complete (normally, or because of a break, a continue or a return), the handler
+// is popped and a copy of the finally body is run.
+func (c *Compiler) VisitTryStatement(statement *ast.TryStatement) error {
+	f := c.current
+	c.line = statement.Line
+	c.span = statement.Position()
+
+	try := &tryBlock{
+		localCount: len(f.locals),
+		scopeDepth: f.scopeDepth,
+		loopCount:  len(f.loops),
+		tryCount:   len(f.tries),
+		finally:    statement.FinallyBody,
+	}
+
+	handler := c.emit(bytecode.OpTry, 0, 0)
+	handlerEnd := handler + bytecode.OpTry.Width()
+
+	f.tries = append(f.tries, try)
+	err := statement.Body.Accept(c)
+	f.tries = f.tries[:try.tryCount]
+	if err != nil {
+		return err
+	}
+
+	err = c.leaveTry(try)
+	if err != nil {
+		return err
+	}
+	endJumps := []int{c.emitJump(bytecode.OpJump)}
+
+	if statement.CatchBody != nil {
+		err := c.patchOffset(handler+1, handlerEnd)
+		if err != nil {
+			return err
+		}
+
+		// the handler is kept while the catch body runs, only if there is a finally body
+		if try.finally != nil {
+			f.tries = append(f.tries, try)
+		}
+
+		// the caught value lives in its own scope (that wraps the catch body)
+		c.beginScope()
+		err = c.addLocal(statement.CatchName.Lexeme)
+		if err != nil {
+			return err
+		}
+		err = statement.CatchBody.Accept(c)
+		if err != nil {
+			return err
+		}
+		c.endScope()
+
+		f.tries = f.tries[:try.tryCount]
+		if try.finally != nil {
+			err := c.leaveTry(try)
+			if err != nil {
+				return err
+			}
+		}
+		endJumps = append(endJumps, c.emitJump(bytecode.OpJump))
+	}
+
+	if try.finally != nil {
+		err := c.patchOffset(handler+3, handlerEnd)
+		if err != nil {
+			return err
+		}
+
+		// the pending error lives in a hidden local, that is consumed when it is re-thrown
+		c.beginScope()
+		err = c.addLocal("")
+		if err != nil {
+			return err
+		}
+		err = try.finally.Accept(c)
+		if err != nil {
+			return err
+		}
+
+		c.line = statement.Line
+		c.span = statement.Position()
+		c.emit(bytecode.OpRethrow)
+		f.scopeDepth--
+		f.locals = f.locals[:len(f.locals)-1]
+	}
+
+	for _, jump := range endJumps {
+		err := c.patchJump(jump)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leaveTry emits the instructions to complete a try statement: its handler is popped, and its finally body is run.
+func (c *Compiler) leaveTry(try *tryBlock) error {
+	c.emit(bytecode.OpPopHandler)
+	if try.finally == nil {
+		return nil
+	}
+
+	// the finally body is compiled as if it was right after the try statement (so it can't see the locals
+	// declared inside the try, and its break and continue statements refer to the loops enclosing the try)
+	f := c.current
+	locals, loops, tries, scopeDepth := f.locals, f.loops, f.tries, f.scopeDepth
+	f.locals = append([]local(nil), locals[:try.localCount]...)
+	f.loops = append([]*loop(nil), loops[:try.loopCount]...)
+	f.tries = append([]*tryBlock(nil), tries[:try.tryCount]...)
+	f.scopeDepth = try.scopeDepth
+
+	err := try.finally.Accept(c)
+
+	// the locals captured by the finally body must be closed when they go out of scope
+	for index := range f.locals[:try.localCount] {
+		locals[index].captured = locals[index].captured || f.locals[index].captured
+	}
+	f.locals, f.loops, f.tries, f.scopeDepth = locals, loops, tries, scopeDepth
+
+	return err
+}
+
+// unwindTries emits the instructions to leave the try statements above the given quantity (popping their
+// handlers and running their finally bodies), returning the quantity of locals left in the stack.
+func (c *Compiler) unwindTries(count int) (int, error) {
+	f := c.current
+	height := len(f.locals)
+
+	for index := len(f.tries) - 1; index >= count; index-- {
+		try := f.tries[index]
+		c.popLocals(height, try.localCount)
+		height = try.localCount
+
+		err := c.leaveTry(try)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return height, nil
+}
+
+// Modules
+
+func (c *Compiler) VisitImportStatement(statement *ast.ImportStatement) error {
+	c.line = statement.Line
+	c.span = statement.Position()
+
+	err := c.emitConstant(bytecode.OpImport, statement.Path.Literal.(string))
+	if err != nil {
+		return err
+	}
+
+	return c.defineVariable(statement.Name.Lexeme)
+}
+
+// Classes
+
+func (c *Compiler) VisitClassStatement(statement *ast.ClassStatement) error {
+	c.line = statement.Name.Line
+	c.span = statement.Position()
+	hasSuperclass := 0
+	if statement.Superclass != nil {
+		_, err := statement.Superclass.Accept(c)
+		if err != nil {
+			return err
+		}
+		hasSuperclass = 1
+	}
+
+	c.line = statement.Name.Line
+	c.span = statement.Position()
+	name, err := c.makeConstant(statement.Name.Lexeme)
+	if err != nil {
+		return err
+	}
+	c.emit(bytecode.OpClass, name, hasSuperclass)
+
+	err = c.defineVariable(statement.Name.Lexeme)
+	if err != nil {
+		return err
+	}
+
+	if statement.Superclass != nil {
+		// the methods capture the superclass from a scope where "super" is defined
+		c.beginScope()
+		_, err := statement.Superclass.Accept(c)
+		if err != nil {
+			return err
+		}
+		err = c.addLocal("super")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.getVariable(statement.Name.Lexeme)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range statement.Methods {
+		fnType := method
+		if m.Name.Lexeme == "init" {
+			fnType = initializer
+		}
+
+		c.line = m.Name.Line
+		c.span = m.Position()
+		err := c.compileFunction(m.Name.Lexeme, m.Paremeters, m.Body, fnType)
+		if err != nil {
+			return err
+		}
+
+		err = c.emitConstant(bytecode.OpMethod, m.Name.Lexeme)
+		if err != nil {
+			return err
+		}
+	}
+	c.emit(bytecode.OpPop)
+
+	if statement.Superclass != nil {
+		c.endScope()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+)
+
+// Compile scans, parses and resolves a script, returning its statements and the interpreter where they can be
+// debugged (that writes to stdout, and resolves the imports from the directory of the file of the script).
+// The errors show the excerpt of the code where they were found.
+func Compile(source string, path string, stdout io.Writer) (*interpreter.Interpreter, []ast.Statement, error) {
+	tokens, err := scanner.NewScanner(bytes.Runes([]byte(source))).Scan()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed on the lexer layer: %w", err.(*scanner.LexingErr).WithExcerpts(source))
+	}
+
+	statements, err := parser.NewParser(tokens).Parse()
+	if err != nil {
+		return nil, nil, err.(*parser.ParsingErr).WithExcerpts(source)
+	}
+
+	i := interpreter.NewInterpreter(stdout)
+	i.SetBaseDir(filepath.Dir(path))
+	i.SetMainFile(path)
+	err = interpreter.NewResolver(i).Resolve(statements)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed resolving the statements: %w", err.(interpreter.ResolverErr).WithExcerpt(source))
+	}
+
+	return i, statements, nil
+}
+
+// WithExcerpt returns the error of a debugged script with the excerpt of the code where it was found (if it's a
+// runtime error).
+func WithExcerpt(err error, source string) error {
+	if runtimeErr, ok := err.(interr.RuntimeError); ok {
+		return runtimeErr.WithExcerpt(source)
+	}
+	return err
+}
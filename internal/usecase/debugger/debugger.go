@@ -0,0 +1,212 @@
+// Package debugger runs the scripts (in the tree-walking interpreter) pausing them at the breakpoints and after each
+// step, so their state can be inspected: the active calls, and the variables of each scope.
+package debugger
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+)
+
+// scriptName is the name of the frame of the top level of the script.
+const scriptName = "<script>"
+
+// ErrQuit is the error returned while paused to stop the execution.
+var ErrQuit = errors.New("the debugging was quit")
+
+// Resume indicates how the execution goes on after a pause.
+type Resume int
+
+const (
+	Step     Resume = iota // until the next line (entering the calls)
+	Next                   // until the next line of the same function (stepping over the calls)
+	Finish                 // until the current function returns
+	Continue               // until the next breakpoint
+)
+
+type (
+	// PauseFunc is the function called when the execution is paused, that returns how it goes on (or an error to stop it).
+	PauseFunc func(pause Pause) (Resume, error)
+
+	// Pause is the state of a paused script.
+	Pause struct {
+		Line       int
+		Breakpoint bool    // paused at a breakpoint (instead of after a step)
+		Frames     []Frame // the active calls, from the innermost to the top level of the script
+	}
+
+	// Frame is an active call of a function (or the top level of the script).
+	Frame struct {
+		Function string
+		Line     int // line being run (for the outer frames, the line of the call to the inner frame)
+		Env      *interpreter.Env
+	}
+
+	// Scope is an environment, with its variables (sorted by name).
+	Scope struct {
+		Global    bool
+		Variables []Variable
+	}
+
+	// Variable is a variable of a scope, with its printable value.
+	Variable struct {
+		Name  string
+		Value string
+	}
+)
+
+// Debugger pauses an interpreter before running the statements where it should stop.
+type Debugger struct {
+	interpreter *interpreter.Interpreter
+	onPause     PauseFunc
+	resume      Resume
+	line        int                    // line of the last pause (it isn't paused again until another line is reached, or a loop iterates)
+	depth       int                    // call depth of the last pause (used to step over the calls, or out of them)
+	run         map[ast.Statement]bool // statements run in the line of the last pause (when one runs again, a loop iterated)
+	err         error                  // error returned by onPause, that stops the execution (eg: ErrQuit)
+
+	mu          sync.Mutex // the breakpoints can be changed while the script runs (eg: by a debug adapter)
+	breakpoints map[int]bool
+}
+
+// New is a constructor for a Debugger, that pauses the interpreter (before its first statement, and then as
+// indicated by onPause).
+func New(i *interpreter.Interpreter, onPause PauseFunc) *Debugger {
+	d := &Debugger{
+		interpreter: i,
+		onPause:     onPause,
+		resume:      Step,
+		breakpoints: map[int]bool{},
+	}
+	i.SetHook(d.hook)
+	return d
+}
+
+// SetResume sets how the execution goes on until the next pause (eg: Continue, to not pause before the first statement).
+func (d *Debugger) SetResume(resume Resume) {
+	d.resume = resume
+}
+
+// SetBreakpoint sets a breakpoint in a line.
+func (d *Debugger) SetBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// ClearBreakpoint removes the breakpoint of a line.
+func (d *Debugger) ClearBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, line)
+}
+
+// ClearBreakpoints removes all the breakpoints.
+func (d *Debugger) ClearBreakpoints() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = map[int]bool{}
+}
+
+// Breakpoints returns the lines with breakpoints, sorted.
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+func (d *Debugger) hasBreakpoint(line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.breakpoints[line]
+}
+
+// hook is called by the interpreter before each statement, and pauses it if needed.
+func (d *Debugger) hook(statement ast.Statement, line int, env *interpreter.Env) error {
+	if d.err != nil {
+		return d.err // the execution is being stopped (but the finally bodies still run)
+	}
+
+	calls := d.interpreter.Calls()
+	depth := len(calls)
+	if line == d.line && depth == d.depth && !d.run[statement] {
+		d.run[statement] = true
+		return nil // another statement of the line where it was paused
+	}
+	d.line, d.run = 0, nil
+
+	breakpoint := d.hasBreakpoint(line)
+	if !breakpoint && !d.stepped(depth) {
+		return nil
+	}
+
+	d.line, d.depth, d.run = line, depth, map[ast.Statement]bool{statement: true}
+	resume, err := d.onPause(Pause{Line: line, Breakpoint: breakpoint, Frames: frames(line, env, calls)})
+	if err != nil {
+		d.err = err
+		return err
+	}
+
+	d.resume = resume
+	return nil
+}
+
+// stepped returns true if the step that is being run ends at the given call depth.
+func (d *Debugger) stepped(depth int) bool {
+	switch d.resume {
+	case Step:
+		return true
+	case Next:
+		return depth <= d.depth
+	case Finish:
+		return depth < d.depth
+	}
+	return false
+}
+
+// frames returns the frames of the active calls, from the innermost one.
+func frames(line int, env *interpreter.Env, calls []interpreter.Call) []Frame {
+	frames := make([]Frame, 0, len(calls)+1)
+	for i := len(calls) - 1; i >= 0; i-- {
+		frames = append(frames, Frame{Function: calls[i].Function, Line: line, Env: env})
+		line, env = calls[i].Line, calls[i].Env
+	}
+	return append(frames, Frame{Function: scriptName, Line: line, Env: env})
+}
+
+// Scopes returns the scopes visible from an environment, going up through its parents (from the innermost one to
+// the global one, without the builtins).
+func Scopes(env *interpreter.Env) []Scope {
+	var scopes []Scope
+	for ; env != nil && env.Parent() != nil; env = env.Parent() {
+		scope := Scope{Global: env.Parent().Parent() == nil}
+		for name, value := range env.Values() {
+			scope.Variables = append(scope.Variables, Variable{Name: name, Value: printable(value)})
+		}
+
+		sort.Slice(scope.Variables, func(i, j int) bool {
+			return scope.Variables[i].Name < scope.Variables[j].Name
+		})
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// printable returns the value as printed by the debugger (the strings are quoted, to tell them apart).
+func printable(value interface{}) string {
+	if s, isString := value.(string); isString {
+		return strconv.Quote(s)
+	}
+	return corerule.PrintableValue(value)
+}
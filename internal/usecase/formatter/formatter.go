@@ -0,0 +1,461 @@
+// Package formatter prints the source code in its canonical format (like gofmt does for Go code).
+//
+// The code is printed from its AST: one statement per line, indented with 4 spaces, with the blocks always
+// wrapped in braces, the statements always ended by semicolons, and without the optional parentheses around the
+// conditions. The comments and the blank lines between the statements (up to one) are kept.
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+)
+
+// indentation is the indentation of each nested level.
+const indentation = "    "
+
+// printer prints the statements, interleaving the comments found in their lines.
+type printer struct {
+	out        *strings.Builder
+	depth      int
+	comments   []*token.Token                  // comments not printed yet (in order)
+	lines      map[ast.Statement]ast.LineRange // lines of the statements in the source code
+	lastLine   int                             // last line of the source code printed
+	blockStart bool                            // whether nothing was printed yet in the current block
+	end        int                             // last line of the statement being printed
+	method     bool                            // whether the function being printed is a method
+}
+
+// Format returns the source code in its canonical format.
+// The code must be valid (it's scanned and parsed), but it isn't resolved.
+func Format(source string) (string, error) {
+	s := scanner.NewScanner(bytes.Runes([]byte(source)))
+	tokens, err := s.Scan()
+	if err != nil {
+		return "", fmt.Errorf("failed on the lexer layer: %w", err)
+	}
+
+	p := parser.NewParser(tokens)
+	statements, err := p.Parse()
+	if err != nil {
+		return "", err
+	}
+
+	printer := &printer{
+		out:        &strings.Builder{},
+		comments:   s.Comments(),
+		lines:      p.Lines(),
+		blockStart: true,
+	}
+	printer.statements(statements, tokens[len(tokens)-1].Line+1)
+	return printer.out.String(), nil
+}
+
+// statements prints a list of statements, one per line, followed by the comments found before the end line.
+func (p *printer) statements(statements []ast.Statement, end int) {
+	for index, statement := range statements {
+		next := 0
+		if index+1 < len(statements) {
+			next = p.lines[statements[index+1]].Start
+		}
+		p.statement(statement, next, end)
+	}
+	p.commentsBefore(end)
+}
+
+// statement prints a statement in its own line, preceded by its comments, and followed by the comment in its
+// last line (unless the next statement, or the end of the block, is in the same line).
+func (p *printer) statement(statement ast.Statement, next int, end int) {
+	lines, isTracked := p.lines[statement]
+	if !isTracked {
+		lines = ast.LineRange{Start: p.lastLine, End: p.lastLine}
+	}
+
+	p.commentsBefore(lines.Start)
+	p.newLine(lines.Start)
+
+	previousEnd := p.end
+	p.end = lines.End
+	_ = statement.Accept(p)
+	p.end = previousEnd
+
+	if len(p.comments) > 0 && p.comments[0].Line == lines.End && next != lines.End && end != lines.End {
+		p.out.WriteString(" " + p.comments[0].Lexeme)
+		p.comments = p.comments[1:]
+	}
+	p.out.WriteString("\n")
+	p.lastLine = lines.End
+}
+
+// commentsBefore prints the comments found before the given line, one per line.
+func (p *printer) commentsBefore(line int) {
+	for len(p.comments) > 0 && p.comments[0].Line < line {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.newLine(comment.Line)
+		p.out.WriteString(strings.TrimRight(comment.Lexeme, " \t\r") + "\n")
+		p.lastLine = comment.Line
+	}
+}
+
+// newLine starts a new line (with its indentation), keeping a blank line when there was one in the source code.
+func (p *printer) newLine(line int) {
+	if !p.blockStart && line > p.lastLine+1 {
+		p.out.WriteString("\n")
+	}
+	p.blockStart = false
+	p.out.WriteString(strings.Repeat(indentation, p.depth))
+}
+
+// block prints a block (with its braces), whose closing brace is in the given line.
+func (p *printer) block(statements []ast.Statement, end int) {
+	if len(statements) == 0 && (len(p.comments) == 0 || p.comments[0].Line >= end) {
+		p.out.WriteString("{}")
+		return
+	}
+
+	p.out.WriteString("{\n")
+	p.depth++
+	p.blockStart = true
+	p.statements(statements, end)
+	p.blockStart = false
+	p.depth--
+	p.out.WriteString(strings.Repeat(indentation, p.depth) + "}")
+}
+
+// body prints the body of a statement, wrapping it in a block when it is a single statement.
+func (p *printer) body(statement ast.Statement) {
+	switch statement.(type) {
+	case *ast.BlockStatement:
+		_ = statement.Accept(p)
+	case *ast.VariableStatement:
+		// it isn't wrapped, since the block would change the scope of the variable
+		_ = statement.Accept(p)
+	default:
+		p.block([]ast.Statement{statement}, p.lines[statement].End)
+	}
+}
+
+// continuation prints the keyword that continues a statement after one of its bodies (eg: else), in the line of
+// the body's closing brace, unless there is a comment after the brace: then the comment is kept after it, and the
+// keyword goes to the next line.
+func (p *printer) continuation(body ast.Statement, keyword string) {
+	if len(p.comments) > 0 && p.comments[0].Line == p.lines[body].End {
+		p.out.WriteString(" " + strings.TrimRight(p.comments[0].Lexeme, " \t\r") + "\n")
+		p.comments = p.comments[1:]
+		p.out.WriteString(strings.Repeat(indentation, p.depth) + keyword + " ")
+		return
+	}
+	p.out.WriteString(" " + keyword + " ")
+}
+
+func (p *printer) expression(expression ast.Expression) string {
+	text, _ := expression.Accept(p)
+	return text.(string)
+}
+
+func (p *printer) expressions(expressions []ast.Expression) string {
+	texts := make([]string, 0, len(expressions))
+	for _, expression := range expressions {
+		texts = append(texts, p.expression(expression))
+	}
+	return strings.Join(texts, ", ")
+}
+
+// condition prints the condition of a statement, without its optional parentheses.
+func (p *printer) condition(expression ast.Expression) string {
+	for {
+		group, isGroup := expression.(*ast.GroupingExpression)
+		if !isGroup {
+			return p.expression(expression)
+		}
+		expression = group.Expression
+	}
+}
+
+// operand prints the operand of a keyword (eg: print, return), separated by a space unless it is wrapped in
+// parentheses (eg: print("a");).
+func (p *printer) operand(expression ast.Expression) string {
+	if _, isGroup := expression.(*ast.GroupingExpression); isGroup {
+		return p.expression(expression)
+	}
+	return " " + p.expression(expression)
+}
+
+func names(tokens []*token.Token) string {
+	lexemes := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		lexemes = append(lexemes, t.Lexeme)
+	}
+	return strings.Join(lexemes, ", ")
+}
+
+// Statements
+
+func (p *printer) VisitExpressionStatement(statement *ast.ExpressionStatement) error {
+	p.out.WriteString(p.expression(statement.Expression) + ";")
+	return nil
+}
+
+func (p *printer) VisitPrintStatement(statement *ast.PrintStatement) error {
+	p.out.WriteString("print" + p.operand(statement.Expression) + ";")
+	return nil
+}
+
+func (p *printer) VisitVariableStatement(statement *ast.VariableStatement) error {
+	switch {
+	case statement.Short:
+		p.out.WriteString(statement.Name.Lexeme + " := " + p.expression(statement.Value) + ";")
+	case statement.Value == nil:
+		p.out.WriteString("dec " + statement.Name.Lexeme + ";")
+	default:
+		p.out.WriteString("dec " + statement.Name.Lexeme + " = " + p.expression(statement.Value) + ";")
+	}
+	return nil
+}
+
+func (p *printer) VisitReturnStatement(statement *ast.ReturnStatement) error {
+	if statement.Value == nil {
+		p.out.WriteString("return;")
+		return nil
+	}
+	p.out.WriteString("return" + p.operand(statement.Value) + ";")
+	return nil
+}
+
+func (p *printer) VisitThrowStatement(statement *ast.ThrowStatement) error {
+	p.out.WriteString("throw" + p.operand(statement.Value) + ";")
+	return nil
+}
+
+func (p *printer) VisitFunctionStatement(statement *ast.FunctionStatement) error {
+	if !p.method {
+		p.out.WriteString("fn ")
+	}
+	p.method = false
+
+	p.out.WriteString(statement.Name.Lexeme + "(" + names(statement.Paremeters) + ") ")
+	p.block(statement.Body, p.end)
+	return nil
+}
+
+func (p *printer) VisitClassStatement(statement *ast.ClassStatement) error {
+	p.out.WriteString("class " + statement.Name.Lexeme + " ")
+	if statement.Superclass != nil {
+		p.out.WriteString("< " + statement.Superclass.Name.Lexeme + " ")
+	}
+
+	end := p.end
+	p.out.WriteString("{\n")
+	p.depth++
+	p.blockStart = true
+	for index, method := range statement.Methods {
+		next := 0
+		if index+1 < len(statement.Methods) {
+			next = p.lines[statement.Methods[index+1]].Start
+		}
+		p.method = true
+		p.statement(method, next, end)
+	}
+	p.commentsBefore(end)
+	p.blockStart = false
+	p.depth--
+	p.out.WriteString(strings.Repeat(indentation, p.depth) + "}")
+	return nil
+}
+
+func (p *printer) VisitImportStatement(statement *ast.ImportStatement) error {
+	fmt.Fprintf(p.out, "import %s as %s;", statement.Path.Lexeme, statement.Name.Lexeme)
+	return nil
+}
+
+func (p *printer) VisitBlockStatement(statement *ast.BlockStatement) error {
+	p.block(statement.Statements, p.lines[statement].End)
+	return nil
+}
+
+func (p *printer) VisitIfStatement(statement *ast.IfStatement) error {
+	p.out.WriteString("if " + p.condition(statement.Condition) + " ")
+	p.body(statement.ThenBlock)
+
+	if statement.ElseBlock == nil {
+		return nil
+	}
+
+	p.continuation(statement.ThenBlock, "else")
+	if _, isIf := statement.ElseBlock.(*ast.IfStatement); isIf {
+		return statement.ElseBlock.Accept(p)
+	}
+	p.body(statement.ElseBlock)
+	return nil
+}
+
+func (p *printer) VisitWhileStatement(statement *ast.WhileStatement) error {
+	p.out.WriteString("while " + p.condition(statement.Condition) + " ")
+	p.body(statement.Body)
+	return nil
+}
+
+func (p *printer) VisitForStatement(statement *ast.ForStatement) error {
+	p.out.WriteString("for ")
+	if statement.Initializer == nil {
+		p.out.WriteString(";")
+	} else {
+		_ = statement.Initializer.Accept(p)
+	}
+
+	if statement.Condition != nil {
+		p.out.WriteString(" " + p.condition(statement.Condition))
+	}
+	p.out.WriteString(";")
+
+	if statement.Post != nil {
+		p.out.WriteString(" " + p.expression(statement.Post))
+	}
+	p.out.WriteString(" ")
+	p.body(statement.Body)
+	return nil
+}
+
+func (p *printer) VisitForInStatement(statement *ast.ForInStatement) error {
+	p.out.WriteString("for " + names(statement.Variables) + " in " + p.condition(statement.Iterable) + " ")
+	p.body(statement.Body)
+	return nil
+}
+
+func (p *printer) VisitBreakStatement(statement *ast.BreakStatement) error {
+	p.out.WriteString("break;")
+	return nil
+}
+
+func (p *printer) VisitContinueStatement(statement *ast.ContinueStatement) error {
+	p.out.WriteString("continue;")
+	return nil
+}
+
+func (p *printer) VisitTryStatement(statement *ast.TryStatement) error {
+	p.out.WriteString("try ")
+	p.body(statement.Body)
+
+	last := statement.Body
+	if statement.CatchBody != nil {
+		p.continuation(last, "catch ("+statement.CatchName.Lexeme+")")
+		p.body(statement.CatchBody)
+		last = statement.CatchBody
+	}
+
+	if statement.FinallyBody != nil {
+		p.continuation(last, "finally")
+		p.body(statement.FinallyBody)
+	}
+	return nil
+}
+
+// Expressions
+
+func (p *printer) VisitLiteralExpression(expression *ast.LiteralExpression) (interface{}, error) {
+	switch value := expression.Value.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case string:
+		// the strings have no escape sequences
+		return `"` + value + `"`, nil
+	}
+	return fmt.Sprint(expression.Value), nil
+}
+
+func (p *printer) VisitGroupingExpression(expression *ast.GroupingExpression) (interface{}, error) {
+	return "(" + p.expression(expression.Expression) + ")", nil
+}
+
+func (p *printer) VisitUnaryExpression(expression *ast.UnaryExpression) (interface{}, error) {
+	return expression.Operator.Lexeme + p.expression(expression.Expression), nil
+}
+
+func (p *printer) VisitBinaryExpression(expression *ast.BinaryExpression) (interface{}, error) {
+	return p.expression(expression.Left) + " " + expression.Operator.Lexeme + " " + p.expression(expression.Right), nil
+}
+
+func (p *printer) VisitLogicalExpression(expression *ast.LogicalExpression) (interface{}, error) {
+	return p.expression(expression.Left) + " " + expression.Operator.Lexeme + " " + p.expression(expression.Right), nil
+}
+
+func (p *printer) VisitVariableExpression(expression *ast.VariableExpression) (interface{}, error) {
+	return expression.Name.Lexeme, nil
+}
+
+func (p *printer) VisitAssignmentExpression(expression *ast.AssignmentExpression) (interface{}, error) {
+	return expression.Name.Lexeme + " = " + p.expression(expression.Value), nil
+}
+
+func (p *printer) VisitCallExpression(expression *ast.CallExpression) (interface{}, error) {
+	return p.expression(expression.Callee) + "(" + p.expressions(expression.Arguments) + ")", nil
+}
+
+func (p *printer) VisitFunctionExpression(expression *ast.FunctionExpression) (interface{}, error) {
+	parameters := "(" + names(expression.Parameters) + ")"
+	if expression.Syntax == ast.ArrowExpressionSyntax {
+		return parameters + " => " + p.expression(expression.Body[0].(*ast.ReturnStatement).Value), nil
+	}
+
+	header := "fn" + parameters + " "
+	if expression.Syntax == ast.ArrowBlockSyntax {
+		header = parameters + " => "
+	}
+
+	// the body is printed apart, since the expressions are returned as text
+	out := p.out
+	p.out = &strings.Builder{}
+	p.block(expression.Body, p.end)
+	body := p.out.String()
+	p.out = out
+
+	return header + body, nil
+}
+
+func (p *printer) VisitListExpression(expression *ast.ListExpression) (interface{}, error) {
+	return "[" + p.expressions(expression.Elements) + "]", nil
+}
+
+func (p *printer) VisitMapExpression(expression *ast.MapExpression) (interface{}, error) {
+	pairs := make([]string, 0, len(expression.Keys))
+	for index := range expression.Keys {
+		pairs = append(pairs, p.expression(expression.Keys[index])+": "+p.expression(expression.Values[index]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}", nil
+}
+
+func (p *printer) VisitIndexExpression(expression *ast.IndexExpression) (interface{}, error) {
+	return p.expression(expression.Object) + "[" + p.expression(expression.Index) + "]", nil
+}
+
+func (p *printer) VisitIndexAssignmentExpression(expression *ast.IndexAssignmentExpression) (interface{}, error) {
+	return p.expression(expression.Object) + "[" + p.expression(expression.Index) + "] = " + p.expression(expression.Value), nil
+}
+
+func (p *printer) VisitGetExpression(expression *ast.GetExpression) (interface{}, error) {
+	return p.expression(expression.Object) + "." + expression.Name.Lexeme, nil
+}
+
+func (p *printer) VisitSetExpression(expression *ast.SetExpression) (interface{}, error) {
+	return p.expression(expression.Object) + "." + expression.Name.Lexeme + " = " + p.expression(expression.Value), nil
+}
+
+func (p *printer) VisitThisExpression(expression *ast.ThisExpression) (interface{}, error) {
+	return "this", nil
+}
+
+func (p *printer) VisitSuperExpression(expression *ast.SuperExpression) (interface{}, error) {
+	return "super." + expression.Method.Lexeme, nil
+}
@@ -0,0 +1,194 @@
+package formatter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/formatter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := map[string]struct {
+		src         string
+		expected    string
+		expectedErr bool
+	}{
+		"empty src": {
+			src:      "",
+			expected: "",
+		},
+		"spacing and semicolons": {
+			src: "dec a=1+2*-b;a:=[1,2.50,null,true];print(a) ;",
+			expected: `
+dec a = 1 + 2 * -b;
+a := [1, 2.5, null, true];
+print(a);
+`,
+		},
+		"indentation and braces": {
+			src: "fn f(a,b){\nif (a<b) return a; else if b {\n      return b;}\nwhile(true){}\n}",
+			expected: `
+fn f(a, b) {
+    if a < b {
+        return a;
+    } else if b {
+        return b;
+    }
+    while true {}
+}
+`,
+		},
+		"blank lines are kept (up to one)": {
+			src: "\n\nprint 1;\n\n\n\nprint 2;\nprint 3;\n\n",
+			expected: `
+print 1;
+
+print 2;
+print 3;
+`,
+		},
+		"comments": {
+			src: "# header\n\nprint 1;   # trailing\nfn f() { # opening\n  # leading\n  return 1; # returned\n  # closing\n}\n# footer",
+			expected: `
+# header
+
+print 1; # trailing
+fn f() {
+    # opening
+    # leading
+    return 1; # returned
+    # closing
+}
+# footer
+`,
+		},
+		"comments in empty blocks": {
+			src: "if a {\n# nothing\n} else {}",
+			expected: `
+if a {
+    # nothing
+} else {}
+`,
+		},
+		"a trailing comment goes with the last statement of the line": {
+			src: "print 1; print 2; # two",
+			expected: `
+print 1;
+print 2; # two
+`,
+		},
+		"a trailing comment after a closing brace stays in its line": {
+			src: "if a {\n    print 1;\n} # after if\nelse if b { print 2; } # after else if\nelse {\n    print 3;\n}\ntry { f(); } # after try\ncatch (e) {} # after catch\nfinally { g(); }",
+			expected: `
+if a {
+    print 1;
+} # after if
+else if b {
+    print 2;
+} # after else if
+else {
+    print 3;
+}
+try {
+    f();
+} # after try
+catch (e) {} # after catch
+finally {
+    g();
+}
+`,
+		},
+		"classes": {
+			src: "class A<B{\ninit(a){this.a=a;}\n\nget(){return super.get()+this.a;}}",
+			expected: `
+class A < B {
+    init(a) {
+        this.a = a;
+    }
+
+    get() {
+        return super.get() + this.a;
+    }
+}
+`,
+		},
+		"anonymous functions": {
+			src: "f:=fn(a){return a;};g:=(a,b)=>a+b;h:=()=>{\n# nothing\n};",
+			expected: `
+f := fn(a) {
+    return a;
+};
+g := (a, b) => a + b;
+h := () => {
+    # nothing
+};
+`,
+		},
+		"loops": {
+			src: "for i:=0;i<3;i=i+1{continue;}for;;{break;}for k,v in (m) {print {\"k\":k}[\"k\"];}",
+			expected: `
+for i := 0; i < 3; i = i + 1 {
+    continue;
+}
+for ;; {
+    break;
+}
+for k, v in m {
+    print {"k": k}["k"];
+}
+`,
+		},
+		"imports and exceptions": {
+			src: `import "lib.vx" as lib; try{throw lib.e;}catch(e){l[0]=e;}finally{print "done";}`,
+			expected: `
+import "lib.vx" as lib;
+try {
+    throw lib.e;
+} catch (e) {
+    l[0] = e;
+} finally {
+    print "done";
+}
+`,
+		},
+		"invalid code": {
+			src:         "print (1;",
+			expectedErr: true,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			formatted, err := formatter.Format(test.src)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimPrefix(test.expected, "\n"), formatted)
+
+			again, err := formatter.Format(formatted)
+			assert.NoError(t, err)
+			assert.Equal(t, formatted, again, "the format must be stable")
+		})
+	}
+}
+
+func TestTheExamplesAreFormatted(t *testing.T) {
+	examples, err := filepath.Glob("../../../web/examples/*.vx")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, examples)
+
+	for _, example := range examples {
+		code, err := os.ReadFile(example)
+		assert.NoError(t, err)
+
+		formatted, err := formatter.Format(string(code))
+		assert.NoError(t, err)
+		assert.Equal(t, string(code), formatted, example)
+	}
+}
@@ -1,49 +1,79 @@
 package interpreter
 
 import (
+	"fmt"
+
 	"github.com/avazquezcode/govetryx/internal/domain/ast"
 )
 
+// anonymousFunctionName is the name used to refer to anonymous functions (eg: in errors).
+const anonymousFunctionName = "anonymous"
+
+// VariadicArity is the arity of the callables that accept any quantity of arguments.
+const VariadicArity = -1
+
 type (
-	callable interface {
+	// Callable is the interface implemented by everything that can be called (eg: functions, native functions).
+	Callable interface {
 		Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
 		Arity() int
+		Name() string
 	}
 
-	Function struct {
-		Declaration *ast.FunctionStatement
-		Closure     *Env
+	// signature is the interface implemented by everything that can be called (callables and natives).
+	signature interface {
+		Arity() int
+		Name() string
 	}
 
-	ReturnObj struct {
-		Value interface{}
+	Function struct {
+		Declaration   *ast.FunctionStatement
+		Closure       *Env
+		IsInitializer bool // indicates if the function is the initializer of a class (that always returns "this")
+		Globals       *Env // global env of the module where the function was declared
 	}
 )
 
-func NewReturnObj(value interface{}) *ReturnObj {
-	return &ReturnObj{
-		Value: value,
+// CheckArity fails if a callable is called with a quantity of arguments different than its arity.
+func CheckArity(name string, arity int, arguments int) error {
+	if arity != VariadicArity && arity != arguments {
+		return fmt.Errorf("the fn %q expects %d argument(s), but got %d", name, arity, arguments)
 	}
+	return nil
 }
 
-func NewFunction(declaration *ast.FunctionStatement, closure *Env) *Function {
+func NewFunction(declaration *ast.FunctionStatement, closure *Env, globals *Env) *Function {
 	return &Function{
 		Declaration: declaration,
 		Closure:     closure,
+		Globals:     globals,
 	}
 }
 
+// NewMethod is a constructor for a method of a class.
+func NewMethod(declaration *ast.FunctionStatement, closure *Env, globals *Env, isInitializer bool) *Function {
+	return &Function{
+		Declaration:   declaration,
+		Closure:       closure,
+		IsInitializer: isInitializer,
+		Globals:       globals,
+	}
+}
+
+// Bind returns a copy of the method, bound to the given instance (that can be accessed using "this").
+func (f *Function) Bind(instance *Instance) *Function {
+	env := NewLocal(f.Closure)
+	env.Set("this", instance)
+	return NewMethod(f.Declaration, env, f.Globals, f.IsInitializer)
+}
+
 // Call executes a function call
-func (f *Function) Call(interpreter *Interpreter, arguments []interface{}) (result interface{}, err error) {
-	// Handle return (using panics)
+func (f *Function) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	// The globals used while running the function are the ones of the module where it was declared
+	previousGlobal := interpreter.global
+	interpreter.global = f.Globals
 	defer func() {
-		if err := recover(); err != nil {
-			if returnObj, ok := err.(*ReturnObj); ok {
-				result = returnObj.Value
-				return
-			}
-			panic(err)
-		}
+		interpreter.global = previousGlobal
 	}()
 
 	// Create a local Env for this call
@@ -51,13 +81,43 @@ func (f *Function) Call(interpreter *Interpreter, arguments []interface{}) (resu
 
 	// Define the paremeters expected by the function in the local env
 	for i, param := range f.Declaration.Paremeters {
-		env.Set(param.Lexeme, arguments[i])
+		err := interpreter.define(env, param.Lexeme, arguments[i], param.Line)
+		if err != nil {
+			interpreter.release(env)
+			return nil, err
+		}
+	}
+
+	var result interface{}
+	err := interpreter.executeBlock(f.Declaration.Body, env)
+	if err == returnSignal {
+		result, err = interpreter.returnValue, nil
+		interpreter.returnValue = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if f.IsInitializer {
+		return f.Closure.GetAt(0, "this")
 	}
 
-	return nil, interpreter.executeBlock(f.Declaration.Body, env)
+	return result, nil
 }
 
 // Arity returns the quantity of parameters defined in the function signature.
 func (f *Function) Arity() int {
 	return len(f.Declaration.Paremeters)
 }
+
+// Name returns the name of the function (anonymous functions don't have a name).
+func (f *Function) Name() string {
+	if f.Declaration.Name == nil {
+		return anonymousFunctionName
+	}
+	return f.Declaration.Name.Lexeme
+}
+
+func (f *Function) String() string {
+	return fmt.Sprintf("<fn %s>", f.Name())
+}
@@ -0,0 +1,218 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+const initializerName = "init"
+
+type (
+	// Class is the runtime representation of a class.
+	// Calling a class creates a new instance of it.
+	Class struct {
+		name       string
+		superclass *Class
+		methods    map[string]*Function
+		globals    *Env // global env of the module where the class was declared
+	}
+
+	// Instance is the runtime representation of an instance of a class.
+	Instance struct {
+		class  *Class
+		fields map[string]interface{}
+	}
+)
+
+func NewClass(name string, superclass *Class, methods map[string]*Function, globals *Env) *Class {
+	return &Class{
+		name:       name,
+		superclass: superclass,
+		methods:    methods,
+		globals:    globals,
+	}
+}
+
+// FindMethod finds a method in the class, or in its superclasses.
+func (c *Class) FindMethod(name string) (*Function, bool) {
+	if method, ok := c.methods[name]; ok {
+		return method, true
+	}
+
+	if c.superclass != nil {
+		return c.superclass.FindMethod(name)
+	}
+
+	return nil, false
+}
+
+// Call creates a new instance of the class, running its initializer (if any).
+func (c *Class) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	instance := NewInstance(c)
+
+	if initializer, ok := c.FindMethod(initializerName); ok {
+		_, err := initializer.Bind(instance).Call(interpreter, arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+// Arity returns the quantity of parameters of the initializer (if any).
+func (c *Class) Arity() int {
+	if initializer, ok := c.FindMethod(initializerName); ok {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *Class) Name() string {
+	return c.name
+}
+
+func (c *Class) String() string {
+	return fmt.Sprintf("<class %s>", c.name)
+}
+
+func NewInstance(class *Class) *Instance {
+	return &Instance{
+		class:  class,
+		fields: map[string]interface{}{},
+	}
+}
+
+// Get returns the value of a property of the instance (a field, or a method bound to the instance).
+func (i *Instance) Get(name *token.Token) (interface{}, error) {
+	if value, ok := i.fields[name.Lexeme]; ok {
+		return value, nil
+	}
+
+	if method, ok := i.class.FindMethod(name.Lexeme); ok {
+		return method.Bind(i), nil
+	}
+
+	return nil, fmt.Errorf("undefined property %q", name.Lexeme)
+}
+
+// Set sets the value of a field of the instance.
+func (i *Instance) Set(name *token.Token, value interface{}) {
+	i.fields[name.Lexeme] = value
+}
+
+func (i *Instance) String() string {
+	return fmt.Sprintf("<%s instance>", i.class.name)
+}
+
+func (i *Interpreter) VisitClassStatement(statement *ast.ClassStatement) error {
+	var superclass *Class
+	if statement.Superclass != nil {
+		value, err := i.evaluate(statement.Superclass)
+		if err != nil {
+			return interr.WrapRuntimeError(err, statement.Name.Line)
+		}
+
+		class, isClass := value.(*Class)
+		if !isClass {
+			return interr.NewRuntimeError(fmt.Sprintf("the superclass of %q must be a class", statement.Name.Lexeme), statement.Name.Line)
+		}
+		superclass = class
+	}
+
+	err := i.define(i.env, statement.Name.Lexeme, nil, statement.Name.Line)
+	if err != nil {
+		return err
+	}
+
+	env := i.env
+	if superclass != nil {
+		// methods are closures over an env where "super" is defined
+		env = NewLocal(i.env)
+		env.Set("super", superclass)
+	}
+
+	methods := make(map[string]*Function, len(statement.Methods))
+	for _, method := range statement.Methods {
+		methods[method.Name.Lexeme] = NewMethod(method, env, i.global, method.Name.Lexeme == initializerName)
+	}
+
+	i.env.Set(statement.Name.Lexeme, NewClass(statement.Name.Lexeme, superclass, methods, i.global))
+	return nil
+}
+
+func (i *Interpreter) VisitGetExpression(expression *ast.GetExpression) (interface{}, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Name.Line)
+	}
+
+	var value interface{}
+	switch object := object.(type) {
+	case *Instance:
+		value, err = object.Get(expression.Name)
+	case *Module:
+		value, err = object.Get(expression.Name)
+	case *ErrorValue:
+		value, err = object.Get(expression.Name)
+	default:
+		return nil, interr.NewRuntimeError("only instances, modules and errors have properties", expression.Name.Line)
+	}
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Name.Line)
+	}
+
+	return value, nil
+}
+
+func (i *Interpreter) VisitSetExpression(expression *ast.SetExpression) (interface{}, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Name.Line)
+	}
+
+	instance, isInstance := object.(*Instance)
+	if !isInstance {
+		return nil, interr.NewRuntimeError("only instances have fields", expression.Name.Line)
+	}
+
+	value, err := i.evaluate(expression.Value)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Name.Line)
+	}
+
+	instance.Set(expression.Name, value)
+	return value, nil
+}
+
+func (i *Interpreter) VisitThisExpression(expression *ast.ThisExpression) (interface{}, error) {
+	return i.lookUpVariable(expression, expression.Keyword)
+}
+
+func (i *Interpreter) VisitSuperExpression(expression *ast.SuperExpression) (interface{}, error) {
+	if !i.local.Exists(expression) {
+		return nil, interr.NewRuntimeError("cannot use 'super' outside of a class", expression.Keyword.Line)
+	}
+	depth := i.local.Get(expression).(int)
+
+	superclass, err := i.env.GetAt(depth, "super")
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Keyword.Line)
+	}
+
+	// "this" is always defined in the env right inside the one where "super" is defined
+	instance, err := i.env.GetAt(depth-1, "this")
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Keyword.Line)
+	}
+
+	method, ok := superclass.(*Class).FindMethod(expression.Method.Lexeme)
+	if !ok {
+		return nil, interr.NewRuntimeError(fmt.Sprintf("undefined property %q", expression.Method.Lexeme), expression.Method.Line)
+	}
+
+	return method.Bind(instance.(*Instance)), nil
+}
@@ -0,0 +1,157 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+)
+
+func (i *Interpreter) VisitListExpression(expression *ast.ListExpression) (interface{}, error) {
+	elements := make([]interface{}, 0, len(expression.Elements))
+	for _, element := range expression.Elements {
+		value, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+
+	return types.NewList(elements), nil
+}
+
+func (i *Interpreter) VisitMapExpression(expression *ast.MapExpression) (interface{}, error) {
+	m := types.NewMap()
+	for idx := range expression.Keys {
+		key, err := i.evaluate(expression.Keys[idx])
+		if err != nil {
+			return nil, interr.WrapRuntimeError(err, expression.Line)
+		}
+
+		err = ValidateMapKey(key)
+		if err != nil {
+			return nil, interr.WrapRuntimeError(err, expression.Line)
+		}
+
+		value, err := i.evaluate(expression.Values[idx])
+		if err != nil {
+			return nil, interr.WrapRuntimeError(err, expression.Line)
+		}
+
+		m.Set(key, value)
+	}
+
+	return m, nil
+}
+
+func (i *Interpreter) VisitIndexExpression(expression *ast.IndexExpression) (interface{}, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Line)
+	}
+
+	index, err := i.evaluate(expression.Index)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Line)
+	}
+
+	switch collection := object.(type) {
+	case *types.List:
+		position, err := ListIndex(collection, index)
+		if err != nil {
+			return nil, interr.WrapRuntimeError(err, expression.Line)
+		}
+		return collection.Get(position), nil
+	case *types.Map:
+		err := ValidateMapKey(index)
+		if err != nil {
+			return nil, interr.WrapRuntimeError(err, expression.Line)
+		}
+		value, _ := collection.Get(index) // missing keys are null
+		return value, nil
+	}
+
+	return nil, interr.NewRuntimeError("only lists and maps can be indexed", expression.Line)
+}
+
+func (i *Interpreter) VisitIndexAssignmentExpression(expression *ast.IndexAssignmentExpression) (interface{}, error) {
+	object, err := i.evaluate(expression.Object)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Line)
+	}
+
+	index, err := i.evaluate(expression.Index)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Line)
+	}
+
+	value, err := i.evaluate(expression.Value)
+	if err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Line)
+	}
+
+	switch collection := object.(type) {
+	case *types.List:
+		position, err := ListIndex(collection, index)
+		if err != nil {
+			return nil, interr.WrapRuntimeError(err, expression.Line)
+		}
+		collection.Set(position, value)
+		return value, nil
+	case *types.Map:
+		err := ValidateMapKey(index)
+		if err != nil {
+			return nil, interr.WrapRuntimeError(err, expression.Line)
+		}
+		collection.Set(index, value)
+		return value, nil
+	}
+
+	return nil, interr.NewRuntimeError("only lists and maps can be indexed", expression.Line)
+}
+
+// ListIndex validates that the value is a valid index for the list, and returns it as an int.
+func ListIndex(list *types.List, value interface{}) (int, error) {
+	index, err := ToInt(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index: %w", err)
+	}
+
+	if index < 0 || index >= list.Len() {
+		return 0, fmt.Errorf("index %d out of range (length %d)", index, list.Len())
+	}
+
+	return index, nil
+}
+
+// ValidateMapKey validates that the value can be used as a map key (only strings, numbers and bools are valid).
+func ValidateMapKey(key interface{}) error {
+	switch value := key.(type) {
+	case string, bool:
+		return nil
+	case float64:
+		if math.IsNaN(value) {
+			return fmt.Errorf("invalid map key: NaN")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("invalid map key %s: only strings, numbers and bools can be used as keys", corerule.PrintableValue(key))
+}
+
+// ToInt converts a value into an int, if the value is an integer number.
+func ToInt(value interface{}) (int, error) {
+	number, isNumber := value.(float64)
+	if !isNumber {
+		return 0, fmt.Errorf("must be a number")
+	}
+
+	if number != math.Trunc(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("must be an integer")
+	}
+
+	return int(number), nil
+}
@@ -0,0 +1,44 @@
+package interpreter
+
+// CompletionKind is the kind of an abrupt completion of a statement.
+type CompletionKind int
+
+const (
+	BreakCompletion CompletionKind = iota
+	ContinueCompletion
+	ReturnCompletion
+)
+
+// Completion is the signal used to propagate the abrupt completion of a statement (a break, a continue
+// or a return) up to the statement that handles it (a loop, or a function call).
+// It is returned through the StatementVisitor like an error, so the enclosing statements stop executing
+// (and restore their environments), but it is not an error for the program.
+type Completion struct {
+	Kind CompletionKind
+}
+
+// The completions are always the same (the value of a return is kept in the interpreter),
+// so signaling them doesn't allocate.
+var (
+	breakSignal    = &Completion{Kind: BreakCompletion}
+	continueSignal = &Completion{Kind: ContinueCompletion}
+	returnSignal   = &Completion{Kind: ReturnCompletion}
+)
+
+// Error returns the error shown if the completion is not handled (eg: a break outside a loop).
+func (c *Completion) Error() string {
+	switch c.Kind {
+	case BreakCompletion:
+		return "cannot execute a break statement outside a loop"
+	case ContinueCompletion:
+		return "cannot execute a continue statement outside a loop"
+	default:
+		return "cannot return from outside a valid function"
+	}
+}
+
+// isCompletion indicates if the error is a completion signal.
+func isCompletion(err error) bool {
+	_, ok := err.(*Completion)
+	return ok
+}
@@ -30,8 +30,8 @@ func NewLocal(parent *Env) *Env {
 // It tries to find the key first on the local environment,
 // and recursivelly do the same in all the parent envs until finding it.
 func (e *Env) Get(key string) (interface{}, error) {
-	if e.values.Exists(key) {
-		return e.values.Get(key), nil
+	if value, exists := e.values.Lookup(key); exists {
+		return value, nil
 	}
 
 	// try recursively on the parents
@@ -42,11 +42,25 @@ func (e *Env) Get(key string) (interface{}, error) {
 	return nil, fmt.Errorf("the variable %s is not defined", key)
 }
 
+// Parent returns the environment that encloses this one (nil for the environment of the builtins).
+func (e *Env) Parent() *Env {
+	return e.parent
+}
+
 // Set sets a new entry in the environment (key -> value)
 func (e *Env) Set(key string, value interface{}) {
 	e.values.Set(key, value)
 }
 
+// Values returns a copy of the entries defined in the environment (without the ones defined in the parents).
+func (e *Env) Values() map[string]interface{} {
+	values := make(map[string]interface{}, len(e.values))
+	for key, value := range e.values {
+		values[key.(string)] = value
+	}
+	return values
+}
+
 // Assigns a value to an "already declared" variable.
 // It tries to find the key first on the local environment,
 // and recursivelly do the same in all the parent envs until finding it,
@@ -67,12 +81,12 @@ func (e *Env) Assign(key string, value interface{}) error {
 
 // GetAt try to get the key value in a specific depth.
 func (e *Env) GetAt(depth int, key string) (interface{}, error) {
-	ancestor := e.ancestor(depth)
-	if !ancestor.values.Exists(key) {
+	value, exists := e.ancestor(depth).values.Lookup(key)
+	if !exists {
 		return nil, fmt.Errorf("could not find the variable %s", key)
 	}
 
-	return ancestor.values.Get(key), nil
+	return value, nil
 }
 
 func (e *Env) ancestor(depth int) *Env {
@@ -88,3 +102,12 @@ func (e *Env) AssignAt(depth int, key string, value interface{}) error {
 	e.ancestor(depth).values.Set(key, value)
 	return nil
 }
+
+// copy returns a new environment with the same parent, and a copy of the entries of this one.
+func (e *Env) copy() *Env {
+	env := NewLocal(e.parent)
+	for key, value := range e.values {
+		env.values[key] = value
+	}
+	return env
+}
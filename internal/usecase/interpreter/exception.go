@@ -0,0 +1,148 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+type (
+	// Thrown is the error used to propagate a value thrown by the program, until it is caught.
+	Thrown struct {
+		Value interface{}
+	}
+
+	// ErrorValue is the value of a runtime error caught by the program.
+	// It has the properties "message" and "line".
+	ErrorValue struct {
+		Message string
+		Line    int
+	}
+)
+
+func (t Thrown) Error() string {
+	return fmt.Sprintf("uncaught exception: %s", corerule.PrintableValue(t.Value))
+}
+
+// Get returns the value of a property of the error.
+func (e *ErrorValue) Get(name *token.Token) (interface{}, error) {
+	switch name.Lexeme {
+	case "message":
+		return e.Message, nil
+	case "line":
+		return float64(e.Line), nil
+	}
+	return nil, fmt.Errorf("undefined property %q", name.Lexeme)
+}
+
+func (e *ErrorValue) String() string {
+	return fmt.Sprintf("<error: %s>", e.Message)
+}
+
+func (i *Interpreter) VisitThrowStatement(statement *ast.ThrowStatement) error {
+	value, err := i.evaluate(statement.Value)
+	if err != nil {
+		return interr.WrapRuntimeError(err, statement.Line)
+	}
+
+	return NewThrowError(value, statement.Line)
+}
+
+// NewThrowError returns the error used to propagate a value thrown at the given line.
+func NewThrowError(value interface{}, line int) interr.RuntimeError {
+	if caught, isError := value.(*ErrorValue); isError {
+		// re-throwing a caught runtime error keeps its original message and line
+		return interr.RuntimeError{Message: caught.Message, Line: caught.Line, Err: Thrown{Value: value}}
+	}
+
+	thrown := Thrown{Value: value}
+	return interr.RuntimeError{Message: thrown.Error(), Line: line, Err: thrown}
+}
+
+func (i *Interpreter) VisitTryStatement(statement *ast.TryStatement) (err error) {
+	if statement.FinallyBody != nil {
+		// the finally body runs even when the try (or the catch) body ends because of an error or a return
+		defer func() {
+			// the value of a pending return is kept, since the finally body can call other functions
+			returnValue := i.returnValue
+			finallyErr := i.execute(statement.FinallyBody)
+			if finallyErr != nil {
+				err = finallyErr
+				return
+			}
+			i.returnValue = returnValue
+		}()
+	}
+
+	err = i.execute(statement.Body)
+	if err == nil || statement.CatchBody == nil || !IsCatchable(err) {
+		return err
+	}
+
+	env := NewLocal(i.env)
+	err = i.define(env, statement.CatchName.Lexeme, CaughtValue(err, statement.Line), statement.Line)
+	if err != nil {
+		return err
+	}
+
+	// the catch body is a block, so it runs in a new env inside the one with the caught value
+	previousEnv := i.env
+	i.env = env
+	defer func() {
+		i.env = previousEnv
+		i.release(env)
+	}()
+
+	return i.execute(statement.CatchBody)
+}
+
+// IsCatchable indicates if an error can be caught by the program.
+// The completions (break, continue and return) are not errors for the program, and neither the cancellation of the
+// execution, the exceeded limits or the errors of the hook (so a program can't ignore them).
+func IsCatchable(err error) bool {
+	if isCompletion(err) {
+		return false
+	}
+
+	var hookErr hookError
+	if errors.As(err, &hookErr) {
+		return false
+	}
+
+	for _, uncatchable := range []error{
+		interr.ErrCanceled,
+		interr.ErrDeadlineExceeded,
+		interr.ErrStepLimitExceeded,
+		interr.ErrCallDepthExceeded,
+		interr.ErrStringTooLong,
+		interr.ErrBindingsLimitExceeded,
+	} {
+		if errors.Is(err, uncatchable) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CaughtValue returns the value that the catch receives for an error: the thrown value, or an ErrorValue for runtime errors.
+func CaughtValue(err error, line int) interface{} {
+	var thrown Thrown
+	if errors.As(err, &thrown) {
+		return thrown.Value
+	}
+
+	var runtimeErr interr.RuntimeError
+	if errors.As(err, &runtimeErr) {
+		if runtimeErr.Line != 0 {
+			line = runtimeErr.Line
+		}
+		return &ErrorValue{Message: runtimeErr.Message, Line: line}
+	}
+
+	return &ErrorValue{Message: err.Error(), Line: line}
+}
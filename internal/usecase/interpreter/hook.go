@@ -0,0 +1,106 @@
+package interpreter
+
+import "github.com/avazquezcode/govetryx/internal/domain/ast"
+
+// ModuleFunction is the name of the function of the top level of the imported files, for the profiler.
+const ModuleFunction = "<module>"
+
+type (
+	// Hook is a function called before running each statement of the script (eg: by a debugger), with its line and
+	// the environment where it runs (the statement tells apart the ones in the same line, and the iterations of the
+	// loops, where it's run again). When it fails, the execution stops with its error.
+	// It's not called for the statements of the imported modules, nor for the blocks (but for their statements).
+	Hook func(statement ast.Statement, line int, env *Env) error
+
+	// Profiler is notified of the statements and the calls while the script runs, to measure where the time is spent.
+	// Unlike the hook, it's notified of the statements of the imported modules (but not of the blocks): the top level
+	// of each imported file is entered as a call to ModuleFunction (at the line of its import), so the statements
+	// belong to the file of the function being called. The file of a function is the path of the import of the file
+	// that declares it (empty for the script and the natives).
+	Profiler interface {
+		Statement(line int)                           // before running a statement
+		Enter(function string, file string, line int) // before calling a function (or a native), at the given line
+		Exit()                                        // after the called function returns (or fails)
+	}
+
+	// hookError is an error returned by the hook, that can't be caught by the program (eg: the debugging was quit).
+	hookError struct {
+		err error
+	}
+
+	// Call is an active call to a function (or to a class, that runs its initializer).
+	Call struct {
+		Function string // name of the function
+		Line     int    // line where it was called
+		Env      *Env   // environment of the caller, when it was called
+	}
+)
+
+// SetHook sets the function called before running each statement (nil to remove it).
+func (i *Interpreter) SetHook(hook Hook) {
+	i.hook = hook
+}
+
+// SetProfiler sets the profiler notified while the script runs (nil to remove it).
+func (i *Interpreter) SetProfiler(profiler Profiler) {
+	i.profiler = profiler
+}
+
+// Calls returns the active calls to functions, from the outermost to the innermost.
+func (i *Interpreter) Calls() []Call {
+	calls := make([]Call, len(i.calls))
+	copy(calls, i.calls)
+	return calls
+}
+
+// callHook calls the hook before running a statement of the script.
+func (i *Interpreter) callHook(statement ast.Statement) error {
+	line := i.observedLine(statement)
+	if line == 0 {
+		return nil
+	}
+
+	err := i.hook(statement, line, i.env)
+	if err != nil {
+		return hookError{err: err}
+	}
+	return nil
+}
+
+// observedLine returns the line of a statement for the hook, or 0 if it isn't called for it (the statements of the
+// imported modules, and the ones the profiler isn't notified of).
+func (i *Interpreter) observedLine(statement ast.Statement) int {
+	if i.global != i.main {
+		return 0
+	}
+	return profiledLine(statement)
+}
+
+// profiledLine returns the line of a statement for the profiler, or 0 if it isn't notified of it (the blocks, and
+// the statements built without the parser, eg: by the optimizer).
+func profiledLine(statement ast.Statement) int {
+	if _, isBlock := statement.(*ast.BlockStatement); isBlock {
+		return 0
+	}
+	return statement.Position().Start.Line
+}
+
+// declaringFile returns the imported file where a function (or a class) was declared, empty for the ones of the
+// program being run and for the natives.
+func (i *Interpreter) declaringFile(function signature) string {
+	switch function := function.(type) {
+	case *Function:
+		return i.files[function.Globals]
+	case *Class:
+		return i.files[function.globals]
+	}
+	return ""
+}
+
+func (e hookError) Error() string {
+	return e.err.Error()
+}
+
+func (e hookError) Unwrap() error {
+	return e.err
+}
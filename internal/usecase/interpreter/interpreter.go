@@ -1,9 +1,12 @@
 package interpreter
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/avazquezcode/govetryx/internal/domain/ast"
 	"github.com/avazquezcode/govetryx/internal/domain/corerule"
@@ -13,109 +16,240 @@ import (
 	"github.com/avazquezcode/govetryx/internal/domain/types"
 )
 
-// Break and continue are defined like errors, to simplify implementation
-type Break struct {
-	error
-}
-type Continue struct {
-	error
-}
-
 type Interpreter struct {
-	env    *Env
-	global *Env
-	local  types.HashMap
-	stdout io.Writer
+	ctx      context.Context
+	env      *Env
+	global   *Env // global env of the module being run
+	main     *Env // global env of the script (the one that is not imported)
+	builtins *Env // env shared by all the modules, with the native functions (parent of the global envs)
+	local    types.HashMap
+	stdout   io.Writer
+	stdin    *bufio.Reader
+	modules  *Modules[*Module]
+	files    map[*Env]string // imported files, by the global env where they were run
+
+	returnValue interface{} // value of the return being completed (see Completion)
+
+	limits    Limits
+	steps     int // quantity of steps evaluated in the current run
+	callDepth int // quantity of nested calls being executed
+	bindings  int // quantity of bindings in the live environments
+
+	hook     Hook     // called before each statement (if set)
+	calls    []Call   // active calls to functions (the innermost is the last one)
+	profiler Profiler // notified of the statements and the calls (if set)
 }
 
 // NewInterpreter is a constructor for an interpreter.
 func NewInterpreter(stdout io.Writer) *Interpreter {
-	global := NewGlobal()
+	builtins := NewGlobal()
 
-	// Register the native functions in the global environment
-	global.Set("sleep", FnSleep{})
-	global.Set("clock", FnClock{})
-	global.Set("min", FnMin{})
-	global.Set("max", FnMax{})
+	// Register the native functions in the builtins environment
+	for name, native := range Natives() {
+		builtins.Set(name, native)
+	}
+
+	global := NewLocal(builtins)
 
 	return &Interpreter{
-		env:    global,
-		global: global,
-		local:  types.HashMap{},
-		stdout: stdout,
+		ctx:      context.Background(),
+		env:      global,
+		global:   global,
+		main:     global,
+		builtins: builtins,
+		local:    types.HashMap{},
+		stdout:   stdout,
+		stdin:    bufio.NewReader(strings.NewReader("")),
+		modules:  NewModules[*Module](),
+		files:    map[*Env]string{},
+		limits:   DefaultLimits(),
 	}
 }
 
+// Define defines a new builtin, visible from all the modules (eg: a native function registered by the host).
+func (i *Interpreter) Define(name string, value interface{}) {
+	i.builtins.Set(name, value)
+}
+
+// SetStdin sets the reader used by the interpreter to read input (eg: the input native fn).
+func (i *Interpreter) SetStdin(stdin io.Reader) {
+	i.stdin = bufio.NewReader(stdin)
+}
+
+// Context returns the context of the current run.
+func (i *Interpreter) Context() context.Context {
+	return i.ctx
+}
+
+// Stdin returns the reader used by the interpreter to read input.
+func (i *Interpreter) Stdin() *bufio.Reader {
+	return i.stdin
+}
+
 // Interpret is the main method of the interpreter.
 // It interprets the code while traversing the AST.
 func (i *Interpreter) Interpret(statements []ast.Statement) error {
-	for _, statement := range statements {
-		err := statement.Accept(i)
-		if err != nil {
+	return i.InterpretContext(context.Background(), statements)
+}
+
+// InterpretContext interprets the code while observing the given context.
+// The context is checked at loop iterations and function calls (and while sleeping), and when
+// it is done, the execution stops with ErrCanceled or ErrDeadlineExceeded.
+func (i *Interpreter) InterpretContext(ctx context.Context, statements []ast.Statement) error {
+	return i.run(ctx, func() error {
+		for _, statement := range statements {
+			err := i.execute(statement)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EvaluateContext evaluates an expression (in the global scope) while observing the given context.
+func (i *Interpreter) EvaluateContext(ctx context.Context, expression ast.Expression) (interface{}, error) {
+	var value interface{}
+	err := i.run(ctx, func() error {
+		var err error
+		value, err = i.evaluate(expression)
+		return err
+	})
+	return value, err
+}
+
+// Globals returns the entries defined in the global environment (including the builtins).
+func (i *Interpreter) Globals() map[string]interface{} {
+	globals := i.builtins.Values()
+	for name, value := range i.global.Values() {
+		globals[name] = value
+	}
+	return globals
+}
+
+// run prepares the interpreter to run with the given context.
+func (i *Interpreter) run(ctx context.Context, fn func() error) error {
+	previousCtx := i.ctx
+	i.ctx = ctx
+	defer func() { i.ctx = previousCtx }()
+
+	i.steps = 0
+
+	if err := i.checkContext(0); err != nil {
+		return err
+	}
+
+	err := fn()
+	if err == returnSignal {
+		// a return outside a function just ends the program
+		i.returnValue = nil
+		return nil
+	}
+	return err
+}
+
+// execute executes a statement.
+func (i *Interpreter) execute(statement ast.Statement) error {
+	if err := i.step(); err != nil {
+		return err
+	}
+
+	if i.hook != nil {
+		if err := i.callHook(statement); err != nil {
 			return err
 		}
 	}
+
+	if i.profiler != nil {
+		if line := profiledLine(statement); line != 0 {
+			i.profiler.Statement(line)
+		}
+	}
+
+	err := statement.Accept(i)
+	if err != nil {
+		return interr.InFile(interr.Locate(err, statement.Position()), i.file())
+	}
 	return nil
 }
 
+// evaluate evaluates an expression.
+func (i *Interpreter) evaluate(expression ast.Expression) (interface{}, error) {
+	if err := i.step(); err != nil {
+		return nil, err
+	}
+
+	value, err := expression.Accept(i)
+	if err != nil {
+		return nil, interr.Locate(err, expression.Position())
+	}
+	return value, nil
+}
+
 func (i *Interpreter) VisitLiteralExpression(expression *ast.LiteralExpression) (interface{}, error) {
 	return expression.Value, nil
 }
 
 func (i *Interpreter) VisitGroupingExpression(expression *ast.GroupingExpression) (interface{}, error) {
-	return expression.Expression.Accept(i)
+	return i.evaluate(expression.Expression)
 }
 
 func (i *Interpreter) VisitUnaryExpression(expression *ast.UnaryExpression) (interface{}, error) {
-	right, err := expression.Expression.Accept(i)
+	right, err := i.evaluate(expression.Expression)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
 	evaluator, err := evaluator.NewUnaryEvaluator(expression.Operator, right)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
 	evaluation, err := evaluator.Evaluate()
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
 	return evaluation, nil
 }
 
 func (i *Interpreter) VisitBinaryExpression(expression *ast.BinaryExpression) (interface{}, error) {
-	left, err := expression.Left.Accept(i)
+	left, err := i.evaluate(expression.Left)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
-	right, err := expression.Right.Accept(i)
+	right, err := i.evaluate(expression.Right)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
+	}
+
+	if expression.Operator.Type == token.Plus {
+		if err := i.checkConcat(left, right, expression.Operator.Line); err != nil {
+			return nil, err
+		}
 	}
 
 	evaluator, err := evaluator.NewBinaryEvaluator(left, expression.Operator, right)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
 	evaluation, err := evaluator.Evaluate()
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
 	return evaluation, nil
 }
 
 func (i *Interpreter) VisitExpressionStatement(statement *ast.ExpressionStatement) error {
-	_, err := statement.Expression.Accept(i)
+	_, err := i.evaluate(statement.Expression)
 	return err
 }
 
 func (i *Interpreter) VisitPrintStatement(statement *ast.PrintStatement) error {
-	value, err := statement.Expression.Accept(i)
+	value, err := i.evaluate(statement.Expression)
 	if err != nil {
 		return err
 	}
@@ -133,37 +267,40 @@ func (i *Interpreter) VisitVariableStatement(statement *ast.VariableStatement) e
 	var err error
 
 	if statement.Value != nil {
-		value, err = statement.Value.Accept(i)
+		value, err = i.evaluate(statement.Value)
 		if err != nil {
-			return interr.NewRuntimeError(err.Error(), statement.Name.Line)
+			return interr.WrapRuntimeError(err, statement.Name.Line)
 		}
 	}
 
 	// Set the variable in the environment
-	i.env.Set(statement.Name.Lexeme, value)
-
-	return nil
+	return i.define(i.env, statement.Name.Lexeme, value, statement.Name.Line)
 }
 
 func (i *Interpreter) VisitVariableExpression(expression *ast.VariableExpression) (interface{}, error) {
-	if i.local.Exists(expression) {
-		return i.env.GetAt(i.local.Get(expression).(int), expression.Name.Lexeme)
+	return i.lookUpVariable(expression, expression.Name)
+}
+
+// lookUpVariable looks up the value of a variable, using the depth found by the resolver (if not found, the variable is global).
+func (i *Interpreter) lookUpVariable(expression ast.Expression, name *token.Token) (interface{}, error) {
+	if depth, ok := i.local.Lookup(expression); ok {
+		return i.env.GetAt(depth.(int), name.Lexeme)
 	}
 
-	return i.global.Get(expression.Name.Lexeme)
+	return i.global.Get(name.Lexeme)
 }
 
 func (i *Interpreter) VisitAssignmentExpression(expression *ast.AssignmentExpression) (interface{}, error) {
-	value, err := expression.Value.Accept(i)
+	value, err := i.evaluate(expression.Value)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Name.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Name.Line)
 	}
 
 	if i.local.Exists(expression) {
 		// Means we found it in the local.
 		err = i.env.AssignAt(i.local.Get(expression).(int), expression.Name.Lexeme, value)
 		if err != nil {
-			return nil, interr.NewRuntimeError(err.Error(), expression.Name.Line)
+			return nil, interr.WrapRuntimeError(err, expression.Name.Line)
 		}
 		return value, nil
 	}
@@ -171,7 +308,7 @@ func (i *Interpreter) VisitAssignmentExpression(expression *ast.AssignmentExpres
 	// Not in local, so should be in global.
 	err = i.global.Assign(expression.Name.Lexeme, value)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Name.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Name.Line)
 	}
 
 	return value, nil
@@ -183,44 +320,46 @@ func (i *Interpreter) VisitBlockStatement(statement *ast.BlockStatement) error {
 
 func (i *Interpreter) executeBlock(statements []ast.Statement, blockEnv *Env) error {
 	previousEnv := i.env
+	defer i.release(blockEnv)
 
-	// switch to block env
+	// switch to block env, and back to the previous env once the block ends
+	// (even if it ends because of an error or a return)
 	i.env = blockEnv
+	defer func() {
+		i.env = previousEnv
+	}()
 
 	for _, statement := range statements {
-		err := statement.Accept(i)
+		err := i.execute(statement)
 		if err != nil {
 			return err
 		}
 	}
 
-	// switch back to previous env
-	i.env = previousEnv
-
 	return nil
 }
 
 func (i *Interpreter) VisitIfStatement(statement *ast.IfStatement) error {
-	condition, err := statement.Condition.Accept(i)
+	condition, err := i.evaluate(statement.Condition)
 	if err != nil {
 		return err
 	}
 
 	if corerule.IsTrue(condition) {
-		return statement.ThenBlock.Accept(i)
+		return i.execute(statement.ThenBlock)
 	}
 
 	if statement.ElseBlock != nil {
-		return statement.ElseBlock.Accept(i)
+		return i.execute(statement.ElseBlock)
 	}
 
 	return nil
 }
 
 func (i *Interpreter) VisitLogicalExpression(expression *ast.LogicalExpression) (interface{}, error) {
-	left, err := expression.Left.Accept(i)
+	left, err := i.evaluate(expression.Left)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
 	// Implementation of short circuit
@@ -232,105 +371,143 @@ func (i *Interpreter) VisitLogicalExpression(expression *ast.LogicalExpression)
 		return left, nil
 	}
 
-	right, err := expression.Right.Accept(i)
+	right, err := i.evaluate(expression.Right)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Operator.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Operator.Line)
 	}
 
 	return right, nil
 }
 
 func (i *Interpreter) VisitWhileStatement(statement *ast.WhileStatement) error {
-	env := i.env
 	for {
-		evalCondition, err := statement.Condition.Accept(i)
+		if err := i.checkContext(statement.Line); err != nil {
+			return err
+		}
+
+		evalCondition, err := i.evaluate(statement.Condition)
 		if err != nil {
 			return err
 		}
 
 		if !corerule.IsTrue(evalCondition) {
-			break
+			return nil
 		}
 
-		err = statement.Body.Accept(i)
-		if err != nil {
-			i.env = env // This is necessary, so the environment is properly reseted. Same as we do in "executeBlock()".
-
-			if errors.Is(err, Break{}) {
-				break
-			}
-
-			if errors.Is(err, Continue{}) {
-				continue
-			}
-
-			// If is not break or continue, we return the real error
+		stop, err := i.executeLoopBody(statement.Body)
+		if err != nil || stop {
 			return err
 		}
 	}
-
-	return nil
 }
 
 func (i *Interpreter) VisitCallExpression(expression *ast.CallExpression) (interface{}, error) {
-	callee, err := expression.Callee.Accept(i)
+	callee, err := i.evaluate(expression.Callee)
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Line)
 	}
 
 	// evaluate the arguments
 	var arguments []interface{}
 	for _, argument := range expression.Arguments {
-		evaluatedArgument, err := argument.Accept(i)
+		evaluatedArgument, err := i.evaluate(argument)
 		if err != nil {
-			return nil, interr.NewRuntimeError(err.Error(), expression.Line)
+			return nil, interr.WrapRuntimeError(err, expression.Line)
 		}
 
 		arguments = append(arguments, evaluatedArgument)
 	}
 
-	function, ok := callee.(callable)
+	function, ok := callee.(signature)
 	if !ok {
 		return nil, interr.NewRuntimeError("tried to call a non-function", expression.Line)
 	}
 
-	if function.Arity() != len(arguments) {
-		return nil, interr.NewRuntimeError("the quantity of arguments for the call doesn't match quantity of parameters expected by the function", expression.Line)
+	if err := CheckArity(function.Name(), function.Arity(), len(arguments)); err != nil {
+		return nil, interr.WrapRuntimeError(err, expression.Line)
+	}
+
+	if err := i.checkContext(expression.Line); err != nil {
+		return nil, err
 	}
 
-	result, err := function.Call(i, arguments)
+	if err := i.enterCall(expression.Line); err != nil {
+		return nil, err
+	}
+	defer i.exitCall()
+
+	if i.profiler != nil {
+		i.profiler.Enter(function.Name(), i.declaringFile(function), expression.Line)
+		defer i.profiler.Exit()
+	}
+
+	var result interface{}
+	switch function := function.(type) {
+	case Native:
+		result, err = function.Call(i, arguments)
+	case Callable:
+		i.calls = append(i.calls, Call{Function: function.Name(), Line: expression.Line, Env: i.env})
+		result, err = function.Call(i, arguments)
+		i.calls = i.calls[:len(i.calls)-1]
+		if err != nil {
+			return nil, interr.WithFrame(err, interr.Frame{Function: function.Name(), Line: expression.Line, File: i.file()})
+		}
+	}
 	if err != nil {
-		return nil, interr.NewRuntimeError(err.Error(), expression.Line)
+		return nil, interr.WrapRuntimeError(err, expression.Line)
 	}
 
 	return result, nil
 }
 
 func (i *Interpreter) VisitFunctionStatement(statement *ast.FunctionStatement) error {
-	i.env.Set(statement.Name.Lexeme, NewFunction(statement, i.env))
-	return nil
+	return i.define(i.env, statement.Name.Lexeme, NewFunction(statement, i.env, i.global), statement.Name.Line)
+}
+
+func (i *Interpreter) VisitFunctionExpression(expression *ast.FunctionExpression) (interface{}, error) {
+	declaration := ast.NewFunctionStatement(nil, expression.Parameters, expression.Body)
+	declaration.SetPosition(expression.Position())
+	return NewFunction(declaration, i.env, i.global), nil
 }
 
 func (i *Interpreter) VisitReturnStatement(statement *ast.ReturnStatement) error {
 	if statement.Value != nil {
-		value, err := statement.Value.Accept(i)
+		value, err := i.evaluate(statement.Value)
 		if err != nil {
-			return interr.NewRuntimeError(err.Error(), statement.Line)
+			return interr.WrapRuntimeError(err, statement.Line)
 		}
-		// return with value
-		panic(NewReturnObj(value))
+		i.returnValue = value
+		return returnSignal
 	}
 
-	// return with no value
-	panic(NewReturnObj(nil))
+	i.returnValue = nil
+	return returnSignal
 }
 
 func (i *Interpreter) VisitBreakStatement(statement *ast.BreakStatement) error {
-	return Break{}
+	return breakSignal
 }
 
 func (i *Interpreter) VisitContinueStatement(statement *ast.ContinueStatement) error {
-	return Continue{}
+	return continueSignal
+}
+
+// checkContext returns a runtime error if the context of the execution is done.
+func (i *Interpreter) checkContext(line int) error {
+	select {
+	case <-i.ctx.Done():
+		return interr.WrapRuntimeError(ContextErr(i.ctx), line)
+	default:
+		return nil
+	}
+}
+
+// ContextErr maps the error of a done context into the errors exposed by the interpreter.
+func ContextErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return interr.ErrDeadlineExceeded
+	}
+	return interr.ErrCanceled
 }
 
 func (i *Interpreter) Resolve(expression ast.Expression, depth int) {
@@ -2,6 +2,9 @@ package interpreter_test
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	interpreter_pkg "github.com/avazquezcode/govetryx/internal/usecase/interpreter"
@@ -92,6 +95,50 @@ func TestInterpret(t *testing.T) {
 			expectedStdout: "1\n",
 			expectedErr:    false,
 		},
+		"return from nested blocks and loops": {
+			src:            "fn a() { dec i = 0; while true { for x in [1, 2] { { if x == 2 { return x + i; } } } i = i + 1; } } print a(); print a();",
+			expectedStdout: "2\n2\n",
+		},
+		"return value is kept when the finally calls a function": {
+			src:            "fn b() { return 2; } fn a() { try { return 1; } finally { b(); } } print a();",
+			expectedStdout: "1\n",
+		},
+		"recursive function": {
+			src:            "fn fib(n) { if n < 2 { return n; } return fib(n - 1) + fib(n - 2); } print fib(10);",
+			expectedStdout: "55\n",
+		},
+		"function call with wrong quantity of arguments": {
+			src:         "fn a(b){} a(1, 2);",
+			expectedErr: true,
+		},
+		"call of a non-function": {
+			src:         "dec a = 1; a();",
+			expectedErr: true,
+		},
+		"anonymous function": {
+			src:            "dec add = fn(a, b) { return a + b; }; print add(1, 2); print fn() { return 3; }();",
+			expectedStdout: "3\n3\n",
+		},
+		"arrow function": {
+			src:            "dec double = (a) => a * 2; print double(2); print (() => 1)(); print ((a, b) => { return a - b; })(3, 1);",
+			expectedStdout: "4\n1\n2\n",
+		},
+		"anonymous function as a callback": {
+			src:            "fn apply(f, a) { return f(a); } print apply((a) => a + 1, 1); print apply(fn(a) { return -a; }, 1);",
+			expectedStdout: "2\n-1\n",
+		},
+		"anonymous function closure": {
+			src:            "fn counter() { dec n = 0; return () => { n = n + 1; return n; }; } dec c = counter(); c(); print c(); print c;",
+			expectedStdout: "2\n<fn anonymous>\n",
+		},
+		"anonymous function with wrong quantity of arguments": {
+			src:         "((a) => a)();",
+			expectedErr: true,
+		},
+		"grouping is not an arrow function": {
+			src:            "dec a = 1; print (a) + 1;",
+			expectedStdout: "2\n",
+		},
 		// if
 		"simple if condition": {
 			src:            "if 1 == 1 {print true;}",
@@ -168,6 +215,113 @@ func TestInterpret(t *testing.T) {
 			expectedStdout: "1\n3\n4\n4\n",
 			expectedErr:    false,
 		},
+		// for
+		"for loop": {
+			src:            "for i := 0; i < 3; i = i + 1 { print i; }",
+			expectedStdout: "0\n1\n2\n",
+		},
+		"for loop with continue runs the post expression": {
+			src:            "for i := 0; i < 4; i = i + 1 { if i == 1 { continue; } print i; }",
+			expectedStdout: "0\n2\n3\n",
+		},
+		"for loop with break": {
+			src:            "for dec i = 0; ; i = i + 1 { if i == 2 { break; } print i; }",
+			expectedStdout: "0\n1\n",
+		},
+		"for loop with an outer variable": {
+			src:            "dec i = 5; for i = 0; i < 2; i = i + 1 {} print i;",
+			expectedStdout: "2\n",
+		},
+		"for loop variable is not visible after the loop": {
+			src:         "for i := 0; i < 1; i = i + 1 {} print i;",
+			expectedErr: true,
+		},
+		"for loop closures capture one variable per iteration": {
+			src:            "dec fns = []; for i := 0; i < 3; i = i + 1 { fn f() { return i; } push(fns, f); } print fns[0](); print fns[2]();",
+			expectedStdout: "0\n2\n",
+		},
+		"nested loops with break": {
+			src:            "for i := 0; i < 2; i = i + 1 { dec j = 0; while true { break; } for x in [1, 2] { if x == 2 { break; } print i + x; } }",
+			expectedStdout: "1\n2\n",
+		},
+		"for in list": {
+			src:            `for x in [1, "a"] { print x; } for i, x in ["a", "b"] { print i; print x; }`,
+			expectedStdout: "1\na\n0\na\n1\nb\n",
+		},
+		"for in map": {
+			src:            `dec m = {"b": 1, "a": 2}; for k in m { print k; } for k, v in m { print [k, v]; }`,
+			expectedStdout: "b\na\n[\"b\", 1]\n[\"a\", 2]\n",
+		},
+		"for in with continue and break": {
+			src:            "for x in [1, 2, 3, 4] { if x == 2 { continue; } if x == 4 { break; } print x; }",
+			expectedStdout: "1\n3\n",
+		},
+		"for in closures capture one variable per iteration": {
+			src:            "dec fns = []; for x in [1, 2] { fn f() { return x; } push(fns, f); } print fns[0]();",
+			expectedStdout: "1\n",
+		},
+		"for in iterates over the elements at the start of the loop": {
+			src:            "dec a = [1, 2]; for x in a { push(a, x); } print a;",
+			expectedStdout: "[1, 2, 1, 2]\n",
+		},
+		"for in a non-collection": {
+			src:         "for x in 1 {}",
+			expectedErr: true,
+		},
+		"for in with a repeated variable": {
+			src:         "for x, x in [1] {}",
+			expectedErr: true,
+		},
+		"break inside a function inside a loop": {
+			src:         "while true { fn a() { break; } }",
+			expectedErr: true,
+		},
+		"break after a nested loop": {
+			src:            "dec a = 0; while a < 3 { a = a + 1; while false {} break; } print a;",
+			expectedStdout: "1\n",
+		},
+		// exceptions
+		"throw and catch a value": {
+			src:            `try { print 1; throw "boom"; print 2; } catch (e) { print e; }`,
+			expectedStdout: "1\nboom\n",
+		},
+		"catch a runtime error": {
+			src:            "try {\n dec a = 1 / 0;\n} catch (e) { print e.message; print e.line; print e; }",
+			expectedStdout: "division per zero\n2\n<error: division per zero>\n",
+		},
+		"catch an error thrown inside a function": {
+			src:            `fn a() { throw {"code": 1}; } try { a(); } catch (e) { print e["code"]; }`,
+			expectedStdout: "1\n",
+		},
+		"finally runs after the try and the catch": {
+			src:            `try { print 1; } finally { print 2; } try { throw 1; } catch (e) { print 3; } finally { print 4; }`,
+			expectedStdout: "1\n2\n3\n4\n",
+		},
+		"finally runs when returning": {
+			src:            `fn a() { try { return 1; } finally { print "finally"; } } print a();`,
+			expectedStdout: "finally\n1\n",
+		},
+		"finally runs when the error is not caught": {
+			src:            `try { throw "boom"; } finally { print "finally"; }`,
+			expectedStdout: "finally\n",
+			expectedErr:    true,
+		},
+		"break and continue pass through try": {
+			src:            `for i := 0; i < 3; i = i + 1 { try { if i == 0 { continue; } if i == 2 { break; } print i; } catch (e) { print "caught"; } }`,
+			expectedStdout: "1\n",
+		},
+		"rethrow a caught error": {
+			src:            `try { try { throw "inner"; } catch (e) { throw e + "!"; } } catch (e) { print e; }`,
+			expectedStdout: "inner!\n",
+		},
+		"uncaught exception": {
+			src:         `throw "boom";`,
+			expectedErr: true,
+		},
+		"caught error is only visible in the catch": {
+			src:         `try { throw 1; } catch (e) {} print e;`,
+			expectedErr: true,
+		},
 		// closures
 		"closures": {
 			src:            "fn a() { fn b() { return 1; } return b(); } print a();",
@@ -180,6 +334,153 @@ func TestInterpret(t *testing.T) {
 			expectedStdout: "2\n",
 			expectedErr:    false,
 		},
+		// lists
+		"list literal": {
+			src:            `dec a = [1, "b", [true, null],]; print a; print [];`,
+			expectedStdout: "[1, \"b\", [true, null]]\n[]\n",
+		},
+		"list index get": {
+			src:            "dec a = [1, [2, 3]]; print a[0]; print a[1][1]; print a[1 - 1];",
+			expectedStdout: "1\n3\n1\n",
+		},
+		"list index set": {
+			src:            "dec a = [1, [2, 3]]; a[0] = 5; a[1][0] = a[0] + 1; print a; print a[0] = 7;",
+			expectedStdout: "[5, [6, 3]]\n7\n",
+		},
+		"lists are references": {
+			src:            "dec a = [1]; dec b = a; b[0] = 2; print a;",
+			expectedStdout: "[2]\n",
+		},
+		"list equality": {
+			src:            "print [1, [2]] == [1, [2]]; print [1] == [2]; print [1] <> [1, 2];",
+			expectedStdout: "true\nfalse\ntrue\n",
+		},
+		"list natives": {
+			src:            `dec a = [1, 2]; print push(a, 3); print a; print pop(a); print a; print len(a); print len("héllo"); print slice([1, 2, 3, 4], 1, 3);`,
+			expectedStdout: "3\n[1, 2, 3]\n3\n[1, 2]\n2\n5\n[2, 3]\n",
+		},
+		"list index out of range": {
+			src:         "dec a = [1]; print a[1];",
+			expectedErr: true,
+		},
+		"list negative index": {
+			src:         "dec a = [1]; a[-1] = 2;",
+			expectedErr: true,
+		},
+		"list index not an integer": {
+			src:         "dec a = [1]; print a[0.5];",
+			expectedErr: true,
+		},
+		"index of a non-list": {
+			src:         "dec a = 1; print a[0];",
+			expectedErr: true,
+		},
+		"pop from an empty list": {
+			src:         "pop([]);",
+			expectedErr: true,
+		},
+		"slice out of range": {
+			src:         "slice([1], 0, 2);",
+			expectedErr: true,
+		},
+		// maps
+		"map literal": {
+			src:            `dec a = {"b": 1, 2: [true], false: {},}; print a; print {};`,
+			expectedStdout: "{\"b\": 1, 2: [true], false: {}}\n{}\n",
+		},
+		"map literal at the beginning of a statement": {
+			src:            `{"a": 1}["a"]; print {"a": 1}["a"];`,
+			expectedStdout: "1\n",
+		},
+		"map index get": {
+			src:            `dec a = {"b": {"c": 1}}; print a["b"]["c"]; print a["missing"];`,
+			expectedStdout: "1\nnull\n",
+		},
+		"map index set keeps insertion order": {
+			src:            `dec a = {"x": 1}; a["z"] = 2; a["y"] = 3; a["x"] = 4; print a;`,
+			expectedStdout: "{\"x\": 4, \"z\": 2, \"y\": 3}\n",
+		},
+		"map equality": {
+			src:            `print {"a": 1, "b": 2} == {"b": 2, "a": 1}; print {"a": 1} <> {"a": 2};`,
+			expectedStdout: "true\ntrue\n",
+		},
+		"map natives": {
+			src:            `dec a = {"x": 1, "y": 2}; print keys(a); print values(a); print has(a, "x"); print has(a, "z"); print delete(a, "x"); print delete(a, "x"); print a; print len(a);`,
+			expectedStdout: "[\"x\", \"y\"]\n[1, 2]\ntrue\nfalse\ntrue\nfalse\n{\"y\": 2}\n1\n",
+		},
+		"invalid map key in literal": {
+			src:         `dec a = {[1]: 1};`,
+			expectedErr: true,
+		},
+		"invalid map key in index": {
+			src:         `dec a = {}; a[null] = 1;`,
+			expectedErr: true,
+		},
+		// classes
+		"class declaration": {
+			src:            "class A {} print A; print A();",
+			expectedStdout: "<class A>\n<A instance>\n",
+		},
+		"class fields": {
+			src:            "class A {} dec a = A(); a.b = 1; a.b = a.b + 1; print a.b; print a.c = 3;",
+			expectedStdout: "2\n3\n",
+		},
+		"class methods with this": {
+			src:            `class A { name() { return "a" + this.suffix; } } dec a = A(); a.suffix = "b"; dec f = a.name; print f();`,
+			expectedStdout: "ab\n",
+		},
+		"class initializer": {
+			src:            "class Point { init(x, y) { this.x = x; this.y = y; } sum() { return this.x + this.y; } } print Point(1, 2).sum();",
+			expectedStdout: "3\n",
+		},
+		"class initializer always returns this": {
+			src:            "class A { init() { this.a = 1; return; } } dec a = A(); print a.init().a;",
+			expectedStdout: "1\n",
+		},
+		"class inheritance": {
+			src:            `class A { a() { return "a"; } b() { return "A.b"; } } class B < A { b() { return "B.b and " + super.b(); } } dec b = B(); print b.a(); print b.b();`,
+			expectedStdout: "a\nB.b and A.b\n",
+		},
+		"class initializer is inherited": {
+			src:            "class A { init(a) { this.a = a; } } class B < A {} print B(1).a;",
+			expectedStdout: "1\n",
+		},
+		"class initializer with wrong quantity of arguments": {
+			src:         "class A { init(a) {} } A();",
+			expectedErr: true,
+		},
+		"class undefined property": {
+			src:         "class A {} A().b;",
+			expectedErr: true,
+		},
+		"property of a non-instance": {
+			src:         "dec a = 1; a.b = 2;",
+			expectedErr: true,
+		},
+		"superclass is not a class": {
+			src:         "dec A = 1; class B < A {}",
+			expectedErr: true,
+		},
+		"class inherits from itself": {
+			src:         "class A < A {}",
+			expectedErr: true,
+		},
+		"this outside of a class": {
+			src:         "print this;",
+			expectedErr: true,
+		},
+		"super outside of a class": {
+			src:         "super.a();",
+			expectedErr: true,
+		},
+		"super without superclass": {
+			src:         "class A { a() { return super.a(); } }",
+			expectedErr: true,
+		},
+		"return a value from an initializer": {
+			src:         "class A { init() { return 1; } }",
+			expectedErr: true,
+		},
 		// break outside loop
 		"break outside loop": {
 			src:         "dec a = 1; break;",
@@ -208,6 +509,7 @@ func TestInterpret(t *testing.T) {
 			err = resolver.Resolve(statements)
 			if err != nil {
 				assert.Equal(t, test.expectedErr, err != nil)
+				return
 			}
 
 			err = interpreter.Interpret(statements)
@@ -220,3 +522,207 @@ func TestInterpret(t *testing.T) {
 func strToBytes(str string) []byte {
 	return []byte(str)
 }
+
+func TestInterpretTopLevelReturn(t *testing.T) {
+	// the resolver rejects a return outside a function, but the interpreter must not crash if it runs one
+	lexer := scanner.NewScanner(bytes.Runes(strToBytes("print 1; return; print 2;")))
+	tokens, _ := lexer.Scan()
+	statements, err := parser.NewParser(tokens).Parse()
+	assert.NoError(t, err)
+
+	var testStdOut bytes.Buffer
+	interpreter := interpreter_pkg.NewInterpreter(&testStdOut)
+
+	err = interpreter.Interpret(statements)
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n", testStdOut.String())
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lib/strings.vx": `import "helpers.vx" as h; dec sep = "-"; fn join(a, b) { return h.wrap(a + sep + b); } print "loading strings";`,
+		"lib/helpers.vx": `fn wrap(a) { return "(" + a + ")"; }`,
+		"lib/cycle_a.vx": `import "cycle_b.vx" as b;`,
+		"lib/cycle_b.vx": `import "cycle_a.vx" as a;`,
+		"lib/broken.vx":  `dec a = 1 / 0;`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	tests := map[string]struct {
+		src            string
+		expectedStdout string
+		expectedErr    string
+	}{
+		"members of the module": {
+			src:            `import "lib/strings.vx" as s; print s.join("a", "b"); print s.sep; print s;`,
+			expectedStdout: "loading strings\n(a-b)\n-\n<module lib/strings.vx>\n",
+		},
+		"modules are run only once": {
+			src:            `import "lib/strings.vx" as s; import "lib/strings.vx" as t; print s.sep == t.sep;`,
+			expectedStdout: "loading strings\ntrue\n",
+		},
+		"modules have their own globals": {
+			src:            `dec sep = "+"; import "lib/strings.vx" as s; print s.join("a", "b"); print sep;`,
+			expectedStdout: "loading strings\n(a-b)\n+\n",
+		},
+		"the globals of the importer are not visible in the module": {
+			src:         `dec secret = 1; import "lib/strings.vx" as s; print s.secret;`,
+			expectedErr: `the module "lib/strings.vx" has no member "secret"`,
+		},
+		"cyclic import": {
+			src:         `import "lib/cycle_a.vx" as a;`,
+			expectedErr: `runtime error occurred at line 1: failed importing "lib/cycle_a.vx": cyclic import: lib/cycle_a.vx -> cycle_b.vx -> cycle_a.vx`,
+		},
+		"missing file": {
+			src:         `import "lib/missing.vx" as m;`,
+			expectedErr: `failed importing "lib/missing.vx": failed when reading the file`,
+		},
+		"runtime error in the module": {
+			src:         `import "lib/broken.vx" as b;`,
+			expectedErr: "division per zero",
+		},
+		"import not at the top level": {
+			src:         `fn a() { import "lib/helpers.vx" as h; }`,
+			expectedErr: "the imports are only allowed at the top level",
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			lexer := scanner.NewScanner(bytes.Runes(strToBytes(test.src)))
+			tokens, _ := lexer.Scan()
+			statements, err := parser.NewParser(tokens).Parse()
+			assert.NoError(t, err)
+
+			var testStdOut bytes.Buffer
+			interpreter := interpreter_pkg.NewInterpreter(&testStdOut)
+			interpreter.SetBaseDir(dir)
+
+			err = interpreter_pkg.NewResolver(interpreter).Resolve(statements)
+			if err == nil {
+				err = interpreter.Interpret(statements)
+			}
+
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedStdout, testStdOut.String())
+		})
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir := t.TempDir()
+	modules := interpreter_pkg.NewModules[string]()
+	modules.SetBaseDir(dir)
+	modules.SetMainFile(filepath.Join(dir, "main.vx"))
+
+	loads := []string{}
+	var load func(absPath string) (string, error)
+	load = func(absPath string) (string, error) {
+		loads = append(loads, absPath)
+		switch filepath.Base(absPath) {
+		case "a.vx":
+			// the imports of a module are resolved from its directory
+			return modules.Import("b.vx", 1, load)
+		case "c.vx":
+			return modules.Import("../main.vx", 1, load)
+		}
+		return "module " + filepath.Base(absPath), nil
+	}
+
+	module, err := modules.Import("lib/a.vx", 1, load)
+	assert.NoError(t, err)
+	assert.Equal(t, "module b.vx", module)
+
+	// the modules are loaded only once
+	module, err = modules.Import("lib/b.vx", 2, load)
+	assert.NoError(t, err)
+	assert.Equal(t, "module b.vx", module)
+	assert.Equal(t, []string{filepath.Join(dir, "lib", "a.vx"), filepath.Join(dir, "lib", "b.vx")}, loads)
+
+	_, err = modules.Import("lib/c.vx", 3, load)
+	assert.EqualError(t, err, fmt.Sprintf(`runtime error occurred at line 3: failed importing "lib/c.vx": cyclic import: %s -> lib/c.vx -> ../main.vx`, filepath.Join(dir, "main.vx")))
+}
+
+func TestImportOfTheMainFile(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vx")
+	src := `print "loading main"; import "lib.vx" as l;`
+	assert.NoError(t, os.WriteFile(main, []byte(src), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib.vx"), []byte(`import "main.vx" as m;`), 0o600))
+
+	lexer := scanner.NewScanner(bytes.Runes(strToBytes(src)))
+	tokens, _ := lexer.Scan()
+	statements, err := parser.NewParser(tokens).Parse()
+	assert.NoError(t, err)
+
+	var testStdOut bytes.Buffer
+	interpreter := interpreter_pkg.NewInterpreter(&testStdOut)
+	interpreter.SetBaseDir(dir)
+	interpreter.SetMainFile(main)
+	assert.NoError(t, interpreter_pkg.NewResolver(interpreter).Resolve(statements))
+
+	err = interpreter.Interpret(statements)
+	assert.EqualError(t, err, fmt.Sprintf(`runtime error occurred at line 1: failed importing "lib.vx": cyclic import: %s -> lib.vx -> main.vx`, main))
+	assert.Equal(t, "loading main\n", testStdOut.String()) // the main file is not run again
+}
+
+func TestResolverRecordsTheSymbols(t *testing.T) {
+	src := "fn f(a) {\n  dec b = a;\n  return g(b);\n}\nfn g(a) { return a; }\nclass P { init(x, y) {} }\ndec h = (a, b) => a;\nfor k in [1] { print f(k) + len(k); }\ntry { throw 1; } catch (e) { print e; }"
+	lexer := scanner.NewScanner(bytes.Runes(strToBytes(src)))
+	tokens, _ := lexer.Scan()
+	statements, err := parser.NewParser(tokens).Parse()
+	assert.NoError(t, err)
+
+	resolver := interpreter_pkg.NewResolver(nil)
+	symbols := resolver.RecordSymbols()
+	err = resolver.Resolve(statements)
+	assert.NoError(t, err)
+
+	type symbol struct {
+		name       string
+		line       int
+		kind       interpreter_pkg.SymbolKind
+		arity      int
+		global     bool
+		references []int // lines of the references
+	}
+	got := []symbol{}
+	for _, s := range symbols.All() {
+		references := []int{}
+		for _, r := range s.References {
+			references = append(references, r.Line)
+		}
+		got = append(got, symbol{s.Name.Lexeme, s.Name.Line, s.Kind, s.Arity, s.Global, references})
+	}
+
+	assert.Equal(t, []symbol{
+		{"f", 1, interpreter_pkg.FunctionSymbol, 1, true, []int{8}},
+		{"a", 1, interpreter_pkg.ParameterSymbol, -1, false, []int{2}},
+		{"b", 2, interpreter_pkg.VariableSymbol, -1, false, []int{3}},
+		{"g", 5, interpreter_pkg.FunctionSymbol, 1, true, []int{3}},
+		{"a", 5, interpreter_pkg.ParameterSymbol, -1, false, []int{5}},
+		{"P", 6, interpreter_pkg.ClassSymbol, 2, true, []int{}},
+		{"x", 6, interpreter_pkg.ParameterSymbol, -1, false, []int{}},
+		{"y", 6, interpreter_pkg.ParameterSymbol, -1, false, []int{}},
+		{"h", 7, interpreter_pkg.VariableSymbol, 2, true, []int{}},
+		{"a", 7, interpreter_pkg.ParameterSymbol, -1, false, []int{7}},
+		{"b", 7, interpreter_pkg.ParameterSymbol, -1, false, []int{}},
+		{"k", 8, interpreter_pkg.VariableSymbol, -1, false, []int{8, 8}},
+		{"e", 9, interpreter_pkg.VariableSymbol, -1, false, []int{9}},
+	}, got)
+
+	unresolved := []string{}
+	for _, name := range symbols.Unresolved() {
+		unresolved = append(unresolved, name.Lexeme)
+	}
+	assert.Equal(t, []string{"len"}, unresolved)
+}
@@ -0,0 +1,98 @@
+package interpreter
+
+import (
+	"fmt"
+
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+)
+
+// DefaultMaxCallDepth is the max call depth used by default, so a deep recursion fails
+// with a runtime error before exhausting the stack of the host.
+const DefaultMaxCallDepth = 10000
+
+// Limits are the resource limits enforced by the interpreter (and by the VM).
+// A zero value means there is no limit.
+// The VM can't tell the variables apart from the temporary values in its stack, so its MaxBindings bounds the globals
+// plus all the values in the stack: the limit is approximate there, and a program can exceed it only in the VM.
+type Limits struct {
+	MaxSteps        int // max quantity of statements and expressions evaluated in one run
+	MaxCallDepth    int // max quantity of nested calls
+	MaxStringLength int // max length (in bytes) of the strings produced by concatenation
+	MaxBindings     int // max quantity of bindings (variables, functions, parameters) in the live environments
+}
+
+// DefaultLimits returns the limits used by default by the interpreter.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxCallDepth: DefaultMaxCallDepth,
+	}
+}
+
+// SetLimits sets the resource limits enforced by the interpreter.
+func (i *Interpreter) SetLimits(limits Limits) {
+	i.limits = limits
+	i.modules.SetMaxStringLength(limits.MaxStringLength)
+}
+
+// step counts a new evaluation step, failing if the max steps limit is exceeded.
+func (i *Interpreter) step() error {
+	i.steps++
+	if i.limits.MaxSteps > 0 && i.steps > i.limits.MaxSteps {
+		return interr.WrapRuntimeError(fmt.Errorf("%w (%d)", interr.ErrStepLimitExceeded, i.limits.MaxSteps), 0)
+	}
+	return nil
+}
+
+// enterCall increases the call depth, failing if the max call depth limit is exceeded.
+func (i *Interpreter) enterCall(line int) error {
+	if i.limits.MaxCallDepth > 0 && i.callDepth >= i.limits.MaxCallDepth {
+		return interr.WrapRuntimeError(fmt.Errorf("%w (%d)", interr.ErrCallDepthExceeded, i.limits.MaxCallDepth), line)
+	}
+	i.callDepth++
+	return nil
+}
+
+// exitCall decreases the call depth.
+func (i *Interpreter) exitCall() {
+	i.callDepth--
+}
+
+// checkConcat fails if the concatenation of two strings exceeds the max string length limit.
+func (i *Interpreter) checkConcat(left interface{}, right interface{}, line int) error {
+	if i.limits.MaxStringLength <= 0 {
+		return nil
+	}
+
+	l, isString := left.(string)
+	if !isString {
+		return nil
+	}
+
+	r, isString := right.(string)
+	if !isString {
+		return nil
+	}
+
+	if len(l)+len(r) > i.limits.MaxStringLength {
+		return interr.WrapRuntimeError(fmt.Errorf("%w (%d)", interr.ErrStringTooLong, i.limits.MaxStringLength), line)
+	}
+	return nil
+}
+
+// define sets a new binding in the env, failing if the max bindings limit is exceeded.
+func (i *Interpreter) define(env *Env, key string, value interface{}, line int) error {
+	if !env.values.Exists(key) {
+		if i.limits.MaxBindings > 0 && i.bindings >= i.limits.MaxBindings {
+			return interr.WrapRuntimeError(fmt.Errorf("%w (%d)", interr.ErrBindingsLimitExceeded, i.limits.MaxBindings), line)
+		}
+		i.bindings++
+	}
+
+	env.Set(key, value)
+	return nil
+}
+
+// release discounts the bindings of an env that is not live anymore.
+func (i *Interpreter) release(env *Env) {
+	i.bindings -= len(env.values)
+}
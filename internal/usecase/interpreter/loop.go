@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+)
+
+func (i *Interpreter) VisitForStatement(statement *ast.ForStatement) error {
+	previousEnv := i.env
+	defer func() {
+		i.env = previousEnv
+	}()
+
+	// the variables declared in the initializer live in their own env (that wraps the body)
+	i.env = NewLocal(previousEnv)
+	defer func() {
+		i.release(i.env)
+	}()
+
+	if statement.Initializer != nil {
+		err := i.execute(statement.Initializer)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		if err := i.checkContext(statement.Line); err != nil {
+			return err
+		}
+
+		if statement.Condition != nil {
+			condition, err := i.evaluate(statement.Condition)
+			if err != nil {
+				return interr.WrapRuntimeError(err, statement.Line)
+			}
+
+			if !corerule.IsTrue(condition) {
+				return nil
+			}
+		}
+
+		stop, err := i.executeLoopBody(statement.Body)
+		if err != nil || stop {
+			return err
+		}
+
+		// each iteration gets its own copy of the loop variables, so the closures
+		// created in one iteration are not affected by the next ones
+		i.env = i.env.copy()
+
+		if statement.Post != nil {
+			_, err := i.evaluate(statement.Post)
+			if err != nil {
+				return interr.WrapRuntimeError(err, statement.Line)
+			}
+		}
+	}
+}
+
+func (i *Interpreter) VisitForInStatement(statement *ast.ForInStatement) error {
+	iterable, err := i.evaluate(statement.Iterable)
+	if err != nil {
+		return interr.WrapRuntimeError(err, statement.Line)
+	}
+
+	// the elements are taken when the loop starts, so changing the collection inside the loop doesn't affect the iteration
+	// (eg: the keys deleted from a map inside the loop are still visited)
+	var keys, values []interface{}
+	switch collection := iterable.(type) {
+	case *types.List:
+		values = append(values, collection.Elements...)
+		for index := range values {
+			keys = append(keys, float64(index))
+		}
+	case *types.Map:
+		keys = collection.Keys()
+		values = collection.Values()
+	default:
+		return interr.NewRuntimeError("only lists and maps can be iterated", statement.Line)
+	}
+
+	for index := range keys {
+		if err := i.checkContext(statement.Line); err != nil {
+			return err
+		}
+
+		stop, err := i.executeIteration(statement, keys[index], values[index], iterable)
+		if err != nil || stop {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// executeIteration executes an iteration of a range-based for, defining the loop variables in a new env.
+func (i *Interpreter) executeIteration(statement *ast.ForInStatement, key interface{}, value interface{}, iterable interface{}) (bool, error) {
+	previousEnv := i.env
+	env := NewLocal(previousEnv)
+	i.env = env
+	defer func() {
+		i.env = previousEnv
+		i.release(env)
+	}()
+
+	values := []interface{}{key, value}
+	if len(statement.Variables) == 1 {
+		// a single variable takes the elements of a list, or the keys of a map
+		if _, isMap := iterable.(*types.Map); !isMap {
+			values = []interface{}{value}
+		}
+	}
+
+	for index, variable := range statement.Variables {
+		err := i.define(env, variable.Lexeme, values[index], statement.Line)
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return i.executeLoopBody(statement.Body)
+}
+
+// executeLoopBody executes the body of a loop, returning true when the loop must stop (because of a break).
+func (i *Interpreter) executeLoopBody(body ast.Statement) (bool, error) {
+	err := i.execute(body)
+	switch err {
+	case nil, continueSignal:
+		return false, nil
+	case breakSignal:
+		return true, nil
+	default:
+		return true, err
+	}
+}
@@ -0,0 +1,105 @@
+package interpreter
+
+import (
+	"fmt"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+// Module is the runtime representation of an imported file.
+// Its members are the top-level declarations of the file.
+type Module struct {
+	name string // path used in the import
+	env  *Env   // global env where the file was run
+}
+
+// Get returns the value of a top-level declaration of the module.
+func (m *Module) Get(name *token.Token) (interface{}, error) {
+	if !m.env.values.Exists(name.Lexeme) {
+		return nil, fmt.Errorf("the module %q has no member %q", m.name, name.Lexeme)
+	}
+	return m.env.values.Get(name.Lexeme), nil
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
+
+// SetBaseDir sets the directory used to resolve the relative imports (by default, the working directory).
+func (i *Interpreter) SetBaseDir(dir string) {
+	i.modules.SetBaseDir(dir)
+}
+
+// SetMainFile sets the file of the program being run, so the imports of the file itself (from the modules it
+// imports) are reported as cyclic, instead of running the file again.
+func (i *Interpreter) SetMainFile(path string) {
+	i.modules.SetMainFile(path)
+}
+
+// SetOptimizations sets whether the imported files are optimized (see the optimizer package) before being resolved.
+func (i *Interpreter) SetOptimizations(enabled bool) {
+	i.modules.SetOptimizations(enabled)
+}
+
+func (i *Interpreter) VisitImportStatement(statement *ast.ImportStatement) error {
+	path := statement.Path.Literal.(string)
+
+	module, err := i.modules.Import(path, statement.Line, func(absPath string) (*Module, error) {
+		return i.importModule(path, absPath, statement.Line)
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.define(i.env, statement.Name.Lexeme, module, statement.Line)
+}
+
+// importModule scans, parses, resolves and runs a file (in its own global env), imported at the given line.
+func (i *Interpreter) importModule(name string, path string, line int) (*Module, error) {
+	statements, err := i.modules.Parse(path, NewResolver(i))
+	if err != nil {
+		return nil, err
+	}
+
+	module := &Module{
+		name: name,
+		env:  NewLocal(i.builtins),
+	}
+	i.files[module.env] = name
+
+	err = i.runModule(module, statements, line)
+	if err != nil {
+		return nil, err
+	}
+
+	return module, nil
+}
+
+// file returns the imported file of the code being run (empty for the program being run).
+func (i *Interpreter) file() string {
+	return i.files[i.global]
+}
+
+// runModule runs the statements of a module in its own global env.
+func (i *Interpreter) runModule(module *Module, statements []ast.Statement, line int) error {
+	if i.profiler != nil {
+		i.profiler.Enter(ModuleFunction, module.name, line)
+		defer i.profiler.Exit()
+	}
+
+	previousEnv, previousGlobal := i.env, i.global
+	i.env, i.global = module.env, module.env
+	defer func() {
+		i.env, i.global = previousEnv, previousGlobal
+	}()
+
+	for _, statement := range statements {
+		err := i.execute(statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package interpreter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/usecase/optimizer"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+)
+
+type (
+	// Modules keeps track of the files imported by a program, for the backends that run them (where M is the
+	// runtime representation of a module): it resolves their paths, detects the cyclic imports, and keeps the
+	// imported modules so each file is run only once.
+	Modules[M any] struct {
+		dir       string            // directory used to resolve the relative imports
+		cache     map[string]M      // imported modules (by absolute path)
+		sources   map[string]string // source code of the files read (by absolute path), for the excerpts of their errors
+		main      *importing        // file of the program being run (if any), the first one in the cyclic imports
+		importing []importing       // files being imported (used to detect cyclic imports)
+		optimize  bool              // whether the imported files are optimized before being resolved
+		maxString int               // max length of the strings folded by the optimizer (0 means there is no limit)
+	}
+
+	importing struct {
+		name string // path used in the import
+		path string // absolute path of the file
+	}
+
+	// cyclicImportError is the error returned when a file imports itself (directly or not).
+	cyclicImportError struct {
+		chain []string // paths of the imports that make the cycle
+	}
+)
+
+// NewModules is a constructor for Modules.
+func NewModules[M any]() *Modules[M] {
+	return &Modules[M]{
+		cache:   map[string]M{},
+		sources: map[string]string{},
+	}
+}
+
+func (e cyclicImportError) Error() string {
+	return fmt.Sprintf("cyclic import: %s", strings.Join(e.chain, " -> "))
+}
+
+// SetBaseDir sets the directory used to resolve the relative imports (by default, the working directory).
+func (m *Modules[M]) SetBaseDir(dir string) {
+	m.dir = dir
+}
+
+// SetMainFile sets the file of the program being run, so the imports of the file itself (from the modules it
+// imports) are reported as cyclic, instead of running the file again.
+func (m *Modules[M]) SetMainFile(path string) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path // the working directory is not available, so neither are the relative imports
+	}
+	m.main = &importing{name: path, path: absPath}
+}
+
+// SetOptimizations sets whether the imported files are optimized (see the optimizer package) before being resolved.
+func (m *Modules[M]) SetOptimizations(enabled bool) {
+	m.optimize = enabled
+}
+
+// SetMaxStringLength sets the max length of the strings folded by the optimizer (the limit of the runtime).
+func (m *Modules[M]) SetMaxStringLength(length int) {
+	m.maxString = length
+}
+
+// Import returns the module of the file imported by an import statement (in the given line), calling load to run
+// it (with the imports resolved from its directory), unless it was already imported.
+// The errors are runtime errors of the import statement, except for the cycles, that are reported once (by the
+// import that started them), and the errors found in the imported file, that point to the file (see moduleError).
+func (m *Modules[M]) Import(path string, line int, load func(absPath string) (M, error)) (M, error) {
+	module, err := m.importFile(path, line, load)
+	if err != nil {
+		var cyclicErr cyclicImportError
+		if errors.As(err, &cyclicErr) && len(m.importing) > 0 {
+			return module, cyclicErr
+		}
+
+		if runtimeErr, ok := err.(interr.RuntimeError); ok && runtimeErr.File != "" {
+			return module, runtimeErr
+		}
+
+		return module, interr.RuntimeError{
+			Message: fmt.Sprintf("failed importing %q: %s", path, err),
+			Line:    line,
+			Err:     err,
+		}
+	}
+
+	return module, nil
+}
+
+func (m *Modules[M]) importFile(path string, line int, load func(absPath string) (M, error)) (M, error) {
+	var module M
+
+	file := path
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(m.dir, file)
+	}
+
+	absPath, err := filepath.Abs(file)
+	if err != nil {
+		return module, err
+	}
+
+	stack := m.importing
+	if m.main != nil {
+		stack = append([]importing{*m.main}, stack...)
+	}
+	for index, file := range stack {
+		if file.path == absPath {
+			chain := make([]string, 0, len(stack)-index+1)
+			for _, file := range stack[index:] {
+				chain = append(chain, file.name)
+			}
+			chain = append(chain, path)
+			return module, cyclicImportError{chain: chain}
+		}
+	}
+
+	if module, ok := m.cache[absPath]; ok {
+		return module, nil
+	}
+
+	importer := "" // the program being run
+	if len(m.importing) > 0 {
+		importer = m.importing[len(m.importing)-1].name
+	}
+
+	previousDir := m.dir
+	m.dir = filepath.Dir(absPath)
+	m.importing = append(m.importing, importing{name: path, path: absPath})
+	defer func() {
+		m.dir = previousDir
+		m.importing = m.importing[:len(m.importing)-1]
+	}()
+
+	module, err = load(absPath)
+	if err != nil {
+		var cyclicErr cyclicImportError
+		source, read := m.sources[absPath]
+		if !read || errors.As(err, &cyclicErr) {
+			return module, err
+		}
+		return module, moduleError(err, path, source, importer, line)
+	}
+
+	m.cache[absPath] = module
+	return module, nil
+}
+
+// Parse scans, parses, optimizes (if enabled) and resolves (with the given resolver) an imported file.
+func (m *Modules[M]) Parse(path string, resolver *Resolver) ([]ast.Statement, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed when reading the file: %w", err)
+	}
+
+	source := string(code)
+	m.sources[path] = source
+
+	tokens, err := scanner.NewScanner(bytes.Runes(code)).Scan()
+	if err != nil {
+		return nil, fmt.Errorf("failed on the lexer layer: %w", err.(*scanner.LexingErr).WithExcerpts(source))
+	}
+
+	statements, err := parser.NewParser(tokens).Parse()
+	if err != nil {
+		return nil, err.(*parser.ParsingErr).WithExcerpts(source)
+	}
+
+	if m.optimize {
+		o := optimizer.NewOptimizer()
+		o.SetMaxStringLength(m.maxString)
+		statements, err = o.Optimize(statements)
+		if err != nil {
+			return nil, fmt.Errorf("failed optimizing the statements: %w", err)
+		}
+	}
+
+	err = resolver.Resolve(statements)
+	if err != nil {
+		if resolverErr, ok := err.(ResolverErr); ok {
+			err = resolverErr.WithExcerpt(source)
+		}
+		return nil, fmt.Errorf("failed resolving the statements: %w", err)
+	}
+
+	return statements, nil
+}
+
+// moduleError returns the error found in an imported file as a runtime error of the file (with the excerpt of
+// its source code), that went out of the import statement of the importer file (in the given line).
+func moduleError(err error, path string, source string, importer string, line int) interr.RuntimeError {
+	runtimeErr, ok := err.(interr.RuntimeError)
+	if !ok {
+		// eg: the file could not be parsed, or it read an undefined variable (that fails without a line)
+		runtimeErr = interr.RuntimeError{Message: strings.TrimSuffix(err.Error(), "\n"), Err: err}
+	}
+
+	runtimeErr = interr.InFile(runtimeErr, path).(interr.RuntimeError).WithFileExcerpt(path, source)
+	runtimeErr.Message = fmt.Sprintf("failed importing %q: %s", path, runtimeErr.Message)
+	runtimeErr.Trace = append(runtimeErr.Trace, interr.Frame{Function: fmt.Sprintf("<module %s>", path), Line: line, File: importer})
+	return runtimeErr
+}
@@ -1,45 +1,155 @@
 package interpreter
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/avazquezcode/govetryx/internal/domain/types"
 )
 
 type (
-	FnClock struct{}
-	FnSleep struct{}
-	FnMin   struct{}
-	FnMax   struct{}
+	FnClock  struct{}
+	FnSleep  struct{}
+	FnMin    struct{}
+	FnMax    struct{}
+	FnInput  struct{}
+	FnLen    struct{}
+	FnPush   struct{}
+	FnPop    struct{}
+	FnSlice  struct{}
+	FnKeys   struct{}
+	FnValues struct{}
+	FnHas    struct{}
+	FnDelete struct{}
+
+	// Host is the engine where the native functions run (eg: the interpreter, or the VM).
+	Host interface {
+		Context() context.Context
+		Stdin() *bufio.Reader
+	}
+
+	// Native is the interface implemented by the functions implemented in Go (that can be called from any engine).
+	Native interface {
+		Call(host Host, arguments []interface{}) (interface{}, error)
+		Arity() int
+		Name() string
+	}
+
+	// NativeFunction is a function implemented in Go, that can be called from the scripts.
+	NativeFunction struct {
+		name  string
+		arity int
+		fn    func(arguments []interface{}) (interface{}, error)
+	}
 )
 
+// Natives returns the native functions available in all the programs (by name).
+func Natives() map[string]Native {
+	natives := map[string]Native{}
+	for _, native := range []Native{
+		FnSleep{}, FnClock{}, FnMin{}, FnMax{}, FnInput{}, FnLen{}, FnPush{},
+		FnPop{}, FnSlice{}, FnKeys{}, FnValues{}, FnHas{}, FnDelete{},
+	} {
+		natives[native.Name()] = native
+	}
+	return natives
+}
+
+// NewNativeFunction is a constructor for a native function.
+// Use VariadicArity as arity, if the function accepts any quantity of arguments.
+func NewNativeFunction(name string, arity int, fn func(arguments []interface{}) (interface{}, error)) *NativeFunction {
+	return &NativeFunction{
+		name:  name,
+		arity: arity,
+		fn:    fn,
+	}
+}
+
+func (n *NativeFunction) Name() string {
+	return n.name
+}
+
+func (n *NativeFunction) Arity() int {
+	return n.arity
+}
+
+func (n *NativeFunction) Call(host Host, arguments []interface{}) (interface{}, error) {
+	return n.fn(arguments)
+}
+
+func (n *NativeFunction) String() string {
+	return nativeString(n)
+}
+
+func nativeString(n Native) string {
+	return fmt.Sprintf("<native fn %s>", n.Name())
+}
+
+func (n FnClock) Name() string {
+	return "clock"
+}
+
+func (n FnClock) String() string {
+	return nativeString(n)
+}
+
 func (n FnClock) Arity() int {
 	return 0
 }
 
-func (n FnClock) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+func (n FnClock) Call(host Host, arguments []interface{}) (interface{}, error) {
 	return float64(time.Now().UnixNano()), nil
 }
 
+func (n FnSleep) Name() string {
+	return "sleep"
+}
+
+func (n FnSleep) String() string {
+	return nativeString(n)
+}
+
 func (n FnSleep) Arity() int {
 	return 1
 }
 
-func (n FnSleep) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+func (n FnSleep) Call(host Host, arguments []interface{}) (interface{}, error) {
 	milliSeconds, validFloat := arguments[0].(float64)
 	if !validFloat {
 		return nil, fmt.Errorf("argument must be a valid float")
 	}
 
-	// Sleep
-	time.Sleep(time.Duration(float64(time.Millisecond) * milliSeconds))
-	return nil, nil
+	// Sleep (unless the execution is canceled first)
+	timer := time.NewTimer(time.Duration(float64(time.Millisecond) * milliSeconds))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil, nil
+	case <-host.Context().Done():
+		return nil, ContextErr(host.Context())
+	}
+}
+
+func (n FnMin) Name() string {
+	return "min"
+}
+
+func (n FnMin) String() string {
+	return nativeString(n)
 }
 
 func (n FnMin) Arity() int {
 	return 2
 }
 
-func (n FnMin) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+func (n FnMin) Call(host Host, arguments []interface{}) (interface{}, error) {
 	v1, validFloat := arguments[0].(float64)
 	if !validFloat {
 		return nil, fmt.Errorf("argument must be a valid float")
@@ -53,11 +163,19 @@ func (n FnMin) Call(interpreter *Interpreter, arguments []interface{}) (interfac
 	return min(v1, v2), nil
 }
 
+func (n FnMax) Name() string {
+	return "max"
+}
+
+func (n FnMax) String() string {
+	return nativeString(n)
+}
+
 func (n FnMax) Arity() int {
 	return 2
 }
 
-func (n FnMax) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+func (n FnMax) Call(host Host, arguments []interface{}) (interface{}, error) {
 	v1, validFloat := arguments[0].(float64)
 	if !validFloat {
 		return nil, fmt.Errorf("argument must be a valid float")
@@ -70,3 +188,227 @@ func (n FnMax) Call(interpreter *Interpreter, arguments []interface{}) (interfac
 
 	return max(v1, v2), nil
 }
+
+func (n FnInput) Name() string {
+	return "input"
+}
+
+func (n FnInput) String() string {
+	return nativeString(n)
+}
+
+func (n FnInput) Arity() int {
+	return 0
+}
+
+func (n FnInput) Call(host Host, arguments []interface{}) (interface{}, error) {
+	line, err := host.Stdin().ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed reading from the input: %w", err)
+	}
+
+	if errors.Is(err, io.EOF) && line == "" {
+		return nil, nil // nothing else to read
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (n FnLen) Name() string {
+	return "len"
+}
+
+func (n FnLen) String() string {
+	return nativeString(n)
+}
+
+func (n FnLen) Arity() int {
+	return 1
+}
+
+func (n FnLen) Call(host Host, arguments []interface{}) (interface{}, error) {
+	switch value := arguments[0].(type) {
+	case *types.List:
+		return float64(value.Len()), nil
+	case *types.Map:
+		return float64(value.Len()), nil
+	case string:
+		return float64(utf8.RuneCountInString(value)), nil
+	}
+
+	return nil, fmt.Errorf("argument must be a list, a map or a string")
+}
+
+func (n FnPush) Name() string {
+	return "push"
+}
+
+func (n FnPush) String() string {
+	return nativeString(n)
+}
+
+func (n FnPush) Arity() int {
+	return 2
+}
+
+func (n FnPush) Call(host Host, arguments []interface{}) (interface{}, error) {
+	list, validList := arguments[0].(*types.List)
+	if !validList {
+		return nil, fmt.Errorf("argument must be a valid list")
+	}
+
+	list.Push(arguments[1])
+	return float64(list.Len()), nil
+}
+
+func (n FnPop) Name() string {
+	return "pop"
+}
+
+func (n FnPop) String() string {
+	return nativeString(n)
+}
+
+func (n FnPop) Arity() int {
+	return 1
+}
+
+func (n FnPop) Call(host Host, arguments []interface{}) (interface{}, error) {
+	list, validList := arguments[0].(*types.List)
+	if !validList {
+		return nil, fmt.Errorf("argument must be a valid list")
+	}
+
+	if list.Len() == 0 {
+		return nil, fmt.Errorf("cannot pop from an empty list")
+	}
+
+	return list.Pop(), nil
+}
+
+func (n FnSlice) Name() string {
+	return "slice"
+}
+
+func (n FnSlice) String() string {
+	return nativeString(n)
+}
+
+func (n FnSlice) Arity() int {
+	return 3
+}
+
+func (n FnSlice) Call(host Host, arguments []interface{}) (interface{}, error) {
+	list, validList := arguments[0].(*types.List)
+	if !validList {
+		return nil, fmt.Errorf("argument must be a valid list")
+	}
+
+	start, err := ToInt(arguments[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %w", err)
+	}
+
+	end, err := ToInt(arguments[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %w", err)
+	}
+
+	if start < 0 || end > list.Len() || start > end {
+		return nil, fmt.Errorf("slice bounds [%d:%d] out of range (length %d)", start, end, list.Len())
+	}
+
+	return list.Slice(start, end), nil
+}
+
+func (n FnKeys) Name() string {
+	return "keys"
+}
+
+func (n FnKeys) String() string {
+	return nativeString(n)
+}
+
+func (n FnKeys) Arity() int {
+	return 1
+}
+
+func (n FnKeys) Call(host Host, arguments []interface{}) (interface{}, error) {
+	m, validMap := arguments[0].(*types.Map)
+	if !validMap {
+		return nil, fmt.Errorf("argument must be a valid map")
+	}
+
+	return types.NewList(m.Keys()), nil
+}
+
+func (n FnValues) Name() string {
+	return "values"
+}
+
+func (n FnValues) String() string {
+	return nativeString(n)
+}
+
+func (n FnValues) Arity() int {
+	return 1
+}
+
+func (n FnValues) Call(host Host, arguments []interface{}) (interface{}, error) {
+	m, validMap := arguments[0].(*types.Map)
+	if !validMap {
+		return nil, fmt.Errorf("argument must be a valid map")
+	}
+
+	return types.NewList(m.Values()), nil
+}
+
+func (n FnHas) Name() string {
+	return "has"
+}
+
+func (n FnHas) String() string {
+	return nativeString(n)
+}
+
+func (n FnHas) Arity() int {
+	return 2
+}
+
+func (n FnHas) Call(host Host, arguments []interface{}) (interface{}, error) {
+	m, validMap := arguments[0].(*types.Map)
+	if !validMap {
+		return nil, fmt.Errorf("argument must be a valid map")
+	}
+
+	if err := ValidateMapKey(arguments[1]); err != nil {
+		return nil, err
+	}
+
+	return m.Exists(arguments[1]), nil
+}
+
+func (n FnDelete) Name() string {
+	return "delete"
+}
+
+func (n FnDelete) String() string {
+	return nativeString(n)
+}
+
+func (n FnDelete) Arity() int {
+	return 2
+}
+
+func (n FnDelete) Call(host Host, arguments []interface{}) (interface{}, error) {
+	m, validMap := arguments[0].(*types.Map)
+	if !validMap {
+		return nil, fmt.Errorf("argument must be a valid map")
+	}
+
+	if err := ValidateMapKey(arguments[1]); err != nil {
+		return nil, err
+	}
+
+	return m.Delete(arguments[1]), nil
+}
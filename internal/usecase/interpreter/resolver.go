@@ -4,16 +4,73 @@ import (
 	"fmt"
 
 	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
 	"github.com/avazquezcode/govetryx/internal/domain/token"
 	"github.com/avazquezcode/govetryx/internal/domain/types"
 )
 
+type (
+	// functionType indicates the type of function being resolved.
+	functionType int
+	// classType indicates the type of class being resolved.
+	classType int
+)
+
+const (
+	noFunction functionType = iota
+	function
+	method
+	initializer
+)
+
+const (
+	noClass classType = iota
+	class
+	subclass
+)
+
+// ResolverErr is an error found by the resolver (eg: a break outside a loop).
+type ResolverErr struct {
+	Message string
+	Span    token.Span // the code that is not valid
+	Excerpt string     // the excerpt of the source code that is not valid (see WithExcerpt)
+}
+
+// newResolverErr is a constructor for a resolver error, found in the given span of the source code.
+func newResolverErr(span token.Span, format string, args ...interface{}) ResolverErr {
+	return ResolverErr{
+		Message: fmt.Sprintf(format, args...),
+		Span:    span,
+	}
+}
+
+// WithExcerpt returns the error with the excerpt of the source code that is not valid.
+func (e ResolverErr) WithExcerpt(source string) ResolverErr {
+	e.Excerpt = interr.Excerpt(source, e.Span)
+	return e
+}
+
+func (e ResolverErr) Error() string {
+	message := e.Message
+	if e.Span.IsKnown() {
+		message = fmt.Sprintf("%s (at line %d, column %d)", e.Message, e.Span.Start.Line, e.Span.Start.Column)
+	}
+
+	if e.Excerpt != "" {
+		message = message + "\n" + e.Excerpt
+	}
+	return message
+}
+
 // Resolver is an important piece of our interpreter, since it resolves the scoping of things.
+// Without an interpreter, it only runs the static checks (eg: a break outside a loop).
 type Resolver struct {
-	interpreter    *Interpreter
-	stack          types.Stack
-	insideFunction bool // indicates if we are inside a function
-	insideLoop     bool // indicates if we are inside a loop
+	interpreter     *Interpreter
+	stack           types.Stack
+	currentFunction functionType // indicates the type of function we are inside of (if any)
+	currentClass    classType    // indicates the type of class we are inside of (if any)
+	insideLoop      bool         // indicates if we are inside a loop
+	symbols         *Symbols     // the declared symbols and their references (only if recorded, see RecordSymbols)
 }
 
 func NewResolver(interpreter *Interpreter) *Resolver {
@@ -23,6 +80,13 @@ func NewResolver(interpreter *Interpreter) *Resolver {
 	}
 }
 
+// RecordSymbols makes the resolver record the symbols declared in the code, and the references to each one
+// (eg: used by the editors to find the definition of a variable). The returned symbols are filled by Resolve.
+func (r *Resolver) RecordSymbols() *Symbols {
+	r.symbols = NewSymbols()
+	return r.symbols
+}
+
 func (r *Resolver) Resolve(statements []ast.Statement) error {
 	for _, statement := range statements {
 		err := statement.Accept(r)
@@ -66,25 +130,81 @@ func (r *Resolver) VisitWhileStatement(v *ast.WhileStatement) error {
 		return err
 	}
 
-	r.insideLoop = true
+	return r.resolveLoopBody(v.Body)
+}
+
+func (r *Resolver) VisitForStatement(v *ast.ForStatement) error {
+	// the variables declared in the initializer live in their own scope (that wraps the body)
+	r.beginScope()
+	defer r.endScope()
+
+	if v.Initializer != nil {
+		err := v.Initializer.Accept(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.Condition != nil {
+		_, err := v.Condition.Accept(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.Post != nil {
+		_, err := v.Post.Accept(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.resolveLoopBody(v.Body)
+}
+
+func (r *Resolver) VisitForInStatement(v *ast.ForInStatement) error {
+	// the iterable is resolved outside the scope of the loop variables
+	_, err := v.Iterable.Accept(r)
+	if err != nil {
+		return err
+	}
+
+	r.beginScope()
+	defer r.endScope()
+
+	for _, variable := range v.Variables {
+		err := r.declare(variable)
+		if err != nil {
+			return err
+		}
+		r.record(variable, VariableSymbol, VariadicArity)
+		r.define(variable.Lexeme)
+	}
 
-	err = v.Body.Accept(r)
+	return r.resolveLoopBody(v.Body)
+}
+
+// resolveLoopBody resolves the body of a loop (where break and continue are allowed).
+func (r *Resolver) resolveLoopBody(body ast.Statement) error {
+	enclosingLoop := r.insideLoop
+	r.insideLoop = true
 
-	r.insideLoop = false
+	err := body.Accept(r)
 
+	r.insideLoop = enclosingLoop
 	return err
 }
 
 func (r *Resolver) VisitBreakStatement(v *ast.BreakStatement) error {
 	if !r.insideLoop {
-		return fmt.Errorf("cannot execute a break statement outside a loop")
+		return newResolverErr(v.Position(), "cannot execute a break statement outside a loop")
 	}
 	return nil
 }
 
 func (r *Resolver) VisitContinueStatement(v *ast.ContinueStatement) error {
 	if !r.insideLoop {
-		return fmt.Errorf("cannot execute a continue statement outside a loop")
+		return newResolverErr(v.Position(), "cannot execute a continue statement outside a loop")
 	}
 	return nil
 }
@@ -127,6 +247,58 @@ func (r *Resolver) VisitIfStatement(v *ast.IfStatement) error {
 	return nil
 }
 
+func (r *Resolver) VisitListExpression(v *ast.ListExpression) (interface{}, error) {
+	for _, element := range v.Elements {
+		_, err := element.Accept(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpression(v *ast.MapExpression) (interface{}, error) {
+	for i := range v.Keys {
+		_, err := v.Keys[i].Accept(r)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = v.Values[i].Accept(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexExpression(v *ast.IndexExpression) (interface{}, error) {
+	_, err := v.Object.Accept(r)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = v.Index.Accept(r)
+	return nil, err
+}
+
+func (r *Resolver) VisitIndexAssignmentExpression(v *ast.IndexAssignmentExpression) (interface{}, error) {
+	_, err := v.Value.Accept(r)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = v.Object.Accept(r)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = v.Index.Accept(r)
+	return nil, err
+}
+
 // Block resolution (start a new scope for the block, and then close it)
 
 func (r *Resolver) VisitBlockStatement(v *ast.BlockStatement) error {
@@ -143,6 +315,7 @@ func (r *Resolver) VisitVariableStatement(statement *ast.VariableStatement) erro
 	if err != nil {
 		return err
 	}
+	r.record(statement.Name, VariableSymbol, valueArity(statement.Value))
 
 	if statement.Value != nil {
 		_, err := statement.Value.Accept(r)
@@ -159,10 +332,11 @@ func (r *Resolver) VisitVariableExpression(expression *ast.VariableExpression) (
 	if r.stack.Length() > 0 {
 		initialized, ok := r.stack.Peek().(types.HashMap)[expression.Name.Lexeme]
 		if ok && !initialized.(bool) {
-			return nil, fmt.Errorf("failed when reading a local variable %q in its initializer", expression.Name.Lexeme)
+			return nil, newResolverErr(expression.Position(), "failed when reading a local variable %q in its initializer", expression.Name.Lexeme)
 		}
 	}
 
+	r.use(expression.Name)
 	return nil, r.resolveLocal(expression, expression.Name.Lexeme)
 }
 
@@ -172,6 +346,7 @@ func (r *Resolver) VisitAssignmentExpression(expression *ast.AssignmentExpressio
 		return nil, err
 	}
 
+	r.use(expression.Name)
 	return nil, r.resolveLocal(expression, expression.Name.Lexeme)
 }
 
@@ -180,7 +355,9 @@ func (r *Resolver) resolveLocal(expression ast.Expression, key string) error {
 	for i := lastElementIndex; i >= 0; i-- {
 		if r.stack[i].(types.HashMap).Exists(key) {
 			// resolve with the key that is closest to the peek of the stack
-			r.interpreter.Resolve(expression, lastElementIndex-i)
+			if r.interpreter != nil {
+				r.interpreter.Resolve(expression, lastElementIndex-i)
+			}
 			return nil
 		}
 	}
@@ -195,17 +372,26 @@ func (r *Resolver) VisitFunctionStatement(statement *ast.FunctionStatement) erro
 	if err != nil {
 		return err
 	}
+	r.record(statement.Name, FunctionSymbol, len(statement.Paremeters))
 	r.define(statement.Name.Lexeme)
 
-	return r.resolveFunction(statement)
+	return r.resolveFunction(statement.Paremeters, statement.Body, function)
+}
+
+func (r *Resolver) VisitFunctionExpression(expression *ast.FunctionExpression) (interface{}, error) {
+	return nil, r.resolveFunction(expression.Parameters, expression.Body, function)
 }
 
 func (r *Resolver) VisitReturnStatement(statement *ast.ReturnStatement) error {
-	if !r.insideFunction {
-		return fmt.Errorf("cannot return from outside a valid function")
+	if r.currentFunction == noFunction {
+		return newResolverErr(statement.Position(), "cannot return from outside a valid function")
 	}
 
 	if statement.Value != nil {
+		if r.currentFunction == initializer {
+			return newResolverErr(statement.Position(), "cannot return a value from an initializer")
+		}
+
 		_, err := statement.Value.Accept(r)
 		return err
 	}
@@ -229,37 +415,182 @@ func (r *Resolver) VisitCallExpression(expression *ast.CallExpression) (interfac
 	return nil, nil
 }
 
-func (r *Resolver) resolveFunction(statement *ast.FunctionStatement) error {
-	wasInsideFunction := r.insideFunction
+func (r *Resolver) resolveFunction(params []*token.Token, body []ast.Statement, fnType functionType) error {
+	enclosingFunction := r.currentFunction
+	enclosingLoop := r.insideLoop
 	r.beginScope()
-	r.insideFunction = true
+	r.currentFunction = fnType
+	r.insideLoop = false // a break or continue inside a function can't affect a loop outside of it
 
-	for _, param := range statement.Paremeters {
+	for _, param := range params {
 		err := r.declare(param)
 		if err != nil {
 			return err
 		}
+		r.record(param, ParameterSymbol, VariadicArity)
 		r.define(param.Lexeme)
 	}
 
-	err := r.Resolve(statement.Body)
+	err := r.Resolve(body)
 
 	r.endScope()
-	r.insideFunction = wasInsideFunction
+	r.currentFunction = enclosingFunction
+	r.insideLoop = enclosingLoop
+
+	return err
+}
+
+// Exceptions resolution
 
+func (r *Resolver) VisitThrowStatement(statement *ast.ThrowStatement) error {
+	_, err := statement.Value.Accept(r)
 	return err
 }
 
+func (r *Resolver) VisitTryStatement(statement *ast.TryStatement) error {
+	err := statement.Body.Accept(r)
+	if err != nil {
+		return err
+	}
+
+	if statement.CatchBody != nil {
+		// the caught error lives in its own scope (that wraps the catch body)
+		r.beginScope()
+		r.record(statement.CatchName, VariableSymbol, VariadicArity)
+		r.define(statement.CatchName.Lexeme)
+		err = statement.CatchBody.Accept(r)
+		r.endScope()
+		if err != nil {
+			return err
+		}
+	}
+
+	if statement.FinallyBody != nil {
+		return statement.FinallyBody.Accept(r)
+	}
+
+	return nil
+}
+
+// Modules resolution
+
+func (r *Resolver) VisitImportStatement(statement *ast.ImportStatement) error {
+	if r.stack.Length() > 0 || r.currentFunction != noFunction {
+		return newResolverErr(statement.Position(), "the imports are only allowed at the top level")
+	}
+
+	err := r.declare(statement.Name)
+	if err != nil {
+		return err
+	}
+	r.record(statement.Name, ImportSymbol, VariadicArity)
+	r.define(statement.Name.Lexeme)
+
+	return nil
+}
+
+// Classes resolution
+
+func (r *Resolver) VisitClassStatement(statement *ast.ClassStatement) error {
+	enclosingClass := r.currentClass
+	r.currentClass = class
+	defer func() { r.currentClass = enclosingClass }()
+
+	err := r.declare(statement.Name)
+	if err != nil {
+		return err
+	}
+	r.record(statement.Name, ClassSymbol, classArity(statement))
+	r.define(statement.Name.Lexeme)
+
+	if statement.Superclass != nil {
+		if statement.Superclass.Name.Lexeme == statement.Name.Lexeme {
+			return newResolverErr(statement.Superclass.Position(), "the class %q cannot inherit from itself", statement.Name.Lexeme)
+		}
+
+		r.currentClass = subclass
+		_, err := statement.Superclass.Accept(r)
+		if err != nil {
+			return err
+		}
+
+		// scope where "super" is defined
+		r.beginScope()
+		defer r.endScope()
+		r.define("super")
+	}
+
+	// scope where "this" is defined
+	r.beginScope()
+	defer r.endScope()
+	r.define("this")
+
+	for _, m := range statement.Methods {
+		fnType := method
+		if m.Name.Lexeme == "init" {
+			fnType = initializer
+		}
+
+		err := r.resolveFunction(m.Paremeters, m.Body, fnType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Resolver) VisitGetExpression(expression *ast.GetExpression) (interface{}, error) {
+	_, err := expression.Object.Accept(r)
+	return nil, err
+}
+
+func (r *Resolver) VisitSetExpression(expression *ast.SetExpression) (interface{}, error) {
+	_, err := expression.Value.Accept(r)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = expression.Object.Accept(r)
+	return nil, err
+}
+
+func (r *Resolver) VisitThisExpression(expression *ast.ThisExpression) (interface{}, error) {
+	if r.currentClass == noClass {
+		return nil, newResolverErr(expression.Position(), "cannot use 'this' outside of a class")
+	}
+
+	return nil, r.resolveLocal(expression, "this")
+}
+
+func (r *Resolver) VisitSuperExpression(expression *ast.SuperExpression) (interface{}, error) {
+	if r.currentClass == noClass {
+		return nil, newResolverErr(expression.Position(), "cannot use 'super' outside of a class")
+	}
+
+	if r.currentClass != subclass {
+		return nil, newResolverErr(expression.Position(), "cannot use 'super' in a class without superclass")
+	}
+
+	return nil, r.resolveLocal(expression, "super")
+}
+
 // Scope management
 
 func (r *Resolver) beginScope() {
 	// Add new scope to the stack.
 	r.stack.Push(types.HashMap{})
+	if r.symbols != nil {
+		r.symbols.beginScope()
+	}
 }
 
 func (r *Resolver) endScope() {
 	// Remove last scope from the stack.
 	r.stack.Pop()
+	if r.symbols != nil {
+		r.symbols.endScope()
+	}
 }
 
 // Declaration and definition
@@ -271,7 +602,7 @@ func (r *Resolver) declare(name *token.Token) error {
 
 	currentScope := r.stack.Peek().(types.HashMap)
 	if _, exists := currentScope[name.Lexeme]; exists {
-		return fmt.Errorf("the variable %q already exists in the scope", name.Lexeme)
+		return newResolverErr(name.Span, "the variable %q already exists in the scope", name.Lexeme)
 	}
 
 	currentScope.Set(name.Lexeme, false)
@@ -284,3 +615,17 @@ func (r *Resolver) define(key string) {
 		currentScope.Set(key, true)
 	}
 }
+
+// record records the declaration of a symbol (if the symbols are recorded).
+func (r *Resolver) record(name *token.Token, kind SymbolKind, arity int) {
+	if r.symbols != nil {
+		r.symbols.declare(name, kind, arity)
+	}
+}
+
+// use records a reference to a symbol (if the symbols are recorded).
+func (r *Resolver) use(name *token.Token) {
+	if r.symbols != nil {
+		r.symbols.use(name)
+	}
+}
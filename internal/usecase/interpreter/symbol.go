@@ -0,0 +1,116 @@
+package interpreter
+
+import (
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+// SymbolKind indicates what declared a symbol.
+type SymbolKind int
+
+const (
+	VariableSymbol SymbolKind = iota
+	FunctionSymbol
+	ClassSymbol
+	ParameterSymbol
+	ImportSymbol
+)
+
+// Symbol is a name declared in the code (eg: a variable, or a function), with the places where it's used.
+type Symbol struct {
+	Name       *token.Token // the token where it's declared
+	Kind       SymbolKind
+	Arity      int  // the number of arguments expected when calling it (VariadicArity when it's not known)
+	Global     bool // declared at the top level
+	References []*token.Token
+}
+
+// Symbols keeps the scope data found by the resolver: the declared symbols, and the references to each one.
+// The references to the globals are linked at the end (since a function can use a global declared after it).
+type Symbols struct {
+	declarations []*Symbol
+	scopes       []map[string]*Symbol
+	globals      map[string]*Symbol
+	pending      []*token.Token // references not found in the local scopes
+	unresolved   []*token.Token // references that weren't declared in the code (eg: the native functions)
+}
+
+// NewSymbols is a constructor for an empty set of Symbols.
+func NewSymbols() *Symbols {
+	return &Symbols{
+		globals: map[string]*Symbol{},
+	}
+}
+
+// All returns the declared symbols, in the order of their declarations.
+func (s *Symbols) All() []*Symbol {
+	s.link()
+	return s.declarations
+}
+
+// Unresolved returns the references to names that weren't declared in the code (eg: the native functions).
+func (s *Symbols) Unresolved() []*token.Token {
+	s.link()
+	return s.unresolved
+}
+
+// link links the pending references to the globals with the same name.
+func (s *Symbols) link() {
+	for _, name := range s.pending {
+		if symbol, exists := s.globals[name.Lexeme]; exists {
+			symbol.References = append(symbol.References, name)
+			continue
+		}
+		s.unresolved = append(s.unresolved, name)
+	}
+	s.pending = nil
+}
+
+func (s *Symbols) beginScope() {
+	s.scopes = append(s.scopes, map[string]*Symbol{})
+}
+
+func (s *Symbols) endScope() {
+	s.scopes = s.scopes[:len(s.scopes)-1]
+}
+
+// declare adds the symbol declared by the given name to the current scope.
+func (s *Symbols) declare(name *token.Token, kind SymbolKind, arity int) {
+	symbol := &Symbol{Name: name, Kind: kind, Arity: arity, Global: len(s.scopes) == 0}
+	s.declarations = append(s.declarations, symbol)
+
+	if symbol.Global {
+		s.globals[name.Lexeme] = symbol
+		return
+	}
+	s.scopes[len(s.scopes)-1][name.Lexeme] = symbol
+}
+
+// use adds a reference to the closest symbol declared with the given name.
+func (s *Symbols) use(name *token.Token) {
+	for i := len(s.scopes) - 1; i >= 0; i-- {
+		if symbol, exists := s.scopes[i][name.Lexeme]; exists {
+			symbol.References = append(symbol.References, name)
+			return
+		}
+	}
+	s.pending = append(s.pending, name)
+}
+
+// classArity returns the number of arguments expected when calling a class (the ones of its initializer).
+func classArity(statement *ast.ClassStatement) int {
+	for _, m := range statement.Methods {
+		if m.Name.Lexeme == "init" {
+			return len(m.Paremeters)
+		}
+	}
+	return 0
+}
+
+// valueArity returns the number of arguments expected when calling the value of a variable.
+func valueArity(value ast.Expression) int {
+	if function, ok := value.(*ast.FunctionExpression); ok {
+		return len(function.Parameters)
+	}
+	return VariadicArity
+}
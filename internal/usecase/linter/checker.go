@@ -0,0 +1,498 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/evaluator"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+)
+
+// unknownArity is the arity of the bindings that are not known functions (or that accept any number of arguments).
+const unknownArity = interpreter.VariadicArity
+
+// bindingKind indicates what was declared by a binding.
+type bindingKind int
+
+const (
+	variableBinding bindingKind = iota
+	parameterBinding
+	functionBinding
+	classBinding
+	otherBinding // imports, loop variables and caught errors (they are not reported when unused)
+)
+
+type (
+	// binding is a name declared in a scope.
+	binding struct {
+		name  *token.Token // nil for the native functions
+		kind  bindingKind
+		arity int
+		used  bool
+	}
+
+	// scope is a local scope, with its bindings in the order they were declared.
+	scope struct {
+		bindings map[string]*binding
+		order    []*binding
+	}
+
+	// checker walks the AST, tracking the scopes (like the resolver), and collecting the problems found.
+	checker struct {
+		lines       map[ast.Statement]ast.LineRange
+		globals     map[string]*binding
+		scopes      []*scope
+		diagnostics []Diagnostic
+	}
+)
+
+// comparisons are the comparison operators, with their result when both operands are the same.
+var comparisons = map[token.Type]bool{
+	token.EqualEqual:     true,
+	token.NotEqual:       false,
+	token.Lower:          false,
+	token.LowerOrEqual:   true,
+	token.Greater:        false,
+	token.GreaterOrEqual: true,
+}
+
+func newChecker(lines map[ast.Statement]ast.LineRange) *checker {
+	globals := map[string]*binding{}
+	for name, native := range interpreter.Natives() {
+		globals[name] = &binding{kind: functionBinding, arity: native.Arity()}
+	}
+
+	return &checker{
+		lines:   lines,
+		globals: globals,
+	}
+}
+
+// declareGlobals declares the top-level declarations before walking the code, since the globals are late bound
+// (eg: a function can call another one declared after it).
+func (c *checker) declareGlobals(statements []ast.Statement) {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *ast.VariableStatement:
+			c.globals[s.Name.Lexeme] = &binding{name: s.Name, kind: variableBinding, arity: unknownArity}
+		case *ast.FunctionStatement:
+			c.globals[s.Name.Lexeme] = &binding{name: s.Name, kind: functionBinding, arity: len(s.Paremeters)}
+		case *ast.ClassStatement:
+			c.globals[s.Name.Lexeme] = &binding{name: s.Name, kind: classBinding, arity: classArity(s)}
+		case *ast.ImportStatement:
+			c.globals[s.Name.Lexeme] = &binding{name: s.Name, kind: otherBinding, arity: unknownArity}
+		}
+	}
+}
+
+func (c *checker) report(id string, line int, format string, args ...interface{}) {
+	r, _ := rule(id)
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Rule:     id,
+		Severity: r.Severity,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) beginScope() {
+	c.scopes = append(c.scopes, &scope{bindings: map[string]*binding{}})
+}
+
+// endScope closes the current scope, reporting its unused bindings.
+func (c *checker) endScope() {
+	current := c.scopes[len(c.scopes)-1]
+	c.scopes = c.scopes[:len(c.scopes)-1]
+
+	for _, b := range current.order {
+		if b.used {
+			continue
+		}
+
+		switch b.kind {
+		case variableBinding:
+			c.report(UnusedVariable, b.name.Line, "the variable %q is never used", b.name.Lexeme)
+		case functionBinding:
+			c.report(UnusedVariable, b.name.Line, "the function %q is never used", b.name.Lexeme)
+		case classBinding:
+			c.report(UnusedVariable, b.name.Line, "the class %q is never used", b.name.Lexeme)
+		case parameterBinding:
+			c.report(UnusedParameter, b.name.Line, "the parameter %q is never used", b.name.Lexeme)
+		}
+	}
+}
+
+// declare declares a name in the current scope (the globals are declared beforehand).
+func (c *checker) declare(name *token.Token, kind bindingKind, arity int) {
+	if len(c.scopes) == 0 {
+		return
+	}
+
+	if shadowed := c.lookup(name.Lexeme); shadowed != nil && shadowed.name != nil && shadowed.name.Line <= name.Line {
+		c.report(ShadowedVariable, name.Line, "the declaration of %q shadows the one of line %d", name.Lexeme, shadowed.name.Line)
+	}
+
+	current := c.scopes[len(c.scopes)-1]
+	b := &binding{name: name, kind: kind, arity: arity}
+	current.bindings[name.Lexeme] = b
+	current.order = append(current.order, b)
+}
+
+// lookup returns the binding of a name, looking from the innermost scope to the globals (nil if it isn't declared).
+func (c *checker) lookup(name string) *binding {
+	for i := len(c.scopes) - 1; i >= 0; i-- {
+		if b, exists := c.scopes[i].bindings[name]; exists {
+			return b
+		}
+	}
+	return c.globals[name]
+}
+
+// classArity returns the number of arguments expected when calling a class (the ones of its initializer).
+func classArity(class *ast.ClassStatement) int {
+	for _, method := range class.Methods {
+		if method.Name.Lexeme == "init" {
+			return len(method.Paremeters)
+		}
+	}
+	return 0
+}
+
+// isTerminal returns true if the statement always leaves the block where it is.
+func isTerminal(statement ast.Statement) bool {
+	switch statement.(type) {
+	case *ast.ReturnStatement, *ast.BreakStatement, *ast.ContinueStatement, *ast.ThrowStatement:
+		return true
+	}
+	return false
+}
+
+// isPure returns true if evaluating the expression twice gives the same value (it has no calls nor assignments).
+func isPure(expression ast.Expression) bool {
+	switch e := expression.(type) {
+	case *ast.LiteralExpression, *ast.VariableExpression, *ast.ThisExpression:
+		return true
+	case *ast.GroupingExpression:
+		return isPure(e.Expression)
+	case *ast.UnaryExpression:
+		return isPure(e.Expression)
+	case *ast.BinaryExpression:
+		return isPure(e.Left) && isPure(e.Right)
+	case *ast.GetExpression:
+		return isPure(e.Object)
+	case *ast.IndexExpression:
+		return isPure(e.Object) && isPure(e.Index)
+	}
+	return false
+}
+
+// arguments describes a number of arguments (eg: 1 argument, 2 arguments).
+func arguments(n int) string {
+	if n == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", n)
+}
+
+// render returns a canonical representation of an expression (used to compare them).
+func render(expression ast.Expression) string {
+	return ast.Dump([]ast.Statement{ast.NewExpressionStatement(expression)})
+}
+
+func (c *checker) statements(statements []ast.Statement) {
+	reachable := true
+	for index, statement := range statements {
+		_ = statement.Accept(c)
+
+		if reachable && isTerminal(statement) && index+1 < len(statements) {
+			// only the first unreachable statement is reported (the rest of the block is still checked)
+			c.report(UnreachableCode, c.lines[statements[index+1]].Start, "the code is unreachable")
+			reachable = false
+		}
+	}
+}
+
+func (c *checker) function(parameters []*token.Token, body []ast.Statement) {
+	c.beginScope()
+	for _, parameter := range parameters {
+		c.declare(parameter, parameterBinding, unknownArity)
+	}
+	c.statements(body)
+	c.endScope()
+}
+
+func (c *checker) expression(expression ast.Expression) {
+	if expression != nil {
+		_, _ = expression.Accept(c)
+	}
+}
+
+func (c *checker) statement(statement ast.Statement) {
+	if statement != nil {
+		_ = statement.Accept(c)
+	}
+}
+
+// Statements
+
+func (c *checker) VisitExpressionStatement(statement *ast.ExpressionStatement) error {
+	c.expression(statement.Expression)
+	return nil
+}
+
+func (c *checker) VisitPrintStatement(statement *ast.PrintStatement) error {
+	c.expression(statement.Expression)
+	return nil
+}
+
+func (c *checker) VisitVariableStatement(statement *ast.VariableStatement) error {
+	c.expression(statement.Value)
+	c.declare(statement.Name, variableBinding, unknownArity)
+	return nil
+}
+
+func (c *checker) VisitReturnStatement(statement *ast.ReturnStatement) error {
+	c.expression(statement.Value)
+	return nil
+}
+
+func (c *checker) VisitThrowStatement(statement *ast.ThrowStatement) error {
+	c.expression(statement.Value)
+	return nil
+}
+
+func (c *checker) VisitFunctionStatement(statement *ast.FunctionStatement) error {
+	c.declare(statement.Name, functionBinding, len(statement.Paremeters))
+	c.function(statement.Paremeters, statement.Body)
+	return nil
+}
+
+func (c *checker) VisitClassStatement(statement *ast.ClassStatement) error {
+	c.declare(statement.Name, classBinding, classArity(statement))
+	if statement.Superclass != nil {
+		c.expression(statement.Superclass)
+	}
+
+	for _, method := range statement.Methods {
+		c.function(method.Paremeters, method.Body)
+	}
+	return nil
+}
+
+func (c *checker) VisitImportStatement(statement *ast.ImportStatement) error {
+	c.declare(statement.Name, otherBinding, unknownArity)
+	return nil
+}
+
+func (c *checker) VisitBlockStatement(statement *ast.BlockStatement) error {
+	c.beginScope()
+	c.statements(statement.Statements)
+	c.endScope()
+	return nil
+}
+
+func (c *checker) VisitIfStatement(statement *ast.IfStatement) error {
+	c.expression(statement.Condition)
+	c.statement(statement.ThenBlock)
+	c.statement(statement.ElseBlock)
+	return nil
+}
+
+func (c *checker) VisitWhileStatement(statement *ast.WhileStatement) error {
+	c.expression(statement.Condition)
+	c.statement(statement.Body)
+	return nil
+}
+
+func (c *checker) VisitForStatement(statement *ast.ForStatement) error {
+	c.beginScope()
+	c.statement(statement.Initializer)
+	c.expression(statement.Condition)
+	c.expression(statement.Post)
+	c.statement(statement.Body)
+	c.endScope()
+	return nil
+}
+
+func (c *checker) VisitForInStatement(statement *ast.ForInStatement) error {
+	c.expression(statement.Iterable)
+
+	c.beginScope()
+	for _, variable := range statement.Variables {
+		c.declare(variable, otherBinding, unknownArity)
+	}
+	c.statement(statement.Body)
+	c.endScope()
+	return nil
+}
+
+func (c *checker) VisitBreakStatement(statement *ast.BreakStatement) error {
+	return nil
+}
+
+func (c *checker) VisitContinueStatement(statement *ast.ContinueStatement) error {
+	return nil
+}
+
+func (c *checker) VisitTryStatement(statement *ast.TryStatement) error {
+	c.statement(statement.Body)
+
+	if statement.CatchBody != nil {
+		c.beginScope()
+		c.declare(statement.CatchName, otherBinding, unknownArity)
+		c.statement(statement.CatchBody)
+		c.endScope()
+	}
+
+	c.statement(statement.FinallyBody)
+	return nil
+}
+
+// Expressions
+
+func (c *checker) VisitLiteralExpression(expression *ast.LiteralExpression) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *checker) VisitGroupingExpression(expression *ast.GroupingExpression) (interface{}, error) {
+	c.expression(expression.Expression)
+	return nil, nil
+}
+
+func (c *checker) VisitUnaryExpression(expression *ast.UnaryExpression) (interface{}, error) {
+	c.expression(expression.Expression)
+	return nil, nil
+}
+
+func (c *checker) VisitBinaryExpression(expression *ast.BinaryExpression) (interface{}, error) {
+	c.expression(expression.Left)
+	c.expression(expression.Right)
+
+	sameResult, isComparison := comparisons[expression.Operator.Type]
+	if !isComparison {
+		return nil, nil
+	}
+
+	left, isLiteral := expression.Left.(*ast.LiteralExpression)
+	right, isOtherLiteral := expression.Right.(*ast.LiteralExpression)
+	if isLiteral && isOtherLiteral {
+		e, err := evaluator.NewBinaryEvaluator(left.Value, expression.Operator, right.Value)
+		if err != nil {
+			return nil, nil
+		}
+
+		result, err := e.Evaluate()
+		if err == nil {
+			c.report(ConstantComparison, expression.Operator.Line, "the comparison is always %v", result)
+		}
+		return nil, nil
+	}
+
+	if isPure(expression.Left) && isPure(expression.Right) && render(expression.Left) == render(expression.Right) {
+		c.report(ConstantComparison, expression.Operator.Line, "the comparison is always %t", sameResult)
+	}
+	return nil, nil
+}
+
+func (c *checker) VisitLogicalExpression(expression *ast.LogicalExpression) (interface{}, error) {
+	c.expression(expression.Left)
+	c.expression(expression.Right)
+	return nil, nil
+}
+
+func (c *checker) VisitVariableExpression(expression *ast.VariableExpression) (interface{}, error) {
+	if b := c.lookup(expression.Name.Lexeme); b != nil {
+		b.used = true
+	}
+	return nil, nil
+}
+
+func (c *checker) VisitAssignmentExpression(expression *ast.AssignmentExpression) (interface{}, error) {
+	c.expression(expression.Value)
+
+	if c.lookup(expression.Name.Lexeme) == nil {
+		c.report(UndeclaredAssignment, expression.Name.Line, "the variable %q is not declared", expression.Name.Lexeme)
+	}
+	return nil, nil
+}
+
+func (c *checker) VisitCallExpression(expression *ast.CallExpression) (interface{}, error) {
+	c.expression(expression.Callee)
+	for _, argument := range expression.Arguments {
+		c.expression(argument)
+	}
+
+	callee := expression.Callee
+	for {
+		group, isGroup := callee.(*ast.GroupingExpression)
+		if !isGroup {
+			break
+		}
+		callee = group.Expression
+	}
+
+	switch callee := callee.(type) {
+	case *ast.LiteralExpression, *ast.ListExpression, *ast.MapExpression:
+		c.report(LiteralCall, expression.Line, "a literal cannot be called")
+	case *ast.VariableExpression:
+		b := c.lookup(callee.Name.Lexeme)
+		if b != nil && b.arity != unknownArity && b.arity != len(expression.Arguments) {
+			c.report(WrongArgumentCount, expression.Line, "%q expects %s, but it is called with %d",
+				callee.Name.Lexeme, arguments(b.arity), len(expression.Arguments))
+		}
+	}
+	return nil, nil
+}
+
+func (c *checker) VisitFunctionExpression(expression *ast.FunctionExpression) (interface{}, error) {
+	c.function(expression.Parameters, expression.Body)
+	return nil, nil
+}
+
+func (c *checker) VisitListExpression(expression *ast.ListExpression) (interface{}, error) {
+	for _, element := range expression.Elements {
+		c.expression(element)
+	}
+	return nil, nil
+}
+
+func (c *checker) VisitMapExpression(expression *ast.MapExpression) (interface{}, error) {
+	for index := range expression.Keys {
+		c.expression(expression.Keys[index])
+		c.expression(expression.Values[index])
+	}
+	return nil, nil
+}
+
+func (c *checker) VisitIndexExpression(expression *ast.IndexExpression) (interface{}, error) {
+	c.expression(expression.Object)
+	c.expression(expression.Index)
+	return nil, nil
+}
+
+func (c *checker) VisitIndexAssignmentExpression(expression *ast.IndexAssignmentExpression) (interface{}, error) {
+	c.expression(expression.Object)
+	c.expression(expression.Index)
+	c.expression(expression.Value)
+	return nil, nil
+}
+
+func (c *checker) VisitGetExpression(expression *ast.GetExpression) (interface{}, error) {
+	c.expression(expression.Object)
+	return nil, nil
+}
+
+func (c *checker) VisitSetExpression(expression *ast.SetExpression) (interface{}, error) {
+	c.expression(expression.Object)
+	c.expression(expression.Value)
+	return nil, nil
+}
+
+func (c *checker) VisitThisExpression(expression *ast.ThisExpression) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *checker) VisitSuperExpression(expression *ast.SuperExpression) (interface{}, error) {
+	return nil, nil
+}
@@ -0,0 +1,115 @@
+// Package linter finds the code that is probably wrong, without running it (eg: unused variables, or calls with a
+// wrong number of arguments).
+//
+// Each problem is reported by a rule, with its severity and line. The rules can be disabled, and their problems
+// can be ignored in a line with a comment (eg: # lint:ignore unused-variable,shadowed-variable), placed at the end
+// of the line or in the line before it. A comment without rules (# lint:ignore) ignores all of them.
+package linter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+)
+
+// ignoreDirective is the prefix of the comments that ignore the problems of a line.
+const ignoreDirective = "lint:ignore"
+
+// allRules is the rule ignored in the lines where all of them are ignored (by a directive without rules).
+const allRules = ""
+
+// Linter runs the enabled rules on the source code.
+type Linter struct {
+	disabled map[string]bool
+}
+
+// NewLinter is a constructor for a Linter, with all the rules enabled except the given ones.
+func NewLinter(disabled ...string) (*Linter, error) {
+	l := &Linter{disabled: map[string]bool{}}
+	for _, id := range disabled {
+		if _, exists := rule(id); !exists {
+			return nil, fmt.Errorf("unknown rule %q", id)
+		}
+		l.disabled[id] = true
+	}
+	return l, nil
+}
+
+// Lint returns the problems found in the source code, sorted by line.
+// The code must be valid (it's scanned, parsed and resolved), otherwise the error is returned.
+func (l *Linter) Lint(source string) ([]Diagnostic, error) {
+	s := scanner.NewScanner(bytes.Runes([]byte(source)))
+	tokens, err := s.Scan()
+	if err != nil {
+		return nil, fmt.Errorf("failed on the lexer layer: %w", err)
+	}
+
+	p := parser.NewParser(tokens)
+	statements, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	err = interpreter.NewResolver(nil).Resolve(statements)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving the statements: %w", err)
+	}
+
+	c := newChecker(p.Lines())
+	c.declareGlobals(statements)
+	c.statements(statements)
+
+	ignored := ignoredRules(s.Comments(), tokens)
+	diagnostics := []Diagnostic{}
+	for _, diagnostic := range c.diagnostics {
+		if l.disabled[diagnostic.Rule] || ignored[diagnostic.Line][diagnostic.Rule] || ignored[diagnostic.Line][allRules] {
+			continue
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+	return diagnostics, nil
+}
+
+// ignoredRules returns the rules ignored in each line, by the lint:ignore comments.
+// A comment at the end of a line applies to that line, and a comment in its own line applies to the next code.
+func ignoredRules(comments []*token.Token, tokens []*token.Token) map[int]map[string]bool {
+	ignored := map[int]map[string]bool{}
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Lexeme, "#"))
+		if text != ignoreDirective && !strings.HasPrefix(text, ignoreDirective+" ") {
+			continue
+		}
+
+		// the rules can be followed by the reason (eg: # lint:ignore unused-parameter it's a callback)
+		ids := allRules
+		if fields := strings.Fields(strings.TrimPrefix(text, ignoreDirective)); len(fields) > 0 {
+			ids = fields[0]
+		}
+
+		line := comment.Line
+		for _, t := range tokens {
+			if t.Line >= comment.Line {
+				line = t.Line
+				break
+			}
+		}
+
+		if ignored[line] == nil {
+			ignored[line] = map[string]bool{}
+		}
+		for _, id := range strings.Split(ids, ",") {
+			ignored[line][id] = true
+		}
+	}
+	return ignored
+}
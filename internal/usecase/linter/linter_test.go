@@ -0,0 +1,130 @@
+package linter_test
+
+import (
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/linter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	tests := map[string]struct {
+		src         string
+		disabled    []string
+		expected    []string
+		expectedErr bool
+	}{
+		"no problems": {
+			src:      "fn f(a) { dec b = a; return b; }\nprint f(1);",
+			expected: []string{},
+		},
+		"unused variables": {
+			src: "fn f() {\n  dec a = 1;\n  fn g() {}\n  class C {}\n}\nf();",
+			expected: []string{
+				`line 2: warning: the variable "a" is never used (unused-variable)`,
+				`line 3: warning: the function "g" is never used (unused-variable)`,
+				`line 4: warning: the class "C" is never used (unused-variable)`,
+			},
+		},
+		"unused globals, loop variables and catch names are not reported": {
+			src:      "dec a = 1;\nfor k, v in {} {}\ntry { throw 1; } catch (e) {}",
+			expected: []string{},
+		},
+		"unused parameters": {
+			src:      "fn f(a, b) { return a; }\nprint f(1, 2);",
+			expected: []string{`line 1: warning: the parameter "b" is never used (unused-parameter)`},
+		},
+		"shadowed variables": {
+			src:      "dec a = 1;\nfn f() {\n  dec a = 2;\n  return a;\n}\nprint f();",
+			expected: []string{`line 3: warning: the declaration of "a" shadows the one of line 1 (shadowed-variable)`},
+		},
+		"undeclared assignment": {
+			src:      "a = 1;",
+			expected: []string{`line 1: error: the variable "a" is not declared (undeclared-assignment)`},
+		},
+		"unreachable code": {
+			src: "fn f() {\n  return 1;\n  print 1;\n  print 2;\n}\nwhile true {\n  break;\n  print 3;\n}\nprint f();",
+			expected: []string{
+				"line 3: warning: the code is unreachable (unreachable-code)",
+				"line 8: warning: the code is unreachable (unreachable-code)",
+			},
+		},
+		"constant comparisons": {
+			src: "dec a = 1;\nprint a == a;\nprint 1 < 2;\nprint a <> a;\nprint a < 2;",
+			expected: []string{
+				"line 2: warning: the comparison is always true (constant-comparison)",
+				"line 3: warning: the comparison is always true (constant-comparison)",
+				"line 4: warning: the comparison is always false (constant-comparison)",
+			},
+		},
+		"literal calls": {
+			src: "1();\n(\"a\")(1);\n[1]();",
+			expected: []string{
+				"line 1: error: a literal cannot be called (literal-call)",
+				"line 2: error: a literal cannot be called (literal-call)",
+				"line 3: error: a literal cannot be called (literal-call)",
+			},
+		},
+		"wrong argument counts": {
+			src: "fn f(a, b) { return a + b; }\nclass A { init(a) { this.a = a; } }\nclass B {}\nf(1);\nlen(1, 2);\nA();\nB(1);\nf(1, 2);",
+			expected: []string{
+				`line 4: error: "f" expects 2 arguments, but it is called with 1 (wrong-argument-count)`,
+				`line 5: error: "len" expects 1 argument, but it is called with 2 (wrong-argument-count)`,
+				`line 6: error: "A" expects 1 argument, but it is called with 0 (wrong-argument-count)`,
+				`line 7: error: "B" expects 0 arguments, but it is called with 1 (wrong-argument-count)`,
+			},
+		},
+		"ignored at the end of the line": {
+			src:      "a = 1; # lint:ignore undeclared-assignment\nb = 2; # lint:ignore unused-variable",
+			expected: []string{`line 2: error: the variable "b" is not declared (undeclared-assignment)`},
+		},
+		"ignored in the line before": {
+			src:      "# lint:ignore unused-parameter it's a callback\nfn f(a) {\n  # lint:ignore unused-variable,shadowed-variable\n  dec f = 1;\n}\nprint f(1);",
+			expected: []string{},
+		},
+		"all the rules ignored": {
+			src:      "# lint:ignore\nfn f(a) { dec b = 1; }\nf(1); c = 1; # lint:ignore",
+			expected: []string{},
+		},
+		"disabled rules": {
+			src:      "fn f(a) { dec b = 1; }\nf(1);",
+			disabled: []string{linter.UnusedVariable, linter.UnusedParameter},
+			expected: []string{},
+		},
+		"unknown rule": {
+			src:         "print 1;",
+			disabled:    []string{"unknown"},
+			expectedErr: true,
+		},
+		"invalid code": {
+			src:         "print (1;",
+			expectedErr: true,
+		},
+		"unresolvable code": {
+			src:         "return 1;",
+			expectedErr: true,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			l, err := linter.NewLinter(test.disabled...)
+			var diagnostics []linter.Diagnostic
+			if err == nil {
+				diagnostics, err = l.Lint(test.src)
+			}
+
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			got := []string{}
+			for _, d := range diagnostics {
+				got = append(got, d.String())
+			}
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package linter
+
+import "fmt"
+
+// Severity is the severity of the problems found by a rule.
+type Severity int
+
+const (
+	// Warning is the severity of the code that runs, but is probably wrong (eg: an unused variable).
+	Warning Severity = iota
+	// Error is the severity of the code that fails when it runs (eg: calling a literal).
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Rule is a check run by the linter.
+type Rule struct {
+	ID          string // used to disable the rule, or to ignore its problems (eg: # lint:ignore unused-variable)
+	Severity    Severity
+	Description string
+}
+
+// IDs of the rules.
+const (
+	UnusedVariable       = "unused-variable"
+	UnusedParameter      = "unused-parameter"
+	ShadowedVariable     = "shadowed-variable"
+	UndeclaredAssignment = "undeclared-assignment"
+	UnreachableCode      = "unreachable-code"
+	ConstantComparison   = "constant-comparison"
+	LiteralCall          = "literal-call"
+	WrongArgumentCount   = "wrong-argument-count"
+)
+
+// Rules are the rules run by the linter (all of them are enabled by default).
+var Rules = []Rule{
+	{ID: UnusedVariable, Severity: Warning, Description: "a local variable, function or class that is never read"},
+	{ID: UnusedParameter, Severity: Warning, Description: "a parameter of a function that is never read"},
+	{ID: ShadowedVariable, Severity: Warning, Description: "a local declaration that hides another one of an enclosing scope"},
+	{ID: UndeclaredAssignment, Severity: Error, Description: "an assignment to a variable that is not declared"},
+	{ID: UnreachableCode, Severity: Warning, Description: "a statement after a return, break, continue or throw"},
+	{ID: ConstantComparison, Severity: Warning, Description: "a comparison that is always true or always false (eg: a == a)"},
+	{ID: LiteralCall, Severity: Error, Description: "a call to a literal (eg: 1())"},
+	{ID: WrongArgumentCount, Severity: Error, Description: "a call to a known function with a wrong number of arguments"},
+}
+
+// Diagnostic is a problem found in the code.
+type Diagnostic struct {
+	Rule     string
+	Severity Severity
+	Line     int
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("line %d: %s: %s (%s)", d.Line, d.Severity, d.Message, d.Rule)
+}
+
+// rule returns the rule with the given ID.
+func rule(id string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,486 @@
+// Package optimizer rewrites the AST (between the parser and the resolver), so the programs do less work at runtime.
+//
+// It folds the operations between literals (eg: (1 + 2) * 3 becomes 9), using the same evaluators of the
+// interpreter, and removes the code that can't be reached (the branches of the conditions that are always false
+// or true, and the statements after a return, break, continue or throw).
+// The operations that fail (eg: 1 / 0) are not folded, so they still fail at runtime (in the same line), and
+// neither are the concatenations that exceed the max string length (see SetMaxStringLength).
+package optimizer
+
+import (
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	"github.com/avazquezcode/govetryx/internal/domain/evaluator"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+// Optimizer optimizes the statements of a program.
+type Optimizer struct {
+	replacement     ast.Statement // statement that replaces the one being visited (nil when it is removed)
+	maxStringLength int           // max length of the folded strings (0 means there is no limit)
+}
+
+// NewOptimizer is a constructor for an optimizer.
+func NewOptimizer() *Optimizer {
+	return &Optimizer{}
+}
+
+// SetMaxStringLength sets the max length of the strings folded by the concatenations (the limit of the runtime),
+// so the concatenations that exceed it still fail at runtime.
+func (o *Optimizer) SetMaxStringLength(length int) {
+	o.maxStringLength = length
+}
+
+// Optimize returns the optimized statements.
+// The statements are rewritten in place, so the original ones should not be used after optimizing them.
+func (o *Optimizer) Optimize(statements []ast.Statement) ([]ast.Statement, error) {
+	return o.statements(statements)
+}
+
+// statements optimizes a list of statements, dropping the removed ones and the ones that can't be reached.
+func (o *Optimizer) statements(statements []ast.Statement) ([]ast.Statement, error) {
+	optimized := make([]ast.Statement, 0, len(statements))
+	for _, statement := range statements {
+		statement, err := o.statement(statement)
+		if err != nil {
+			return nil, err
+		}
+
+		if statement == nil {
+			continue
+		}
+
+		optimized = append(optimized, statement)
+		if isTerminal(statement) {
+			// the statements after it can't be reached
+			break
+		}
+	}
+	return optimized, nil
+}
+
+// statement optimizes a statement, returning the statement that replaces it (nil when it is removed).
+func (o *Optimizer) statement(statement ast.Statement) (ast.Statement, error) {
+	previous := o.replacement
+	o.replacement = statement
+	defer func() { o.replacement = previous }()
+
+	err := statement.Accept(o)
+	return o.replacement, err
+}
+
+// branch optimizes a statement that is the branch or the body of another statement (a removed branch is
+// replaced by an empty block).
+func (o *Optimizer) branch(statement ast.Statement) (ast.Statement, error) {
+	if statement == nil {
+		return nil, nil
+	}
+
+	optimized, err := o.statement(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	if optimized == nil {
+		block := ast.NewBlockStatement(nil)
+		block.SetPosition(statement.Position())
+		return block, nil
+	}
+	return optimized, nil
+}
+
+// expression optimizes an expression, returning the expression that replaces it.
+func (o *Optimizer) expression(expression ast.Expression) (ast.Expression, error) {
+	if expression == nil {
+		return nil, nil
+	}
+
+	optimized, err := expression.Accept(o)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := optimized.(ast.Expression)
+	if !replacement.Position().IsKnown() {
+		// eg: a folded literal takes the position of the operation it replaces
+		replacement.SetPosition(expression.Position())
+	}
+	return replacement, nil
+}
+
+// expressions optimizes a list of expressions in place.
+func (o *Optimizer) expressions(expressions []ast.Expression) error {
+	for index := range expressions {
+		optimized, err := o.expression(expressions[index])
+		if err != nil {
+			return err
+		}
+		expressions[index] = optimized
+	}
+	return nil
+}
+
+// isTerminal returns true if the statement always leaves the block where it is.
+func isTerminal(statement ast.Statement) bool {
+	switch statement.(type) {
+	case *ast.ReturnStatement, *ast.BreakStatement, *ast.ContinueStatement, *ast.ThrowStatement:
+		return true
+	}
+	return false
+}
+
+// literal returns the value of an expression, when it is a literal.
+func literal(expression ast.Expression) (interface{}, bool) {
+	l, isLiteral := expression.(*ast.LiteralExpression)
+	if !isLiteral {
+		return nil, false
+	}
+	return l.Value, true
+}
+
+// fold evaluates an operation between literals, returning the literal with its result (or nil, when the
+// operation fails, so the error happens at runtime).
+func fold(e evaluator.Evaluator, err error) ast.Expression {
+	if err != nil {
+		return nil
+	}
+
+	value, err := e.Evaluate()
+	if err != nil {
+		return nil
+	}
+	return ast.NewLiteralExpression(value)
+}
+
+// Statements
+
+func (o *Optimizer) VisitExpressionStatement(statement *ast.ExpressionStatement) (err error) {
+	statement.Expression, err = o.expression(statement.Expression)
+	return err
+}
+
+func (o *Optimizer) VisitPrintStatement(statement *ast.PrintStatement) (err error) {
+	statement.Expression, err = o.expression(statement.Expression)
+	return err
+}
+
+func (o *Optimizer) VisitVariableStatement(statement *ast.VariableStatement) (err error) {
+	statement.Value, err = o.expression(statement.Value)
+	return err
+}
+
+func (o *Optimizer) VisitReturnStatement(statement *ast.ReturnStatement) (err error) {
+	statement.Value, err = o.expression(statement.Value)
+	return err
+}
+
+func (o *Optimizer) VisitThrowStatement(statement *ast.ThrowStatement) (err error) {
+	statement.Value, err = o.expression(statement.Value)
+	return err
+}
+
+func (o *Optimizer) VisitFunctionStatement(statement *ast.FunctionStatement) (err error) {
+	statement.Body, err = o.statements(statement.Body)
+	return err
+}
+
+func (o *Optimizer) VisitClassStatement(statement *ast.ClassStatement) error {
+	for _, method := range statement.Methods {
+		err := o.VisitFunctionStatement(method)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Optimizer) VisitImportStatement(statement *ast.ImportStatement) error {
+	return nil
+}
+
+func (o *Optimizer) VisitBlockStatement(statement *ast.BlockStatement) (err error) {
+	statement.Statements, err = o.statements(statement.Statements)
+	return err
+}
+
+func (o *Optimizer) VisitIfStatement(statement *ast.IfStatement) error {
+	condition, err := o.expression(statement.Condition)
+	if err != nil {
+		return err
+	}
+	statement.Condition = condition
+
+	if value, isLiteral := literal(condition); isLiteral {
+		// only the branch that is always taken is kept
+		taken := statement.ElseBlock
+		if corerule.IsTrue(value) {
+			taken = statement.ThenBlock
+		}
+
+		if taken == nil {
+			o.replacement = nil
+			return nil
+		}
+
+		replacement, err := o.statement(taken)
+		if err != nil {
+			return err
+		}
+		o.replacement = replacement
+		return nil
+	}
+
+	statement.ThenBlock, err = o.branch(statement.ThenBlock)
+	if err != nil {
+		return err
+	}
+
+	statement.ElseBlock, err = o.branch(statement.ElseBlock)
+	return err
+}
+
+func (o *Optimizer) VisitWhileStatement(statement *ast.WhileStatement) error {
+	condition, err := o.expression(statement.Condition)
+	if err != nil {
+		return err
+	}
+	statement.Condition = condition
+
+	if value, isLiteral := literal(condition); isLiteral && !corerule.IsTrue(value) {
+		// the body never runs
+		o.replacement = nil
+		return nil
+	}
+
+	statement.Body, err = o.branch(statement.Body)
+	return err
+}
+
+func (o *Optimizer) VisitForStatement(statement *ast.ForStatement) (err error) {
+	if statement.Initializer != nil {
+		statement.Initializer, err = o.branch(statement.Initializer)
+		if err != nil {
+			return err
+		}
+	}
+
+	statement.Condition, err = o.expression(statement.Condition)
+	if err != nil {
+		return err
+	}
+
+	statement.Post, err = o.expression(statement.Post)
+	if err != nil {
+		return err
+	}
+
+	statement.Body, err = o.branch(statement.Body)
+	return err
+}
+
+func (o *Optimizer) VisitForInStatement(statement *ast.ForInStatement) (err error) {
+	statement.Iterable, err = o.expression(statement.Iterable)
+	if err != nil {
+		return err
+	}
+
+	statement.Body, err = o.branch(statement.Body)
+	return err
+}
+
+func (o *Optimizer) VisitBreakStatement(statement *ast.BreakStatement) error {
+	return nil
+}
+
+func (o *Optimizer) VisitContinueStatement(statement *ast.ContinueStatement) error {
+	return nil
+}
+
+func (o *Optimizer) VisitTryStatement(statement *ast.TryStatement) (err error) {
+	statement.Body, err = o.branch(statement.Body)
+	if err != nil {
+		return err
+	}
+
+	statement.CatchBody, err = o.branch(statement.CatchBody)
+	if err != nil {
+		return err
+	}
+
+	statement.FinallyBody, err = o.branch(statement.FinallyBody)
+	return err
+}
+
+// Expressions
+
+func (o *Optimizer) VisitLiteralExpression(expression *ast.LiteralExpression) (interface{}, error) {
+	return expression, nil
+}
+
+func (o *Optimizer) VisitGroupingExpression(expression *ast.GroupingExpression) (interface{}, error) {
+	inner, err := o.expression(expression.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, isLiteral := literal(inner); isLiteral {
+		return inner, nil
+	}
+
+	expression.Expression = inner
+	return expression, nil
+}
+
+func (o *Optimizer) VisitUnaryExpression(expression *ast.UnaryExpression) (interface{}, error) {
+	operand, err := o.expression(expression.Expression)
+	if err != nil {
+		return nil, err
+	}
+	expression.Expression = operand
+
+	if value, isLiteral := literal(operand); isLiteral {
+		if folded := fold(evaluator.NewUnaryEvaluator(expression.Operator, value)); folded != nil {
+			return folded, nil
+		}
+	}
+
+	return expression, nil
+}
+
+func (o *Optimizer) VisitBinaryExpression(expression *ast.BinaryExpression) (interface{}, error) {
+	left, err := o.expression(expression.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := o.expression(expression.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	expression.Left, expression.Right = left, right
+
+	leftValue, isLiteral := literal(left)
+	rightValue, isOtherLiteral := literal(right)
+	if isLiteral && isOtherLiteral && !o.exceedsMaxStringLength(leftValue, rightValue) {
+		if folded := fold(evaluator.NewBinaryEvaluator(leftValue, expression.Operator, rightValue)); folded != nil {
+			return folded, nil
+		}
+	}
+
+	return expression, nil
+}
+
+// exceedsMaxStringLength returns whether the concatenation of two values is a string longer than the max length.
+func (o *Optimizer) exceedsMaxStringLength(left interface{}, right interface{}) bool {
+	l, isString := left.(string)
+	r, isOtherString := right.(string)
+	return o.maxStringLength > 0 && isString && isOtherString && len(l)+len(r) > o.maxStringLength
+}
+
+func (o *Optimizer) VisitLogicalExpression(expression *ast.LogicalExpression) (interface{}, error) {
+	left, err := o.expression(expression.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := o.expression(expression.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	expression.Left, expression.Right = left, right
+
+	// the value of a logical expression is the one of the last operand evaluated
+	if value, isLiteral := literal(left); isLiteral {
+		shortCircuits := corerule.IsTrue(value) == (expression.Operator.Type == token.Or)
+		if shortCircuits {
+			return left, nil
+		}
+		return right, nil
+	}
+
+	return expression, nil
+}
+
+func (o *Optimizer) VisitVariableExpression(expression *ast.VariableExpression) (interface{}, error) {
+	return expression, nil
+}
+
+func (o *Optimizer) VisitAssignmentExpression(expression *ast.AssignmentExpression) (_ interface{}, err error) {
+	expression.Value, err = o.expression(expression.Value)
+	return expression, err
+}
+
+func (o *Optimizer) VisitCallExpression(expression *ast.CallExpression) (_ interface{}, err error) {
+	expression.Callee, err = o.expression(expression.Callee)
+	if err != nil {
+		return nil, err
+	}
+	return expression, o.expressions(expression.Arguments)
+}
+
+func (o *Optimizer) VisitFunctionExpression(expression *ast.FunctionExpression) (_ interface{}, err error) {
+	expression.Body, err = o.statements(expression.Body)
+	return expression, err
+}
+
+func (o *Optimizer) VisitListExpression(expression *ast.ListExpression) (interface{}, error) {
+	return expression, o.expressions(expression.Elements)
+}
+
+func (o *Optimizer) VisitMapExpression(expression *ast.MapExpression) (interface{}, error) {
+	err := o.expressions(expression.Keys)
+	if err != nil {
+		return nil, err
+	}
+	return expression, o.expressions(expression.Values)
+}
+
+func (o *Optimizer) VisitIndexExpression(expression *ast.IndexExpression) (_ interface{}, err error) {
+	expression.Object, err = o.expression(expression.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	expression.Index, err = o.expression(expression.Index)
+	return expression, err
+}
+
+func (o *Optimizer) VisitIndexAssignmentExpression(expression *ast.IndexAssignmentExpression) (_ interface{}, err error) {
+	expression.Object, err = o.expression(expression.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	expression.Index, err = o.expression(expression.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	expression.Value, err = o.expression(expression.Value)
+	return expression, err
+}
+
+func (o *Optimizer) VisitGetExpression(expression *ast.GetExpression) (_ interface{}, err error) {
+	expression.Object, err = o.expression(expression.Object)
+	return expression, err
+}
+
+func (o *Optimizer) VisitSetExpression(expression *ast.SetExpression) (_ interface{}, err error) {
+	expression.Object, err = o.expression(expression.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	expression.Value, err = o.expression(expression.Value)
+	return expression, err
+}
+
+func (o *Optimizer) VisitThisExpression(expression *ast.ThisExpression) (interface{}, error) {
+	return expression, nil
+}
+
+func (o *Optimizer) VisitSuperExpression(expression *ast.SuperExpression) (interface{}, error) {
+	return expression, nil
+}
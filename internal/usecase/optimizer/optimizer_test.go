@@ -0,0 +1,148 @@
+package optimizer_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/usecase/optimizer"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := map[string]struct {
+		src          string
+		expectedDump string
+	}{
+		"arithmetic": {
+			src: "print (1 + 2) * 3 - -1;",
+			expectedDump: `
+(print 10)
+`,
+		},
+		"string concatenation": {
+			src: `dec a = "a" + "b";`,
+			expectedDump: `
+(dec a "ab")
+`,
+		},
+		"comparison": {
+			src: "print 1 < 2; print 1 <> 1; print !true;",
+			expectedDump: `
+(print true)
+(print false)
+(print false)
+`,
+		},
+		"only the literal operands are folded": {
+			src: "print a + 2 * 3;",
+			expectedDump: `
+(print (+ a 6))
+`,
+		},
+		"operations that fail at runtime are not folded": {
+			src: `print 1 / 0; print "a" + 1;`,
+			expectedDump: `
+(print (/ 1 0))
+(print (+ "a" 1))
+`,
+		},
+		"logical with a literal on the left": {
+			src: "print true && a; print false && a; print null || a; print 1 || a; print a && true;",
+			expectedDump: `
+(print a)
+(print false)
+(print a)
+(print 1)
+(print (&& a true))
+`,
+		},
+		"if with a true condition": {
+			src: "if (1 < 2) { print 1; } else { print 2; }",
+			expectedDump: `
+(block
+  (print 1))
+`,
+		},
+		"if with a false condition": {
+			src: "if (1 > 2) { print 1; } else if a { print 2; }",
+			expectedDump: `
+(if a
+  (block
+    (print 2)))
+`,
+		},
+		"if with a false condition and no else": {
+			src: "if false { print 1; } print 2;",
+			expectedDump: `
+(print 2)
+`,
+		},
+		"while with a false condition": {
+			src: "while (false) { print 1; } while a { if false { print 1; } }",
+			expectedDump: `
+(while a
+  (block))
+`,
+		},
+		"statements after a return": {
+			src: "fn f() { print 1; return 2; print 3; }",
+			expectedDump: `
+(fn f ()
+  (print 1)
+  (return 2))
+`,
+		},
+		"statements after a break or a continue": {
+			src: "while a { break; print 1; } for i := 0; i < 2; i = i + 1 { continue; print i; }",
+			expectedDump: `
+(while a
+  (block
+    (break)))
+(for (dec i 0) (< i 2) (= i (+ i 1))
+  (block
+    (continue)))
+`,
+		},
+		"nested functions and methods": {
+			src: "class A { m() { return (a) => 1 + 1; } }",
+			expectedDump: `
+(class A
+  (fn m ()
+    (return (fn (a)
+      (return 2)))))
+`,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			tokens, err := scanner.NewScanner(bytes.Runes([]byte(test.src))).Scan()
+			assert.NoError(t, err)
+
+			statements, err := parser.NewParser(tokens).Parse()
+			assert.NoError(t, err)
+
+			optimized, err := optimizer.NewOptimizer().Optimize(statements)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimPrefix(test.expectedDump, "\n"), ast.Dump(optimized))
+		})
+	}
+}
+
+func TestOptimizeKeepsTheConcatenationsThatExceedTheMaxStringLength(t *testing.T) {
+	tokens, err := scanner.NewScanner([]rune(`print "ab" + "c"; print "abc" + "d";`)).Scan()
+	assert.NoError(t, err)
+
+	statements, err := parser.NewParser(tokens).Parse()
+	assert.NoError(t, err)
+
+	o := optimizer.NewOptimizer()
+	o.SetMaxStringLength(3)
+	optimized, err := o.Optimize(statements)
+	assert.NoError(t, err)
+	assert.Equal(t, "(print \"abc\")\n(print (+ \"abc\" \"d\"))\n", ast.Dump(optimized))
+}
@@ -1,10 +1,17 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
 
 // ParsingErr represents an error during parsing.
 type ParsingErr struct {
-	errs []error
+	errs     []error
+	spans    []token.Span // the code where each error was found (set by the parser)
+	excerpts []string     // the excerpt of the source code where each error was found (see WithExcerpts)
 }
 
 // NewParsingErr is a constructor for a parsing error.
@@ -14,11 +21,33 @@ func NewParsingErr(errs []error) *ParsingErr {
 	}
 }
 
+// WithExcerpts sets the excerpts of the source code where the errors were found (so they are shown under each error).
+func (p *ParsingErr) WithExcerpts(source string) *ParsingErr {
+	p.excerpts = make([]string, len(p.spans))
+	for i, span := range p.spans {
+		p.excerpts[i] = interr.Excerpt(source, span)
+	}
+	return p
+}
+
+// Errors returns each one of the errors found.
+func (p *ParsingErr) Errors() []error {
+	return p.errs
+}
+
+// Spans returns the span of the code where each error was found.
+func (p *ParsingErr) Spans() []token.Span {
+	return p.spans
+}
+
 func (p *ParsingErr) Error() string {
 	var message string
 
 	for i, err := range p.errs {
 		message = message + fmt.Sprintf("error #%d: %s \n", i+1, err.Error())
+		if i < len(p.excerpts) && p.excerpts[i] != "" {
+			message = message + p.excerpts[i] + "\n"
+		}
 	}
 
 	return message
@@ -14,17 +14,27 @@ import (
 type Parser struct {
 	tokens  []*token.Token
 	current int
+	lines   map[ast.Statement]ast.LineRange // lines of the parsed statements (eg: used to keep the comments when formatting)
 }
 
 // NewParser is a constructor for our Parser.
 func NewParser(tokens []*token.Token) *Parser {
-	return &Parser{tokens: tokens}
+	return &Parser{
+		tokens: tokens,
+		lines:  map[ast.Statement]ast.LineRange{},
+	}
+}
+
+// Lines returns the range of lines of each statement parsed (including the nested ones, and the class methods).
+func (p *Parser) Lines() map[ast.Statement]ast.LineRange {
+	return p.lines
 }
 
 // Parse is the main method of the parser.
 // It parses the list of tokens into our AST, returning a list of statements.
 func (p *Parser) Parse() ([]ast.Statement, error) {
 	var errors []error
+	var spans []token.Span
 	var statements []ast.Statement
 
 	for !p.isEnd() {
@@ -33,6 +43,7 @@ func (p *Parser) Parse() ([]ast.Statement, error) {
 		statement, err := p.declaration()
 		if err != nil {
 			errors = append(errors, err)
+			spans = append(spans, p.peek().Span) // the token where the parser got stuck
 			p.synchronize()
 			continue
 		}
@@ -40,7 +51,9 @@ func (p *Parser) Parse() ([]ast.Statement, error) {
 	}
 
 	if errors != nil {
-		return nil, NewParsingErr(errors)
+		parsingErr := NewParsingErr(errors)
+		parsingErr.spans = spans
+		return nil, parsingErr
 	}
 
 	return statements, nil
@@ -48,17 +61,119 @@ func (p *Parser) Parse() ([]ast.Statement, error) {
 
 // declaration is the top of our grammar (program is a set of declarations).
 func (p *Parser) declaration() (ast.Statement, error) {
+	track := p.track()
 	switch p.peek().Type {
 	case token.Fn:
+		if p.peekNext().Type != token.Identifier {
+			// an anonymous function used as an expression statement
+			break
+		}
 		p.increment()
-		return p.function()
+		return track(p.function())
 	case token.VarDeclarator:
 		p.increment()
-		return p.variable()
+		return track(p.variable())
+	case token.Class:
+		p.increment()
+		return track(p.class())
+	case token.Import:
+		p.increment()
+		return track(p.importStatement())
 	}
 	return p.statement()
 }
 
+// track returns a function that records the lines and the position of the statement that starts at the current token, once parsed.
+func (p *Parser) track() func(ast.Statement, error) (ast.Statement, error) {
+	start := p.peek()
+	return func(statement ast.Statement, err error) (ast.Statement, error) {
+		if err == nil {
+			p.lines[statement] = ast.LineRange{Start: start.Line, End: p.previous().Line}
+			statement.SetPosition(p.spanFrom(start))
+		}
+		return statement, err
+	}
+}
+
+// at sets the position of an expression, from its start token to the last consumed one.
+func (p *Parser) at(start *token.Token, expression ast.Expression) ast.Expression {
+	expression.SetPosition(p.spanFrom(start))
+	return expression
+}
+
+// spanFrom returns the span that goes from the start token to the last consumed one.
+func (p *Parser) spanFrom(start *token.Token) token.Span {
+	return token.Span{Start: start.Span.Start, End: p.previous().Span.End}
+}
+
+// importStatement parses an import (eg: import "lib/strings.vx" as s;).
+func (p *Parser) importStatement() (ast.Statement, error) {
+	importLine := p.previous().Line
+
+	path, err := p.consume(token.String)
+	if err != nil {
+		return nil, fmt.Errorf("expected the path of the imported file: %w", err)
+	}
+
+	_, err = p.consume(token.As)
+	if err != nil {
+		return nil, fmt.Errorf("expected 'as' after the imported path: %w", err)
+	}
+
+	name, err := p.consume(token.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("expected a valid name for the imported module: %w", err)
+	}
+
+	_, err = p.consume(token.Semicolon)
+	if err != nil {
+		return nil, fmt.Errorf("expected a ';' after the import: %w", err)
+	}
+
+	return ast.NewImportStatement(importLine, path, name), nil
+}
+
+// class parses a class declaration.
+func (p *Parser) class() (ast.Statement, error) {
+	className, err := p.consume(token.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("expected a valid class name: %w", err)
+	}
+
+	var superclass *ast.VariableExpression
+	if p.is(token.Lower) {
+		p.increment() // skip the "<"
+
+		superclassName, err := p.consume(token.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("expected a valid superclass name after '<': %w", err)
+		}
+		superclass = ast.NewVariableExpression(superclassName)
+		superclass.SetPosition(superclassName.Span)
+	}
+
+	_, err = p.consume(token.LeftBrace)
+	if err != nil {
+		return nil, fmt.Errorf("expected '{' before the class body: %w", err)
+	}
+
+	var methods []*ast.FunctionStatement
+	for !p.is(token.RightBrace) && !p.isEnd() {
+		method, err := p.track()(p.function())
+		if err != nil {
+			return nil, fmt.Errorf("failed when parsing a method of the class %q: %w", className.Lexeme, err)
+		}
+		methods = append(methods, method.(*ast.FunctionStatement))
+	}
+
+	_, err = p.consume(token.RightBrace)
+	if err != nil {
+		return nil, fmt.Errorf("expected '}' after the class body: %w", err)
+	}
+
+	return ast.NewClassStatement(className, superclass, methods), nil
+}
+
 // function parses a function.
 func (p *Parser) function() (ast.Statement, error) {
 	functionName, err := p.consume(token.Identifier)
@@ -71,6 +186,26 @@ func (p *Parser) function() (ast.Statement, error) {
 		return nil, fmt.Errorf("expected '(' after function name: %w", err)
 	}
 
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.LeftBrace)
+	if err != nil {
+		return nil, fmt.Errorf("expected '{' before the function body: %w", err)
+	}
+
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewFunctionStatement(functionName, parameters, body), nil
+}
+
+// parameters parses the parameters of a function (after the opening parentheses), until the closing parentheses.
+func (p *Parser) parameters() ([]*token.Token, error) {
 	var parameters []*token.Token
 	if !p.is(token.RightParentheses) {
 		parameter, err := p.consume(token.Identifier)
@@ -91,11 +226,28 @@ func (p *Parser) function() (ast.Statement, error) {
 		}
 	}
 
-	_, err = p.consume(token.RightParentheses)
+	_, err := p.consume(token.RightParentheses)
 	if err != nil {
 		return nil, fmt.Errorf("expected ')' after the function parameters list: %w", err)
 	}
 
+	return parameters, nil
+}
+
+// functionExpression parses an anonymous function (eg: fn(a) { return a; }).
+func (p *Parser) functionExpression() (ast.Expression, error) {
+	fnLine := p.previous().Line
+
+	_, err := p.consume(token.LeftParentheses)
+	if err != nil {
+		return nil, fmt.Errorf("expected '(' after fn: %w", err)
+	}
+
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
 	_, err = p.consume(token.LeftBrace)
 	if err != nil {
 		return nil, fmt.Errorf("expected '{' before the function body: %w", err)
@@ -106,7 +258,47 @@ func (p *Parser) function() (ast.Statement, error) {
 		return nil, err
 	}
 
-	return ast.NewFunctionStatement(functionName, parameters, body), nil
+	return ast.NewFunctionExpression(fnLine, parameters, body), nil
+}
+
+// arrowFunction parses an arrow function (eg: (a) => a * 2, or (a) => { return a * 2; }).
+func (p *Parser) arrowFunction() (ast.Expression, error) {
+	arrowLine := p.previous().Line
+
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.Arrow)
+	if err != nil {
+		return nil, fmt.Errorf("expected '=>' after the arrow function parameters: %w", err)
+	}
+
+	if p.is(token.LeftBrace) {
+		p.increment()
+
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		function := ast.NewFunctionExpression(arrowLine, parameters, body)
+		function.Syntax = ast.ArrowBlockSyntax
+		return function, nil
+	}
+
+	// a body that is an expression is the value returned by the function
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	returnStatement := ast.NewReturnStatement(arrowLine, value)
+	returnStatement.SetPosition(value.Position())
+	body := []ast.Statement{returnStatement}
+	function := ast.NewFunctionExpression(arrowLine, parameters, body)
+	function.Syntax = ast.ArrowExpressionSyntax
+	return function, nil
 }
 
 // block parses a block.
@@ -156,10 +348,20 @@ func (p *Parser) variable() (ast.Statement, error) {
 
 // statement parses a statement.
 func (p *Parser) statement() (ast.Statement, error) {
+	return p.track()(p.parseStatement())
+}
+
+// parseStatement parses a statement (without recording its lines).
+func (p *Parser) parseStatement() (ast.Statement, error) {
 	if !p.isEnd() && p.peekNext().Type == token.VarShortDeclarator {
 		return p.varShortDeclaratorStatement()
 	}
 
+	if p.isMapLiteral() {
+		// a "{" starting a statement is a block, unless it starts a map literal (eg: {"a": 1}["a"];)
+		return p.expressionStatement()
+	}
+
 	switch p.peek().Type {
 	case token.If:
 		p.increment()
@@ -167,6 +369,12 @@ func (p *Parser) statement() (ast.Statement, error) {
 	case token.While:
 		p.increment()
 		return p.whileStatement()
+	case token.For:
+		p.increment()
+		if p.isForIn() {
+			return p.forInStatement()
+		}
+		return p.forStatement()
 	case token.Return:
 		p.increment()
 		return p.returnStatement()
@@ -179,6 +387,12 @@ func (p *Parser) statement() (ast.Statement, error) {
 	case token.Continue:
 		p.increment()
 		return p.continueStatement()
+	case token.Throw:
+		p.increment()
+		return p.throwStatement()
+	case token.Try:
+		p.increment()
+		return p.tryStatement()
 	case token.LeftBrace:
 		p.increment()
 
@@ -219,6 +433,7 @@ func (p *Parser) ifStatement() (ast.Statement, error) {
 
 // whileStmt parses a while statement.
 func (p *Parser) whileStatement() (ast.Statement, error) {
+	whileLine := p.previous().Line
 	condition, err := p.expression()
 	if err != nil {
 		return nil, err
@@ -229,7 +444,99 @@ func (p *Parser) whileStatement() (ast.Statement, error) {
 		return nil, err
 	}
 
-	return ast.NewWhileStatement(condition, body), nil
+	return ast.NewWhileStatement(whileLine, condition, body), nil
+}
+
+// forStatement parses a C-style for statement (eg: for i := 0; i < 3; i = i + 1 {}).
+func (p *Parser) forStatement() (ast.Statement, error) {
+	forLine := p.previous().Line
+
+	var initializer ast.Statement
+	var err error
+	track := p.track()
+	switch {
+	case p.is(token.Semicolon):
+		p.increment() // no initializer
+	case p.is(token.VarDeclarator):
+		p.increment()
+		initializer, err = p.variable()
+	case p.peekNext().Type == token.VarShortDeclarator:
+		initializer, err = p.varShortDeclaratorStatement()
+	default:
+		initializer, err = p.expressionStatement()
+	}
+	if initializer != nil {
+		initializer, err = track(initializer, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var condition ast.Expression
+	if !p.is(token.Semicolon) {
+		condition, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(token.Semicolon)
+	if err != nil {
+		return nil, fmt.Errorf("expected a ';' after the for condition: %w", err)
+	}
+
+	var post ast.Expression
+	if !p.is(token.LeftBrace) {
+		post, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := p.blockStatement("for")
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewForStatement(forLine, initializer, condition, post, body), nil
+}
+
+// forInStatement parses a range-based for statement (eg: for k, v in map {}).
+func (p *Parser) forInStatement() (ast.Statement, error) {
+	forLine := p.previous().Line
+
+	variable, err := p.consume(token.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("expected a valid loop variable: %w", err)
+	}
+	variables := []*token.Token{variable}
+
+	if p.is(token.Comma) {
+		p.increment() // skip the comma
+
+		variable, err = p.consume(token.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("expected a valid loop variable after ',': %w", err)
+		}
+		variables = append(variables, variable)
+	}
+
+	_, err = p.consume(token.In)
+	if err != nil {
+		return nil, fmt.Errorf("expected 'in' after the loop variables: %w", err)
+	}
+
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.blockStatement("for")
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewForInStatement(forLine, variables, iterable, body), nil
 }
 
 // varShortDeclaratorStmt parses a short declaration of a variable.
@@ -254,7 +561,9 @@ func (p *Parser) varShortDeclaratorStatement() (ast.Statement, error) {
 		return nil, fmt.Errorf("expected a ';' after the variable declaration: %w", err)
 	}
 
-	return ast.NewVariableStatement(name, initializer), nil
+	variable := ast.NewVariableStatement(name, initializer)
+	variable.Short = true
+	return variable, nil
 }
 
 // returnStmt parses a return statement.
@@ -278,6 +587,95 @@ func (p *Parser) returnStatement() (ast.Statement, error) {
 	return ast.NewReturnStatement(returnLine, value), nil
 }
 
+// throwStatement parses a throw statement.
+func (p *Parser) throwStatement() (ast.Statement, error) {
+	throwLine := p.previous().Line
+
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(token.Semicolon)
+	if err != nil {
+		return nil, fmt.Errorf("expected a ';' after the thrown value: %w", err)
+	}
+
+	return ast.NewThrowStatement(throwLine, value), nil
+}
+
+// tryStatement parses a try statement (eg: try {} catch (e) {} finally {}).
+func (p *Parser) tryStatement() (ast.Statement, error) {
+	tryLine := p.previous().Line
+
+	body, err := p.blockStatement("try")
+	if err != nil {
+		return nil, err
+	}
+
+	var catchName *token.Token
+	var catchBody ast.Statement
+	if p.is(token.Catch) {
+		p.increment() // skip the catch
+
+		_, err = p.consume(token.LeftParentheses)
+		if err != nil {
+			return nil, fmt.Errorf("expected '(' after catch: %w", err)
+		}
+
+		catchName, err = p.consume(token.Identifier)
+		if err != nil {
+			return nil, fmt.Errorf("expected a valid variable name for the caught error: %w", err)
+		}
+
+		_, err = p.consume(token.RightParentheses)
+		if err != nil {
+			return nil, fmt.Errorf("expected ')' after the caught error name: %w", err)
+		}
+
+		catchBody, err = p.blockStatement("catch")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var finallyBody ast.Statement
+	if p.is(token.Finally) {
+		p.increment() // skip the finally
+
+		finallyBody, err = p.blockStatement("finally")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if catchBody == nil && finallyBody == nil {
+		return nil, fmt.Errorf("expected a catch or a finally after the try block at line %d", tryLine)
+	}
+
+	return ast.NewTryStatement(tryLine, body, catchName, catchBody, finallyBody), nil
+}
+
+// blockStatement parses a block that is required by a statement (eg: the body of a for, or a try).
+func (p *Parser) blockStatement(keyword string) (ast.Statement, error) {
+	return p.track()(p.parseBlockStatement(keyword))
+}
+
+// parseBlockStatement parses a block that is required by a statement (without recording its lines).
+func (p *Parser) parseBlockStatement(keyword string) (ast.Statement, error) {
+	_, err := p.consume(token.LeftBrace)
+	if err != nil {
+		return nil, fmt.Errorf("expected '{' before the %s body: %w", keyword, err)
+	}
+
+	statements, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewBlockStatement(statements), nil
+}
+
 // breakStatement parses a break statement.
 func (p *Parser) breakStatement() (ast.Statement, error) {
 	breakLine := p.previous().Line
@@ -335,6 +733,7 @@ func (p *Parser) expression() (ast.Expression, error) {
 }
 
 func (p *Parser) assignment() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.or()
 	if err != nil {
 		return nil, err
@@ -351,15 +750,20 @@ func (p *Parser) assignment() (ast.Expression, error) {
 		return nil, fmt.Errorf("failed when parsing the assignment value: %w", err)
 	}
 
-	name, ok := expression.(*ast.VariableExpression)
-	if !ok {
-		return nil, errors.New("invalid assignment")
+	switch target := expression.(type) {
+	case *ast.VariableExpression:
+		return p.at(start, ast.NewAssignmentExpression(target.Name, value)), nil
+	case *ast.GetExpression:
+		return p.at(start, ast.NewSetExpression(target.Object, target.Name, value)), nil
+	case *ast.IndexExpression:
+		return p.at(start, ast.NewIndexAssignmentExpression(target.Line, target.Object, target.Index, value)), nil
 	}
 
-	return ast.NewAssignmentExpression(name.Name, value), nil
+	return nil, errors.New("invalid assignment")
 }
 
 func (p *Parser) or() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.and()
 	if err != nil {
 		return nil, err
@@ -374,13 +778,14 @@ func (p *Parser) or() (ast.Expression, error) {
 			return nil, err
 		}
 
-		expression = ast.NewLogicalExpression(expression, operator, right)
+		expression = p.at(start, ast.NewLogicalExpression(expression, operator, right))
 	}
 
 	return expression, nil
 }
 
 func (p *Parser) and() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.equality()
 	if err != nil {
 		return nil, err
@@ -396,7 +801,7 @@ func (p *Parser) and() (ast.Expression, error) {
 			return nil, err
 		}
 
-		expression = ast.NewLogicalExpression(expression, operator, right)
+		expression = p.at(start, ast.NewLogicalExpression(expression, operator, right))
 	}
 
 	return expression, nil
@@ -404,6 +809,7 @@ func (p *Parser) and() (ast.Expression, error) {
 
 // equality parses an equality.
 func (p *Parser) equality() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.comparison()
 	if err != nil {
 		return nil, err
@@ -419,7 +825,7 @@ func (p *Parser) equality() (ast.Expression, error) {
 			return nil, err
 		}
 
-		expression = ast.NewBinaryExpression(expression, operator, right)
+		expression = p.at(start, ast.NewBinaryExpression(expression, operator, right))
 	}
 
 	return expression, nil
@@ -427,6 +833,7 @@ func (p *Parser) equality() (ast.Expression, error) {
 
 // comparison parses a comparison.
 func (p *Parser) comparison() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.term()
 	if err != nil {
 		return nil, err
@@ -442,7 +849,7 @@ func (p *Parser) comparison() (ast.Expression, error) {
 			return nil, err
 		}
 
-		expression = ast.NewBinaryExpression(expression, operator, right)
+		expression = p.at(start, ast.NewBinaryExpression(expression, operator, right))
 	}
 
 	return expression, nil
@@ -450,6 +857,7 @@ func (p *Parser) comparison() (ast.Expression, error) {
 
 // term parses a term.
 func (p *Parser) term() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.factor()
 	if err != nil {
 		return nil, err
@@ -464,7 +872,7 @@ func (p *Parser) term() (ast.Expression, error) {
 		if err != nil {
 			return nil, err
 		}
-		expression = ast.NewBinaryExpression(expression, operator, right)
+		expression = p.at(start, ast.NewBinaryExpression(expression, operator, right))
 	}
 
 	return expression, nil
@@ -472,6 +880,7 @@ func (p *Parser) term() (ast.Expression, error) {
 
 // factor parses a factor.
 func (p *Parser) factor() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.unary()
 	if err != nil {
 		return nil, err
@@ -487,7 +896,7 @@ func (p *Parser) factor() (ast.Expression, error) {
 			return nil, err
 		}
 
-		expression = ast.NewBinaryExpression(expression, operator, right)
+		expression = p.at(start, ast.NewBinaryExpression(expression, operator, right))
 	}
 
 	return expression, nil
@@ -505,7 +914,7 @@ func (p *Parser) unary() (ast.Expression, error) {
 			return nil, err
 		}
 
-		return ast.NewUnaryExpression(operator, expression), nil
+		return p.at(operator, ast.NewUnaryExpression(operator, expression)), nil
 	}
 
 	return p.call()
@@ -513,17 +922,27 @@ func (p *Parser) unary() (ast.Expression, error) {
 
 // call parses a call.
 func (p *Parser) call() (ast.Expression, error) {
+	start := p.peek()
 	expression, err := p.primary()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.is(token.LeftParentheses) {
-		p.increment() // skip the parentheses
-		expression, err = p.parseCall(expression)
+	for p.is(token.LeftParentheses, token.LeftBracket, token.Dot) {
+		p.increment() // skip the parentheses, bracket or dot
+
+		switch p.previous().Type {
+		case token.LeftBracket:
+			expression, err = p.parseIndex(expression)
+		case token.Dot:
+			expression, err = p.parseProperty(expression)
+		default:
+			expression, err = p.parseCall(expression)
+		}
 		if err != nil {
 			return nil, err
 		}
+		p.at(start, expression)
 	}
 
 	return expression, nil
@@ -531,6 +950,16 @@ func (p *Parser) call() (ast.Expression, error) {
 
 // primary parses a primary.
 func (p *Parser) primary() (ast.Expression, error) {
+	start := p.peek()
+	expression, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return p.at(start, expression), nil
+}
+
+// parsePrimary parses a primary (without setting its position).
+func (p *Parser) parsePrimary() (ast.Expression, error) {
 	if p.is(token.Number, token.String) {
 		p.increment()
 		return ast.NewLiteralExpression(p.previous().Literal), nil
@@ -551,6 +980,36 @@ func (p *Parser) primary() (ast.Expression, error) {
 		p.increment()
 		return ast.NewVariableExpression(p.previous()), nil
 	}
+	if p.is(token.This) {
+		p.increment()
+		return ast.NewThisExpression(p.previous()), nil
+	}
+	if p.is(token.Super) {
+		p.increment()
+		return p.super()
+	}
+
+	// Handle lists
+	if p.is(token.LeftBracket) {
+		p.increment()
+		return p.list()
+	}
+
+	// Handle maps
+	if p.is(token.LeftBrace) {
+		p.increment()
+		return p.mapLiteral()
+	}
+
+	// Handle anonymous functions
+	if p.is(token.Fn) {
+		p.increment()
+		return p.functionExpression()
+	}
+	if p.isArrowFunction() {
+		p.increment()
+		return p.arrowFunction()
+	}
 
 	// Handle grouping
 	if p.is(token.LeftParentheses) {
@@ -602,6 +1061,162 @@ func (p *Parser) parseCall(callee ast.Expression) (ast.Expression, error) {
 	return ast.NewCallExpression(closingParen.Line, callee, arguments), nil
 }
 
+// list parses a list literal (trailing comma is allowed).
+func (p *Parser) list() (ast.Expression, error) {
+	var elements []ast.Expression
+
+	for !p.is(token.RightBracket) && !p.isEnd() {
+		element, err := p.expression()
+		if err != nil {
+			return nil, fmt.Errorf("failed when parsing list element: %w", err)
+		}
+		elements = append(elements, element)
+
+		if !p.is(token.Comma) {
+			break
+		}
+		p.increment() // skip the comma
+	}
+
+	_, err := p.consume(token.RightBracket)
+	if err != nil {
+		return nil, fmt.Errorf("expected a closing ']' after the list elements: %w", err)
+	}
+
+	return ast.NewListExpression(elements), nil
+}
+
+// mapLiteral parses a map literal (trailing comma is allowed).
+func (p *Parser) mapLiteral() (ast.Expression, error) {
+	line := p.previous().Line
+	var keys []ast.Expression
+	var values []ast.Expression
+
+	for !p.is(token.RightBrace) && !p.isEnd() {
+		key, err := p.expression()
+		if err != nil {
+			return nil, fmt.Errorf("failed when parsing map key: %w", err)
+		}
+
+		_, err = p.consume(token.Colon)
+		if err != nil {
+			return nil, fmt.Errorf("expected a ':' after the map key: %w", err)
+		}
+
+		value, err := p.expression()
+		if err != nil {
+			return nil, fmt.Errorf("failed when parsing map value: %w", err)
+		}
+
+		keys = append(keys, key)
+		values = append(values, value)
+
+		if !p.is(token.Comma) {
+			break
+		}
+		p.increment() // skip the comma
+	}
+
+	_, err := p.consume(token.RightBrace)
+	if err != nil {
+		return nil, fmt.Errorf("expected a closing '}' after the map entries: %w", err)
+	}
+
+	return ast.NewMapExpression(line, keys, values), nil
+}
+
+// isArrowFunction determines if the current "(" starts an arrow function instead of a grouping.
+// The parameters of an arrow function can only be identifiers separated by commas, followed by ") =>".
+func (p *Parser) isArrowFunction() bool {
+	if !p.is(token.LeftParentheses) {
+		return false
+	}
+
+	expectIdentifier := true
+	for i := p.current + 1; i+1 < len(p.tokens); i++ {
+		switch tokenType := p.tokens[i].Type; {
+		case tokenType == token.RightParentheses:
+			return p.tokens[i+1].Type == token.Arrow
+		case expectIdentifier && tokenType == token.Identifier,
+			!expectIdentifier && tokenType == token.Comma:
+			expectIdentifier = !expectIdentifier
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// isForIn checks if the for statement being parsed is a range-based one (eg: for x in list, or for k, v in map).
+func (p *Parser) isForIn() bool {
+	if !p.is(token.Identifier) || p.current+1 >= len(p.tokens) {
+		return false
+	}
+
+	next := p.tokens[p.current+1].Type
+	if next == token.In {
+		return true
+	}
+
+	return next == token.Comma && p.current+3 < len(p.tokens) && p.tokens[p.current+3].Type == token.In
+}
+
+// isMapLiteral determines if the current "{" starts a map literal instead of a block.
+// A block can never start with "<something> :", so that is used to distinguish them.
+// Note: an empty "{}" at the beginning of a statement is an empty block.
+func (p *Parser) isMapLiteral() bool {
+	if !p.is(token.LeftBrace) || p.current+2 >= len(p.tokens) {
+		return false
+	}
+
+	return p.tokens[p.current+2].Type == token.Colon
+}
+
+// super parses the access to a method of the superclass (eg: super.method).
+func (p *Parser) super() (ast.Expression, error) {
+	keyword := p.previous()
+
+	_, err := p.consume(token.Dot)
+	if err != nil {
+		return nil, fmt.Errorf("expected '.' after 'super': %w", err)
+	}
+
+	method, err := p.consume(token.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("expected a superclass method name after 'super.': %w", err)
+	}
+
+	return ast.NewSuperExpression(keyword, method), nil
+}
+
+// parseProperty parses the access to a property (eg: object.property).
+func (p *Parser) parseProperty(object ast.Expression) (ast.Expression, error) {
+	name, err := p.consume(token.Identifier)
+	if err != nil {
+		return nil, fmt.Errorf("expected a property name after '.': %w", err)
+	}
+
+	return ast.NewGetExpression(object, name), nil
+}
+
+// parseIndex parses the index used to access an element (eg: list[0]).
+func (p *Parser) parseIndex(object ast.Expression) (ast.Expression, error) {
+	line := p.previous().Line
+
+	index, err := p.expression()
+	if err != nil {
+		return nil, fmt.Errorf("failed when parsing the index: %w", err)
+	}
+
+	_, err = p.consume(token.RightBracket)
+	if err != nil {
+		return nil, fmt.Errorf("expected a closing ']' after the index: %w", err)
+	}
+
+	return ast.NewIndexExpression(line, object, index), nil
+}
+
 func (p *Parser) previous() *token.Token {
 	return p.tokens[p.current-1]
 }
@@ -641,7 +1256,7 @@ func (p *Parser) consume(expected token.Type) (*token.Token, error) {
 		return p.previous(), nil
 	}
 
-	return nil, fmt.Errorf("unexpected token at line %d", p.peek().Line)
+	return nil, fmt.Errorf("unexpected token at line %d, column %d", p.peek().Line, p.peek().Span.Start.Column)
 }
 
 func (p *Parser) synchronize() {
@@ -655,9 +1270,14 @@ func (p *Parser) synchronize() {
 		switch p.peek().Type {
 		case
 			token.Fn,
+			token.Class,
+			token.Import,
 			token.VarDeclarator,
 			token.If,
 			token.While,
+			token.For,
+			token.Try,
+			token.Throw,
 			token.Return,
 			token.Print:
 			return
@@ -2,6 +2,7 @@ package parser_test
 
 import (
 	"bytes"
+	"reflect"
 	"testing"
 
 	"github.com/avazquezcode/govetryx/internal/domain/ast"
@@ -235,9 +236,11 @@ func TestParse(t *testing.T) {
 		"assignment with variable declaration (short var declarator)": {
 			src: "a := \"hello\";",
 			expected: []ast.Statement{
-				ast.NewVariableStatement(
-					token.NewToken(token.Identifier, "a", nil, 1),
-					ast.NewLiteralExpression("hello")),
+				&ast.VariableStatement{
+					Name:  token.NewToken(token.Identifier, "a", nil, 1),
+					Value: ast.NewLiteralExpression("hello"),
+					Short: true,
+				},
 			},
 		},
 		"if condition": {
@@ -281,7 +284,7 @@ func TestParse(t *testing.T) {
 		"while loop": {
 			src: "while 1 == 1 {}",
 			expected: []ast.Statement{
-				ast.NewWhileStatement(
+				ast.NewWhileStatement(1,
 					ast.NewBinaryExpression(
 						ast.NewLiteralExpression(float64(1)),
 						token.NewToken(token.EqualEqual, "==", nil, 1),
@@ -292,7 +295,7 @@ func TestParse(t *testing.T) {
 		"while loop (with paren)": {
 			src: "while (1 == 1) {}",
 			expected: []ast.Statement{
-				ast.NewWhileStatement(
+				ast.NewWhileStatement(1,
 					ast.NewGroupingExpression(
 						ast.NewBinaryExpression(
 							ast.NewLiteralExpression(float64(1)),
@@ -305,7 +308,7 @@ func TestParse(t *testing.T) {
 		"while loop with break": {
 			src: "while 1 == 1 {break;}",
 			expected: []ast.Statement{
-				ast.NewWhileStatement(
+				ast.NewWhileStatement(1,
 					ast.NewBinaryExpression(
 						ast.NewLiteralExpression(float64(1)),
 						token.NewToken(token.EqualEqual, "==", nil, 1),
@@ -320,7 +323,7 @@ func TestParse(t *testing.T) {
 		"while loop with continue": {
 			src: "while 1 == 1 {continue;}",
 			expected: []ast.Statement{
-				ast.NewWhileStatement(
+				ast.NewWhileStatement(1,
 					ast.NewBinaryExpression(
 						ast.NewLiteralExpression(float64(1)),
 						token.NewToken(token.EqualEqual, "==", nil, 1),
@@ -332,6 +335,128 @@ func TestParse(t *testing.T) {
 					)),
 			},
 		},
+		"for loop": {
+			src: "for i := 0; i < 1; i = i + 1 {}",
+			expected: []ast.Statement{
+				ast.NewForStatement(1,
+					&ast.VariableStatement{
+						Name:  token.NewToken(token.Identifier, "i", nil, 1),
+						Value: ast.NewLiteralExpression(float64(0)),
+						Short: true,
+					},
+					ast.NewBinaryExpression(
+						ast.NewVariableExpression(token.NewToken(token.Identifier, "i", nil, 1)),
+						token.NewToken(token.Lower, "<", nil, 1),
+						ast.NewLiteralExpression(float64(1))),
+					ast.NewAssignmentExpression(
+						token.NewToken(token.Identifier, "i", nil, 1),
+						ast.NewBinaryExpression(
+							ast.NewVariableExpression(token.NewToken(token.Identifier, "i", nil, 1)),
+							token.NewToken(token.Plus, "+", nil, 1),
+							ast.NewLiteralExpression(float64(1)))),
+					ast.NewBlockStatement(nil)),
+			},
+		},
+		"for loop without clauses": {
+			src: "for ;; {break;}",
+			expected: []ast.Statement{
+				ast.NewForStatement(1, nil, nil, nil,
+					ast.NewBlockStatement(
+						[]ast.Statement{
+							ast.NewBreakStatement(1),
+						},
+					)),
+			},
+		},
+		"for in loop": {
+			src: "for x in a {}",
+			expected: []ast.Statement{
+				ast.NewForInStatement(1,
+					[]*token.Token{token.NewToken(token.Identifier, "x", nil, 1)},
+					ast.NewVariableExpression(token.NewToken(token.Identifier, "a", nil, 1)),
+					ast.NewBlockStatement(nil)),
+			},
+		},
+		"for in loop with two variables": {
+			src: "for k, v in a {}",
+			expected: []ast.Statement{
+				ast.NewForInStatement(1,
+					[]*token.Token{
+						token.NewToken(token.Identifier, "k", nil, 1),
+						token.NewToken(token.Identifier, "v", nil, 1),
+					},
+					ast.NewVariableExpression(token.NewToken(token.Identifier, "a", nil, 1)),
+					ast.NewBlockStatement(nil)),
+			},
+		},
+		"anonymous function": {
+			src: "fn(a) { return a; };",
+			expected: []ast.Statement{
+				ast.NewExpressionStatement(
+					ast.NewFunctionExpression(1,
+						[]*token.Token{token.NewToken(token.Identifier, "a", nil, 1)},
+						[]ast.Statement{
+							ast.NewReturnStatement(1,
+								ast.NewVariableExpression(token.NewToken(token.Identifier, "a", nil, 1))),
+						})),
+			},
+		},
+		"arrow function": {
+			src: "dec f = (a, b) => a;",
+			expected: []ast.Statement{
+				ast.NewVariableStatement(
+					token.NewToken(token.Identifier, "f", nil, 1),
+					&ast.FunctionExpression{
+						Line: 1,
+						Parameters: []*token.Token{
+							token.NewToken(token.Identifier, "a", nil, 1),
+							token.NewToken(token.Identifier, "b", nil, 1),
+						},
+						Body: []ast.Statement{
+							ast.NewReturnStatement(1,
+								ast.NewVariableExpression(token.NewToken(token.Identifier, "a", nil, 1))),
+						},
+						Syntax: ast.ArrowExpressionSyntax,
+					}),
+			},
+		},
+		"arrow function without parameters and with a block": {
+			src: "() => {};",
+			expected: []ast.Statement{
+				ast.NewExpressionStatement(
+					&ast.FunctionExpression{Line: 1, Syntax: ast.ArrowBlockSyntax}),
+			},
+		},
+		"import": {
+			src: `import "lib/a.vx" as a;`,
+			expected: []ast.Statement{
+				ast.NewImportStatement(1,
+					token.NewToken(token.String, `"lib/a.vx"`, "lib/a.vx", 1),
+					token.NewToken(token.Identifier, "a", nil, 1)),
+			},
+		},
+		"throw": {
+			src: `throw "a";`,
+			expected: []ast.Statement{
+				ast.NewThrowStatement(1, ast.NewLiteralExpression("a")),
+			},
+		},
+		"try with catch and finally": {
+			src: "try {} catch (e) {} finally {}",
+			expected: []ast.Statement{
+				ast.NewTryStatement(1,
+					ast.NewBlockStatement(nil),
+					token.NewToken(token.Identifier, "e", nil, 1),
+					ast.NewBlockStatement(nil),
+					ast.NewBlockStatement(nil)),
+			},
+		},
+		"try with finally": {
+			src: "try {} finally {}",
+			expected: []ast.Statement{
+				ast.NewTryStatement(1, ast.NewBlockStatement(nil), nil, nil, ast.NewBlockStatement(nil)),
+			},
+		},
 		"function declaration": {
 			src: "fn a() {}",
 			expected: []ast.Statement{
@@ -418,6 +543,87 @@ func TestParse(t *testing.T) {
 						})),
 			},
 		},
+		"empty list": {
+			src: "[];",
+			expected: []ast.Statement{
+				ast.NewExpressionStatement(ast.NewListExpression(nil)),
+			},
+		},
+		"list with elements (and trailing comma)": {
+			src: "[1, a,];",
+			expected: []ast.Statement{
+				ast.NewExpressionStatement(
+					ast.NewListExpression([]ast.Expression{
+						ast.NewLiteralExpression(float64(1)),
+						ast.NewVariableExpression(token.NewToken(token.Identifier, "a", nil, 1)),
+					})),
+			},
+		},
+		"empty map": {
+			src: "dec a = {};",
+			expected: []ast.Statement{
+				ast.NewVariableStatement(
+					token.NewToken(token.Identifier, "a", nil, 1),
+					ast.NewMapExpression(1, nil, nil)),
+			},
+		},
+		"map with entries": {
+			src: `dec a = {"b": 1, c: 2,};`,
+			expected: []ast.Statement{
+				ast.NewVariableStatement(
+					token.NewToken(token.Identifier, "a", nil, 1),
+					ast.NewMapExpression(
+						1,
+						[]ast.Expression{
+							ast.NewLiteralExpression("b"),
+							ast.NewVariableExpression(token.NewToken(token.Identifier, "c", nil, 1)),
+						},
+						[]ast.Expression{
+							ast.NewLiteralExpression(float64(1)),
+							ast.NewLiteralExpression(float64(2)),
+						})),
+			},
+		},
+		"map at the beginning of a statement": {
+			src: `{"b": 1};`,
+			expected: []ast.Statement{
+				ast.NewExpressionStatement(
+					ast.NewMapExpression(
+						1,
+						[]ast.Expression{ast.NewLiteralExpression("b")},
+						[]ast.Expression{ast.NewLiteralExpression(float64(1))})),
+			},
+		},
+		"empty block (not a map)": {
+			src: "{}",
+			expected: []ast.Statement{
+				ast.NewBlockStatement(nil),
+			},
+		},
+		"index": {
+			src: "a[0][1];",
+			expected: []ast.Statement{
+				ast.NewExpressionStatement(
+					ast.NewIndexExpression(
+						1,
+						ast.NewIndexExpression(
+							1,
+							ast.NewVariableExpression(token.NewToken(token.Identifier, "a", nil, 1)),
+							ast.NewLiteralExpression(float64(0))),
+						ast.NewLiteralExpression(float64(1)))),
+			},
+		},
+		"index assignment": {
+			src: "a[0] = 1;",
+			expected: []ast.Statement{
+				ast.NewExpressionStatement(
+					ast.NewIndexAssignmentExpression(
+						1,
+						ast.NewVariableExpression(token.NewToken(token.Identifier, "a", nil, 1)),
+						ast.NewLiteralExpression(float64(0)),
+						ast.NewLiteralExpression(float64(1)))),
+			},
+		},
 		// ERRORS SECTION
 		"missing identifier after fn declaration": {
 			src:         "fn ()",
@@ -479,6 +685,50 @@ func TestParse(t *testing.T) {
 			src:         "while ()",
 			expectedErr: true,
 		},
+		"missing semicolon after for condition": {
+			src:         "for i := 0; i < 1 {}",
+			expectedErr: true,
+		},
+		"for without a block body": {
+			src:         "for x in a print x;",
+			expectedErr: true,
+		},
+		"for in without iterable": {
+			src:         "for x in {}",
+			expectedErr: true,
+		},
+		"arrow function without body": {
+			src:         "dec f = (a) =>;",
+			expectedErr: true,
+		},
+		"arrow function with an invalid parameter": {
+			src:         "dec f = (1) => 1;",
+			expectedErr: true,
+		},
+		"anonymous function without body": {
+			src:         "dec f = fn(a);",
+			expectedErr: true,
+		},
+		"import without name": {
+			src:         `import "lib/a.vx";`,
+			expectedErr: true,
+		},
+		"import without path": {
+			src:         `import a as a;`,
+			expectedErr: true,
+		},
+		"try without catch or finally": {
+			src:         "try {}",
+			expectedErr: true,
+		},
+		"catch without variable": {
+			src:         "try {} catch {}",
+			expectedErr: true,
+		},
+		"throw without value": {
+			src:         "throw;",
+			expectedErr: true,
+		},
 		"wrong right operand on unary operation": {
 			src:         "!>",
 			expectedErr: true,
@@ -539,6 +789,30 @@ func TestParse(t *testing.T) {
 			src:         "(",
 			expectedErr: true,
 		},
+		"missing closing bracket in list": {
+			src:         "[1, 2;",
+			expectedErr: true,
+		},
+		"missing closing bracket in index": {
+			src:         "a[1;",
+			expectedErr: true,
+		},
+		"missing colon in map": {
+			src:         `dec a = {"b" 1};`,
+			expectedErr: true,
+		},
+		"missing value in map": {
+			src:         `dec a = {"b": };`,
+			expectedErr: true,
+		},
+		"missing closing brace in map": {
+			src:         `dec a = {"b": 1;`,
+			expectedErr: true,
+		},
+		"missing index": {
+			src:         "a[];",
+			expectedErr: true,
+		},
 	}
 
 	for desc, test := range tests {
@@ -553,12 +827,95 @@ func TestParse(t *testing.T) {
 				return
 			}
 
+			clearPositions(reflect.ValueOf(statements)) // the positions are checked by TestParseTracksThePositions
 			assert.Equal(t, test.expected, statements)
 			assert.Nil(t, err)
 		})
 	}
 }
 
+func TestParseTracksTheLines(t *testing.T) {
+	src := "print 1;\nfn f() {\n  return\n    2;\n}\nclass A {\n  m() {}\n}"
+	tokens, _ := scanner.NewScanner(bytes.Runes(strToBytes(src))).Scan()
+	p := parser.NewParser(tokens)
+	statements, err := p.Parse()
+	assert.Nil(t, err)
+
+	lines := p.Lines()
+	assert.Equal(t, ast.LineRange{Start: 1, End: 1}, lines[statements[0]])
+	assert.Equal(t, ast.LineRange{Start: 2, End: 5}, lines[statements[1]])
+	assert.Equal(t, ast.LineRange{Start: 3, End: 4}, lines[statements[1].(*ast.FunctionStatement).Body[0]])
+	assert.Equal(t, ast.LineRange{Start: 6, End: 8}, lines[statements[2]])
+	assert.Equal(t, ast.LineRange{Start: 7, End: 7}, lines[statements[2].(*ast.ClassStatement).Methods[0]])
+}
+
+func TestParseTracksThePositions(t *testing.T) {
+	src := "dec a = -b + (1 * 2);\nfoo(a,\n  \"ñ\")[0];"
+	tokens, _ := scanner.NewScanner(bytes.Runes(strToBytes(src))).Scan()
+	statements, err := parser.NewParser(tokens).Parse()
+	assert.Nil(t, err)
+
+	span := func(startLine, startColumn, startOffset, endLine, endColumn, endOffset int) token.Span {
+		return token.Span{
+			Start: token.Position{Line: startLine, Column: startColumn, Offset: startOffset},
+			End:   token.Position{Line: endLine, Column: endColumn, Offset: endOffset},
+		}
+	}
+
+	declaration := statements[0].(*ast.VariableStatement)
+	assert.Equal(t, span(1, 1, 0, 1, 22, 21), declaration.Position())
+	assert.Equal(t, span(1, 5, 4, 1, 6, 5), declaration.Name.Span)
+
+	sum := declaration.Value.(*ast.BinaryExpression)
+	assert.Equal(t, span(1, 9, 8, 1, 21, 20), sum.Position())
+	assert.Equal(t, span(1, 9, 8, 1, 11, 10), sum.Left.Position())
+	assert.Equal(t, span(1, 14, 13, 1, 21, 20), sum.Right.Position())
+	assert.Equal(t, span(1, 15, 14, 1, 20, 19), sum.Right.(*ast.GroupingExpression).Expression.Position())
+
+	index := statements[1].(*ast.ExpressionStatement).Expression.(*ast.IndexExpression)
+	assert.Equal(t, span(2, 1, 22, 3, 10, 39), index.Position())
+	assert.Equal(t, span(2, 1, 22, 3, 7, 36), index.Object.Position())
+	assert.Equal(t, span(3, 3, 31, 3, 6, 35), index.Object.(*ast.CallExpression).Arguments[1].Position()) // "ñ" takes 2 bytes
+}
+
+func TestParsingErrShowsTheExcerpts(t *testing.T) {
+	src := "print 1;\ndec a = (1;\nprint 2"
+	tokens, _ := scanner.NewScanner(bytes.Runes(strToBytes(src))).Scan()
+	_, err := parser.NewParser(tokens).Parse()
+
+	var parsingErr *parser.ParsingErr
+	assert.ErrorAs(t, err, &parsingErr)
+	expected := "error #1: expected ')' after the expression: unexpected token at line 2, column 11 \n" +
+		"2 | dec a = (1;\n" +
+		"  |           ^\n" +
+		"error #2: expected a ';' after the print statement: unexpected token at line 3, column 8 \n" +
+		"3 | print 2\n" +
+		"  |        ^\n"
+	assert.Equal(t, expected, parsingErr.WithExcerpts(src).Error())
+}
+
+// clearPositions clears the positions of the tokens and nodes reachable from the value.
+func clearPositions(value reflect.Value) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			clearPositions(value.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			clearPositions(value.Index(i))
+		}
+	case reflect.Struct:
+		if value.Type() == reflect.TypeOf(token.Span{}) {
+			value.Set(reflect.ValueOf(token.Span{}))
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			clearPositions(value.Field(i))
+		}
+	}
+}
+
 func strToBytes(str string) []byte {
 	return []byte(str)
 }
@@ -0,0 +1,193 @@
+package profiler
+
+import (
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The fields of the messages of the pprof format (see github.com/google/pprof/proto/profile.proto).
+const (
+	profileSampleType        = 1
+	profileSample            = 2
+	profileLocation          = 4
+	profileFunction          = 5
+	profileStringTable       = 6
+	profileDurationNanos     = 10
+	profileDefaultSampleType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+)
+
+// WritePprof writes the profile in the pprof format (a gzipped protocol buffer), so it can be opened by
+// "go tool pprof". Each sample is a line of a call (with the stack of the calls that led to it), with the
+// quantity of statements run and the time spent in it. The filename is the path of the script (the paths of the
+// imported files, as written in their imports, are resolved from its directory).
+func (p *Profile) WritePprof(w io.Writer, filename string) error {
+	e := &pprofEncoder{filename: filename, strings: map[string]int64{}, functions: map[callSite]uint64{}, locations: map[callSite]uint64{}}
+	e.strings[""] = 0
+	e.table = []string{""}
+
+	var profile protobuf
+	for _, valueType := range [][2]string{{"hits", "count"}, {"time", "nanoseconds"}} {
+		var message protobuf
+		message.int64(valueTypeType, e.string(valueType[0]))
+		message.int64(valueTypeUnit, e.string(valueType[1]))
+		profile.message(profileSampleType, &message)
+	}
+
+	p.root.visit(func(n *node) {
+		for _, line := range sortedLines(n) {
+			stats := n.lines[line]
+			if stats.hits == 0 && stats.time == 0 {
+				continue
+			}
+
+			// the stack goes from the line being run up to the top level of the script
+			stack := []uint64{e.location(n.key(), line)}
+			for call := n; call.parent != nil; call = call.parent {
+				stack = append(stack, e.location(call.parent.key(), call.line))
+			}
+
+			var sample protobuf
+			sample.packed(sampleLocationID, stack)
+			sample.packed(sampleValue, []uint64{uint64(stats.hits), uint64(stats.time)})
+			profile.message(profileSample, &sample)
+		}
+	})
+
+	profile.Write(e.messages.Bytes())
+	for _, s := range e.table {
+		profile.bytes(profileStringTable, []byte(s))
+	}
+	profile.int64(profileDurationNanos, int64(p.Duration))
+	profile.int64(profileDefaultSampleType, e.string("time"))
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// pprofEncoder builds the tables of the profile: the strings, and the functions and locations referenced by
+// the samples (whose messages are kept until the samples are written).
+type pprofEncoder struct {
+	filename  string // path of the script
+	table     []string
+	strings   map[string]int64
+	functions map[callSite]uint64
+	locations map[callSite]uint64
+	messages  protobuf
+}
+
+// string returns the index of a string in the table of strings.
+func (e *pprofEncoder) string(s string) int64 {
+	index, ok := e.strings[s]
+	if !ok {
+		index = int64(len(e.table))
+		e.strings[s] = index
+		e.table = append(e.table, s)
+	}
+	return index
+}
+
+// location returns the ID of the location of a line of a function.
+func (e *pprofEncoder) location(function callSite, line int) uint64 {
+	site := callSite{function: function.function, file: function.file, line: line}
+	if id, ok := e.locations[site]; ok {
+		return id
+	}
+
+	id := uint64(len(e.locations) + 1)
+	e.locations[site] = id
+
+	var l protobuf
+	l.uint64(lineFunctionID, e.function(function))
+	l.int64(lineLine, int64(line))
+
+	var location protobuf
+	location.uint64(locationID, id)
+	location.message(locationLine, &l)
+	e.messages.message(profileLocation, &location)
+	return id
+}
+
+// function returns the ID of a function.
+func (e *pprofEncoder) function(site callSite) uint64 {
+	if id, ok := e.functions[site]; ok {
+		return id
+	}
+
+	id := uint64(len(e.functions) + 1)
+	e.functions[site] = id
+
+	// pprof removes the text between angle brackets from the names (like the arguments of the C++ templates)
+	name := site.function
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		name = "[" + name[1:len(name)-1] + "]"
+	}
+
+	filename := e.filename
+	if site.file != "" {
+		filename = site.file
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(filepath.Dir(e.filename), filename)
+		}
+	}
+
+	var function protobuf
+	function.uint64(functionID, id)
+	function.int64(functionName, e.string(name))
+	function.int64(functionSystemName, e.string(name))
+	function.int64(functionFilename, e.string(filename))
+	e.messages.message(profileFunction, &function)
+	return id
+}
+
+// visit calls a function with the node and all the nodes below it (the children are sorted, so the order
+// doesn't change between runs).
+func (n *node) visit(f func(n *node)) {
+	f(n)
+
+	sites := make([]callSite, 0, len(n.children))
+	for site := range n.children {
+		sites = append(sites, site)
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].line != sites[j].line {
+			return sites[i].line < sites[j].line
+		}
+		return sites[i].function < sites[j].function
+	})
+
+	for _, site := range sites {
+		n.children[site].visit(f)
+	}
+}
+
+func sortedLines(n *node) []int {
+	lines := make([]int, 0, len(n.lines))
+	for line := range n.lines {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
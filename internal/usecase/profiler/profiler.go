@@ -0,0 +1,256 @@
+// Package profiler measures where the scripts (run in the tree-walking interpreter) spend their time: it traces
+// the statements and the calls, building the tree of the calls made, with the time spent and the statements run
+// in each line of each of them.
+package profiler
+
+import (
+	"sort"
+	"time"
+)
+
+// scriptName is the name of the function of the top level of the script.
+const scriptName = "<script>"
+
+type (
+	// Profile is the result of profiling a script.
+	Profile struct {
+		Duration  time.Duration
+		Functions []FunctionStats // sorted by self time (from the greatest one)
+		Lines     []LineStats     // sorted by self time (from the greatest one)
+		root      *node           // the tree of the calls (see WritePprof)
+	}
+
+	// FunctionStats are the measurements of a function, adding up all its calls.
+	FunctionStats struct {
+		Name  string
+		File  string // imported file where the function was declared (empty for the script and the natives)
+		Calls int
+		Self  time.Duration // time spent running the function itself
+		Total time.Duration // time spent running the function and the ones it called
+	}
+
+	// LineStats are the measurements of a line of a file (the one of the function), in a function.
+	// The line 0 has the time spent in the calls of the function outside its lines (entering and leaving them, or
+	// running the natives), so the lines add up to the self time of the functions.
+	LineStats struct {
+		Line     int
+		Function string
+		File     string // imported file where the function was declared (empty for the script and the natives)
+		Hits     int    // quantity of statements run
+		Self     time.Duration
+	}
+)
+
+type (
+	// node is a call in the tree of the calls (the calls to the same function from the same line of the same
+	// caller are merged into one node).
+	node struct {
+		function string
+		file     string
+		line     int // line of the call, in the caller
+		calls    int
+		total    time.Duration // time spent in the call and in the ones it made (see measure)
+		parent   *node
+		children map[callSite]*node
+		lines    map[int]*lineStats // measurements of the lines run in the function (0 outside its lines)
+	}
+
+	// callSite is a line of a function (the line is 0 when it identifies the function).
+	callSite struct {
+		function string
+		file     string
+		line     int
+	}
+
+	lineStats struct {
+		hits int
+		time time.Duration
+	}
+)
+
+// Profiler measures the time spent in each line of each active call, adding the time elapsed since the previous
+// event (a statement or a call) to the line being run.
+// It implements interpreter.Profiler.
+type Profiler struct {
+	clock   func() time.Time
+	start   time.Time
+	last    time.Time
+	root    *node
+	current *node
+	line    int   // line being run in the current call
+	lines   []int // lines being run in the callers of the current call
+}
+
+// New is a constructor for a Profiler, that starts measuring at the first statement. The clock returns the current
+// time (eg: time.Now).
+func New(clock func() time.Time) *Profiler {
+	root := newNode(scriptName, "", 0, nil)
+	root.calls = 1
+
+	return &Profiler{
+		clock:   clock,
+		root:    root,
+		current: root,
+	}
+}
+
+func newNode(function string, file string, line int, parent *node) *node {
+	return &node{
+		function: function,
+		file:     file,
+		line:     line,
+		parent:   parent,
+		children: map[callSite]*node{},
+		lines:    map[int]*lineStats{},
+	}
+}
+
+// Statement is called before running a statement (of the file of the current call).
+func (p *Profiler) Statement(line int) {
+	p.tick()
+	p.line = line
+	p.current.stats(line).hits++
+}
+
+// Enter is called before calling a function (declared in the given file).
+func (p *Profiler) Enter(function string, file string, line int) {
+	p.tick()
+
+	site := callSite{function: function, file: file, line: line}
+	child, ok := p.current.children[site]
+	if !ok {
+		child = newNode(function, file, line, p.current)
+		p.current.children[site] = child
+	}
+	child.calls++
+
+	p.lines = append(p.lines, p.line)
+	p.current, p.line = child, 0
+}
+
+// Exit is called after a called function returns.
+func (p *Profiler) Exit() {
+	p.tick()
+	p.current = p.current.parent
+	p.line = p.lines[len(p.lines)-1]
+	p.lines = p.lines[:len(p.lines)-1]
+}
+
+// tick adds the time elapsed since the previous event to the line being run.
+func (p *Profiler) tick() {
+	now := p.clock()
+	if p.start.IsZero() {
+		p.start, p.last = now, now
+	}
+	p.current.stats(p.line).time += now.Sub(p.last)
+	p.last = now
+}
+
+func (n *node) stats(line int) *lineStats {
+	stats, ok := n.lines[line]
+	if !ok {
+		stats = &lineStats{}
+		n.lines[line] = stats
+	}
+	return stats
+}
+
+// Profile returns the measurements taken until now (eg: when the script ends).
+func (p *Profiler) Profile() *Profile {
+	p.tick()
+
+	profile := &Profile{Duration: p.last.Sub(p.start), root: p.root}
+	functions := map[callSite]*FunctionStats{}
+	lines := map[callSite]*LineStats{}
+	p.root.measure()
+	p.root.walk(map[callSite]bool{}, func(n *node, recursive bool) {
+		function, ok := functions[n.key()]
+		if !ok {
+			function = &FunctionStats{Name: n.function, File: n.file}
+			functions[n.key()] = function
+		}
+		function.Calls += n.calls
+		if !recursive {
+			function.Total += n.total // the time of the recursive calls is already in the outer one
+		}
+
+		for number, stats := range n.lines {
+			function.Self += stats.time
+			if stats.hits == 0 && stats.time == 0 {
+				continue // eg: the line 0 of the script, before the first statement
+			}
+
+			site := callSite{function: n.function, file: n.file, line: number}
+			line, ok := lines[site]
+			if !ok {
+				line = &LineStats{Line: number, Function: n.function, File: n.file}
+				lines[site] = line
+			}
+			line.Hits += stats.hits
+			line.Self += stats.time
+		}
+	})
+
+	for _, function := range functions {
+		profile.Functions = append(profile.Functions, *function)
+	}
+	sort.Slice(profile.Functions, func(i, j int) bool {
+		a, b := profile.Functions[i], profile.Functions[j]
+		if a.Self != b.Self {
+			return a.Self > b.Self
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.File < b.File
+	})
+
+	for _, line := range lines {
+		profile.Lines = append(profile.Lines, *line)
+	}
+	sort.Slice(profile.Lines, func(i, j int) bool {
+		a, b := profile.Lines[i], profile.Lines[j]
+		if a.Self != b.Self {
+			return a.Self > b.Self
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Function != b.Function {
+			return a.Function < b.Function
+		}
+		return a.File < b.File
+	})
+
+	return profile
+}
+
+// measure sets the total time of the node and of its children, returning it.
+func (n *node) measure() time.Duration {
+	n.total = 0
+	for _, stats := range n.lines {
+		n.total += stats.time
+	}
+	for _, child := range n.children {
+		n.total += child.measure()
+	}
+	return n.total
+}
+
+// walk visits the nodes of the tree (the parents before their children), indicating whether each one is a
+// recursive call (of a function that is already being called by one of its ancestors).
+func (n *node) walk(active map[callSite]bool, visit func(n *node, recursive bool)) {
+	recursive := active[n.key()]
+	visit(n, recursive)
+
+	active[n.key()] = true
+	for _, child := range n.children {
+		child.walk(active, visit)
+	}
+	active[n.key()] = recursive
+}
+
+// key identifies the function of the node (the functions with the same name in different files are different).
+func (n *node) key() callSite {
+	return callSite{function: n.function, file: n.file}
+}
@@ -0,0 +1,212 @@
+package profiler_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/profiler"
+	"github.com/avazquezcode/govetryx/vetryx"
+	"github.com/stretchr/testify/assert"
+)
+
+const script = `fn double(n) {
+    return n * 2;
+}
+dec a = double(1);
+dec b = double(2);`
+
+// importingScript calls a function of lib.vx with the same name as one of its own.
+const importingScript = `import "lib.vx" as lib;
+fn f(n) {
+    return lib.f(n);
+}
+dec a = f(1);`
+
+// clock returns a clock that advances a millisecond each time it's read.
+func clock() func() time.Time {
+	now := time.Unix(0, 0)
+	return func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+}
+
+// profile runs a script in a profiler (with the fake clock), returning its profile. The files it imports are
+// written in a temporary directory (by their names).
+func profile(t *testing.T, src string, files map[string]string) *profiler.Profile {
+	dir := t.TempDir()
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		assert.NoError(t, err)
+	}
+
+	p := profiler.New(clock())
+	runtime := vetryx.NewRuntime(vetryx.WithProfiler(p), vetryx.WithBaseDir(dir))
+	program, err := runtime.Compile(src)
+	assert.NoError(t, err)
+
+	err = runtime.Run(context.Background(), program)
+	assert.NoError(t, err)
+	return p.Profile()
+}
+
+func TestProfile(t *testing.T) {
+	tests := map[string]struct {
+		src               string
+		files             map[string]string
+		expectedDuration  time.Duration
+		expectedFunctions []profiler.FunctionStats
+		expectedLines     []profiler.LineStats
+	}{
+		"calls": {
+			src:              script,
+			expectedDuration: 9 * time.Millisecond,
+			expectedFunctions: []profiler.FunctionStats{
+				{Name: "<script>", Calls: 1, Self: 5 * time.Millisecond, Total: 9 * time.Millisecond},
+				{Name: "double", Calls: 2, Self: 4 * time.Millisecond, Total: 4 * time.Millisecond},
+			},
+			expectedLines: []profiler.LineStats{
+				{Line: 0, Function: "double", Hits: 0, Self: 2 * time.Millisecond},
+				{Line: 2, Function: "double", Hits: 2, Self: 2 * time.Millisecond},
+				{Line: 4, Function: "<script>", Hits: 1, Self: 2 * time.Millisecond},
+				{Line: 5, Function: "<script>", Hits: 1, Self: 2 * time.Millisecond},
+				{Line: 1, Function: "<script>", Hits: 1, Self: time.Millisecond},
+			},
+		},
+		"the recursive calls are not counted twice in the total": {
+			src:              "fn down(n) {\n  if n > 0 { down(n - 1); }\n}\ndown(2);",
+			expectedDuration: 13 * time.Millisecond,
+			expectedFunctions: []profiler.FunctionStats{
+				{Name: "down", Calls: 3, Self: 10 * time.Millisecond, Total: 10 * time.Millisecond},
+				{Name: "<script>", Calls: 1, Self: 3 * time.Millisecond, Total: 13 * time.Millisecond},
+			},
+			expectedLines: []profiler.LineStats{
+				{Line: 2, Function: "down", Hits: 5, Self: 7 * time.Millisecond},
+				{Line: 0, Function: "down", Hits: 0, Self: 3 * time.Millisecond},
+				{Line: 4, Function: "<script>", Hits: 1, Self: 2 * time.Millisecond},
+				{Line: 1, Function: "<script>", Hits: 1, Self: time.Millisecond},
+			},
+		},
+		"the natives are profiled": {
+			src:              `dec a = len("abc");`,
+			expectedDuration: 3 * time.Millisecond,
+			expectedFunctions: []profiler.FunctionStats{
+				{Name: "<script>", Calls: 1, Self: 2 * time.Millisecond, Total: 3 * time.Millisecond},
+				{Name: "len", Calls: 1, Self: time.Millisecond, Total: time.Millisecond},
+			},
+			expectedLines: []profiler.LineStats{
+				{Line: 1, Function: "<script>", Hits: 1, Self: 2 * time.Millisecond},
+				{Line: 0, Function: "len", Hits: 0, Self: time.Millisecond},
+			},
+		},
+		"the imported files are profiled, and their functions are told apart": {
+			src:              importingScript,
+			files:            map[string]string{"lib.vx": "fn f(n) {\n    return n + 1;\n}"},
+			expectedDuration: 12 * time.Millisecond,
+			expectedFunctions: []profiler.FunctionStats{
+				{Name: "<script>", Calls: 1, Self: 5 * time.Millisecond, Total: 12 * time.Millisecond},
+				{Name: "f", Calls: 1, Self: 3 * time.Millisecond, Total: 5 * time.Millisecond},
+				{Name: "<module>", File: "lib.vx", Calls: 1, Self: 2 * time.Millisecond, Total: 2 * time.Millisecond},
+				{Name: "f", File: "lib.vx", Calls: 1, Self: 2 * time.Millisecond, Total: 2 * time.Millisecond},
+			},
+			expectedLines: []profiler.LineStats{
+				{Line: 1, Function: "<script>", Hits: 1, Self: 2 * time.Millisecond},
+				{Line: 3, Function: "f", Hits: 1, Self: 2 * time.Millisecond},
+				{Line: 5, Function: "<script>", Hits: 1, Self: 2 * time.Millisecond},
+				{Line: 0, Function: "<module>", File: "lib.vx", Hits: 0, Self: time.Millisecond},
+				{Line: 0, Function: "f", Hits: 0, Self: time.Millisecond},
+				{Line: 0, Function: "f", File: "lib.vx", Hits: 0, Self: time.Millisecond},
+				{Line: 1, Function: "<module>", File: "lib.vx", Hits: 1, Self: time.Millisecond},
+				{Line: 2, Function: "<script>", Hits: 1, Self: time.Millisecond},
+				{Line: 2, Function: "f", File: "lib.vx", Hits: 1, Self: time.Millisecond},
+			},
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			profile := profile(t, test.src, test.files)
+			assert.Equal(t, test.expectedDuration, profile.Duration)
+			assert.Equal(t, test.expectedFunctions, profile.Functions)
+			assert.Equal(t, test.expectedLines, profile.Lines)
+
+			// the lines add up to the self time of the functions
+			var functions, lines time.Duration
+			for _, function := range profile.Functions {
+				functions += function.Self
+			}
+			for _, line := range profile.Lines {
+				lines += line.Self
+			}
+			assert.Equal(t, functions, lines)
+			assert.Equal(t, profile.Duration, lines)
+		})
+	}
+}
+
+func TestWriteTop(t *testing.T) {
+	var out bytes.Buffer
+	err := profile(t, script, nil).WriteTop(&out, 3, script)
+	assert.NoError(t, err)
+
+	expected := `total time: 9.000ms
+
+functions (top 3 by self time):
+        self   self%        total  total%     calls  function
+     5.000ms  55.56%      9.000ms 100.00%         1  <script>
+     4.000ms  44.44%      4.000ms  44.44%         2  double
+
+lines (top 3 by self time):
+        self   self%      hits   line  function
+     2.000ms  22.22%         0      -  double: <call overhead>
+     2.000ms  22.22%         2      2  double: return n * 2;
+     2.000ms  22.22%         1      4  <script>: dec a = double(1);
+`
+	assert.Equal(t, expected, out.String())
+}
+
+func TestWritePprof(t *testing.T) {
+	var out bytes.Buffer
+	err := profile(t, script, nil).WritePprof(&out, "script.vx")
+	assert.NoError(t, err)
+
+	reader, err := gzip.NewReader(&out)
+	assert.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+
+	// the string table has the types of the samples, the names of the functions and the name of the file
+	for _, s := range []string{"hits", "count", "time", "nanoseconds", "[script]", "double", "script.vx"} {
+		assert.Contains(t, string(data), s)
+	}
+}
+
+func TestWriteTopOfImportedFiles(t *testing.T) {
+	var out bytes.Buffer
+	err := profile(t, importingScript, map[string]string{"lib.vx": "fn f(n) {\n    return n + 1;\n}"}).WriteTop(&out, 4, importingScript)
+	assert.NoError(t, err)
+
+	expected := `total time: 12.000ms
+
+functions (top 4 by self time):
+        self   self%        total  total%     calls  function
+     5.000ms  41.67%     12.000ms 100.00%         1  <script>
+     3.000ms  25.00%      5.000ms  41.67%         1  f
+     2.000ms  16.67%      2.000ms  16.67%         1  <module> (lib.vx)
+     2.000ms  16.67%      2.000ms  16.67%         1  f (lib.vx)
+
+lines (top 4 by self time):
+        self   self%      hits   line  function
+     2.000ms  16.67%         1      1  <script>: import "lib.vx" as lib;
+     2.000ms  16.67%         1      3  f: return lib.f(n);
+     2.000ms  16.67%         1      5  <script>: dec a = f(1);
+     1.000ms   8.33%         0      -  <module> (lib.vx): <call overhead>
+`
+	assert.Equal(t, expected, out.String())
+}
@@ -0,0 +1,59 @@
+package profiler
+
+import "bytes"
+
+// The wire types of the fields of the protocol buffers.
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// protobuf is an encoded protocol buffer message, where the fields are appended (only the types used by the
+// pprof format are supported). Like in the protocol buffers, the fields with zero values are omitted.
+type protobuf struct {
+	bytes.Buffer
+}
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		b.WriteByte(byte(x) | 0x80)
+		x >>= 7
+	}
+	b.WriteByte(byte(x))
+}
+
+func (b *protobuf) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protobuf) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protobuf) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+// bytes appends a field with bytes (or a string), even when they are empty (eg: the first string of a table).
+func (b *protobuf) bytes(field int, data []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
+
+func (b *protobuf) message(field int, message *protobuf) {
+	b.bytes(field, message.Bytes())
+}
+
+// packed appends a repeated field of numbers, in the packed encoding.
+func (b *protobuf) packed(field int, xs []uint64) {
+	var packed protobuf
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.bytes(field, packed.Bytes())
+}
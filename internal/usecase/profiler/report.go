@@ -0,0 +1,74 @@
+package profiler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// callOverhead is shown instead of the code in the line 0 of the functions (see LineStats).
+const callOverhead = "<call overhead>"
+
+// WriteTop writes a report with the functions and the lines where the most time was spent (up to top of each),
+// showing the code of the lines of the script (from its source, when given). The functions declared in imported
+// files are followed by the file.
+func (p *Profile) WriteTop(w io.Writer, top int, source string) error {
+	code := strings.Split(source, "\n")
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "total time: %s\n", milliseconds(p.Duration))
+
+	fmt.Fprintf(b, "\nfunctions (top %d by self time):\n", top)
+	fmt.Fprintf(b, "%12s %7s %12s %7s %9s  %s\n", "self", "self%", "total", "total%", "calls", "function")
+	for i, function := range p.Functions {
+		if i == top {
+			break
+		}
+		fmt.Fprintf(b, "%12s %7s %12s %7s %9d  %s\n",
+			milliseconds(function.Self), p.percentage(function.Self),
+			milliseconds(function.Total), p.percentage(function.Total),
+			function.Calls, qualifiedName(function.Name, function.File))
+	}
+
+	fmt.Fprintf(b, "\nlines (top %d by self time):\n", top)
+	fmt.Fprintf(b, "%12s %7s %9s %6s  %s\n", "self", "self%", "hits", "line", "function")
+	for i, line := range p.Lines {
+		if i == top {
+			break
+		}
+		if line.Line == 0 {
+			fmt.Fprintf(b, "%12s %7s %9d %6s  %s: %s\n", milliseconds(line.Self), p.percentage(line.Self), line.Hits, "-", qualifiedName(line.Function, line.File), callOverhead)
+			continue
+		}
+
+		fmt.Fprintf(b, "%12s %7s %9d %6d  %s", milliseconds(line.Self), p.percentage(line.Self), line.Hits, line.Line, qualifiedName(line.Function, line.File))
+		if line.Line <= len(code) && source != "" && line.File == "" {
+			fmt.Fprintf(b, ": %s", strings.TrimSpace(code[line.Line-1]))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.Flush()
+}
+
+// qualifiedName returns the name of a function followed by the imported file where it was declared (if any).
+func qualifiedName(function string, file string) string {
+	if file == "" {
+		return function
+	}
+	return fmt.Sprintf("%s (%s)", function, file)
+}
+
+// percentage returns the percentage of the total time of the profile represented by a duration.
+func (p *Profile) percentage(d time.Duration) string {
+	if p.Duration == 0 {
+		return "0.00%"
+	}
+	return fmt.Sprintf("%.2f%%", float64(d)/float64(p.Duration)*100)
+}
+
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.3fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"fmt"
+
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+)
+
+// LexingErr represents the errors found during the scanning (all of them, since the scanner recovers from each one).
+type LexingErr struct {
+	errs     []error
+	spans    []token.Span // the code where each error was found
+	excerpts []string     // the excerpt of the source code where each error was found (see WithExcerpts)
+}
+
+// NewLexingErr is a constructor for a lexing error.
+func NewLexingErr(errs []error) *LexingErr {
+	return &LexingErr{
+		errs: errs,
+	}
+}
+
+// WithExcerpts sets the excerpts of the source code where the errors were found (so they are shown under each error).
+func (l *LexingErr) WithExcerpts(source string) *LexingErr {
+	l.excerpts = make([]string, len(l.spans))
+	for i, span := range l.spans {
+		l.excerpts[i] = interr.Excerpt(source, span)
+	}
+	return l
+}
+
+// Errors returns each one of the errors found.
+func (l *LexingErr) Errors() []error {
+	return l.errs
+}
+
+// Spans returns the span of the code where each error was found.
+func (l *LexingErr) Spans() []token.Span {
+	return l.spans
+}
+
+func (l *LexingErr) Error() string {
+	var message string
+
+	for i, err := range l.errs {
+		message = message + fmt.Sprintf("error #%d: %s \n", i+1, err.Error())
+		if i < len(l.excerpts) && l.excerpts[i] != "" {
+			message = message + l.excerpts[i] + "\n"
+		}
+	}
+
+	return message
+}
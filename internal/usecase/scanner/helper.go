@@ -6,6 +6,8 @@ var singleChars = map[rune]bool{
 	')': true,
 	'{': true,
 	'}': true,
+	'[': true,
+	']': true,
 	',': true,
 	'.': true,
 	'-': true,
@@ -26,7 +28,7 @@ var matchableChars = map[rune]bool{
 	'>': true, // can be matched with "=" to form "greater or equal"
 	'&': true, // can be matched with "&" to form AND operator
 	'|': true, // can be matched with "|" to form OR operator
-	':': true, // can be matched with "=" to form var short declarator
+	':': true, // can be matched with "=" to form var short declarator (alone, is used in map literals)
 }
 
 // ignorableChars are characters that can be ignored by the scanner.
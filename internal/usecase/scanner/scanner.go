@@ -3,6 +3,7 @@ package scanner
 import (
 	"fmt"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/avazquezcode/govetryx/internal/domain/token"
 )
@@ -14,6 +15,17 @@ type Scanner struct {
 	start      int // represents the position where we start scanning a token.
 	current    int // indicates the "pointer" position that moves forward during a token scan.
 	line       int // indicates the line where we are standing on, during the scanning.
+	column     int // indicates the column of the current pointer (in chars).
+	offset     int // indicates the offset of the current pointer (in bytes).
+
+	startPosition token.Position // position where the token being scanned starts.
+
+	// comments found during the scanning (they are not part of the tokens, since the parser ignores them).
+	comments []*token.Token
+
+	// errors found during the scanning, and the span of the code where each one was found.
+	errs  []error
+	spans []token.Span
 }
 
 // NewScanner is a constructor for a Scanner.
@@ -21,24 +33,42 @@ func NewScanner(sourceCode []rune) *Scanner {
 	return &Scanner{
 		sourceCode: sourceCode,
 		line:       1, // starts at line 1
+		column:     1, // starts at column 1
 	}
 }
 
 // Scan is the main method of the scanner.
 // It scans all the tokens from a given sourceCode code.
+// The invalid code is scanned as Illegal tokens, and the scanning goes on after it, so all the errors are returned
+// together (in a LexingErr), along with the tokens.
 func (s *Scanner) Scan() ([]*token.Token, error) {
 	for !s.isEnd() {
 		s.start = s.current
+		s.startPosition = s.position()
 		if err := s.scanToken(); err != nil {
-			return nil, fmt.Errorf("failed on lexer layer, while scanning line %d, with error: %w", s.line, err)
+			s.addToken(token.Illegal, nil)
+			s.errs = append(s.errs, fmt.Errorf("line %d, column %d: %w", s.startPosition.Line, s.startPosition.Column, err))
+			s.spans = append(s.spans, s.previousToken().Span)
 		}
 	}
 
 	// Add EOF
-	s.tokens = append(s.tokens, token.NewToken(token.EOF, "", nil, s.line))
+	s.startPosition = s.position()
+	s.tokens = append(s.tokens, s.newToken(token.EOF, "", nil))
+
+	if s.errs != nil {
+		lexingErr := NewLexingErr(s.errs)
+		lexingErr.spans = s.spans
+		return s.tokens, lexingErr
+	}
 	return s.tokens, nil
 }
 
+// Comments returns the comments found by Scan (as Hashtag tokens, whose lexeme is the whole comment), in order.
+func (s *Scanner) Comments() []*token.Token {
+	return s.comments
+}
+
 // scanToken scans the current token.
 func (s *Scanner) scanToken() error {
 	char := s.consume()
@@ -53,8 +83,7 @@ func (s *Scanner) scanToken() error {
 	}
 
 	if _, ok := matchableChars[char]; ok {
-		s.scanMatchableChars(char)
-		return nil
+		return s.scanMatchableChars(char)
 	}
 
 	if char == '\n' {
@@ -84,7 +113,7 @@ func (s *Scanner) scanToken() error {
 		return nil
 	}
 
-	return fmt.Errorf("unexpected char")
+	return fmt.Errorf("unexpected char %q", char)
 }
 
 // scanSingleChar handle the scanning of single chars (simple logic).
@@ -98,8 +127,14 @@ func (s *Scanner) scanSingleChar(char rune) {
 		s.addToken(token.LeftParentheses, nil)
 	case ')':
 		s.addToken(token.RightParentheses, nil)
+	case '[':
+		s.addToken(token.LeftBracket, nil)
+	case ']':
+		s.addToken(token.RightBracket, nil)
 	case ',':
 		s.addToken(token.Comma, nil)
+	case '.':
+		s.addToken(token.Dot, nil)
 	case '+':
 		s.addToken(token.Plus, nil)
 	case '-':
@@ -117,59 +152,70 @@ func (s *Scanner) scanSingleChar(char rune) {
 		for s.peek() != '\n' && !s.isEnd() {
 			s.increment() // skip everything until the comment ends
 		}
+		comment := string(s.sourceCode[s.start:s.current])
+		s.comments = append(s.comments, s.newToken(token.Hashtag, comment, nil))
 	case ';':
 		s.addToken(token.Semicolon, nil)
 	}
 }
 
 // scanMatchableChars handle the scanning of chars that can be matched with successive chars to form a composite token.
-func (s *Scanner) scanMatchableChars(char rune) {
+func (s *Scanner) scanMatchableChars(char rune) error {
 	switch char {
 	case '<':
 		if s.is('=') {
 			s.increment()
 			s.addToken(token.LowerOrEqual, nil)
-			return
+			return nil
 		}
 		if s.is('>') {
 			s.increment()
 			s.addToken(token.NotEqual, nil)
-			return
+			return nil
 		}
 		s.addToken(token.Lower, nil)
 	case '>':
 		if s.is('=') {
 			s.increment()
 			s.addToken(token.GreaterOrEqual, nil)
-			return
+			return nil
 		}
 		s.addToken(token.Greater, nil)
 	case '=':
 		if s.is('=') {
 			s.increment()
 			s.addToken(token.EqualEqual, nil)
-			return
+			return nil
+		}
+		if s.is('>') {
+			s.increment()
+			s.addToken(token.Arrow, nil)
+			return nil
 		}
 		s.addToken(token.Equal, nil)
 	case '|':
 		if s.is('|') {
 			s.increment()
 			s.addToken(token.Or, nil)
-			return
+			return nil
 		}
+		return fmt.Errorf("unexpected char '|' (the or operator is '||')")
 	case '&':
 		if s.is('&') {
 			s.increment()
 			s.addToken(token.And, nil)
-			return
+			return nil
 		}
+		return fmt.Errorf("unexpected char '&' (the and operator is '&&')")
 	case ':':
 		if s.is('=') {
 			s.increment()
 			s.addToken(token.VarShortDeclarator, nil)
-			return
+			return nil
 		}
+		s.addToken(token.Colon, nil)
 	}
+	return nil
 }
 
 // scanNewLine handles the scan of new lines.
@@ -184,8 +230,19 @@ func (s *Scanner) scanNewLine() {
 // addToken creates a new token, and adds it to the tokens list.
 func (s *Scanner) addToken(tokenType token.Type, literal interface{}) {
 	lexeme := string(s.sourceCode[s.start:s.current])
-	token := token.NewToken(tokenType, lexeme, literal, s.line)
-	s.tokens = append(s.tokens, token)
+	s.tokens = append(s.tokens, s.newToken(tokenType, lexeme, literal))
+}
+
+// newToken creates a new token, that spans from the start of the token being scanned to the current pointer.
+func (s *Scanner) newToken(tokenType token.Type, lexeme string, literal interface{}) *token.Token {
+	t := token.NewToken(tokenType, lexeme, literal, s.line)
+	t.Span = token.Span{Start: s.startPosition, End: s.position()}
+	return t
+}
+
+// position returns the position of the current pointer.
+func (s *Scanner) position() token.Position {
+	return token.Position{Line: s.line, Column: s.column, Offset: s.offset}
 }
 
 // scanString handles the scanning of a string.
@@ -215,6 +272,7 @@ func (s *Scanner) scanNumber() error {
 
 	// check if is valid float
 	if s.peek() == '.' && !isDigit(s.peekNext()) {
+		s.increment() // the "." is part of the invalid number
 		return fmt.Errorf("the number is invalid")
 	}
 
@@ -275,9 +333,15 @@ func (s *Scanner) previous() rune {
 	return s.sourceCode[s.current-1]
 }
 
-// increment moves the current pointer of the scanner forward.
+// increment moves the current pointer of the scanner forward (keeping track of its column and offset).
 func (s *Scanner) increment() {
+	char := s.sourceCode[s.current]
 	s.current++
+	s.offset += utf8.RuneLen(char)
+	s.column++
+	if char == '\n' {
+		s.column = 1
+	}
 }
 
 // is returns true if the current char (rune) matches with an expected char.
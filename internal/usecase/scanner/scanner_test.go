@@ -50,6 +50,14 @@ func TestScan(t *testing.T) {
 				token.NewToken(token.EOF, "", nil, 1),
 			},
 		},
+		"brackets": {
+			src: "[]",
+			expected: []*token.Token{
+				token.NewToken(token.LeftBracket, "[", nil, 1),
+				token.NewToken(token.RightBracket, "]", nil, 1),
+				token.NewToken(token.EOF, "", nil, 1),
+			},
+		},
 		"arithmetic operators": {
 			src: "+-*/%",
 			expected: []*token.Token{
@@ -125,6 +133,29 @@ func TestScan(t *testing.T) {
 			},
 		},
 
+		"dot": {
+			src: "a.b",
+			expected: []*token.Token{
+				token.NewToken(token.Identifier, "a", nil, 1),
+				token.NewToken(token.Dot, ".", nil, 1),
+				token.NewToken(token.Identifier, "b", nil, 1),
+				token.NewToken(token.EOF, "", nil, 1),
+			},
+		},
+		"arrow": {
+			src: "=>",
+			expected: []*token.Token{
+				token.NewToken(token.Arrow, "=>", nil, 1),
+				token.NewToken(token.EOF, "", nil, 1),
+			},
+		},
+		"colon": {
+			src: ":",
+			expected: []*token.Token{
+				token.NewToken(token.Colon, ":", nil, 1),
+				token.NewToken(token.EOF, "", nil, 1),
+			},
+		},
 		"short variable declarator": {
 			src: ":=",
 			expected: []*token.Token{
@@ -202,7 +233,7 @@ func TestScan(t *testing.T) {
 			},
 		},
 		"reserved words": {
-			src: `dec fn true false if else while print return null break continue`,
+			src: `dec fn true false if else while for in print return null break continue class this super import as throw try catch finally`,
 			expected: []*token.Token{
 				token.NewToken(token.VarDeclarator, "dec", nil, 1),
 				token.NewToken(token.Fn, "fn", nil, 1),
@@ -211,11 +242,22 @@ func TestScan(t *testing.T) {
 				token.NewToken(token.If, "if", nil, 1),
 				token.NewToken(token.Else, "else", nil, 1),
 				token.NewToken(token.While, "while", nil, 1),
+				token.NewToken(token.For, "for", nil, 1),
+				token.NewToken(token.In, "in", nil, 1),
 				token.NewToken(token.Print, "print", nil, 1),
 				token.NewToken(token.Return, "return", nil, 1),
 				token.NewToken(token.Null, "null", nil, 1),
 				token.NewToken(token.Break, "break", nil, 1),
 				token.NewToken(token.Continue, "continue", nil, 1),
+				token.NewToken(token.Class, "class", nil, 1),
+				token.NewToken(token.This, "this", nil, 1),
+				token.NewToken(token.Super, "super", nil, 1),
+				token.NewToken(token.Import, "import", nil, 1),
+				token.NewToken(token.As, "as", nil, 1),
+				token.NewToken(token.Throw, "throw", nil, 1),
+				token.NewToken(token.Try, "try", nil, 1),
+				token.NewToken(token.Catch, "catch", nil, 1),
+				token.NewToken(token.Finally, "finally", nil, 1),
 				token.NewToken(token.EOF, "", nil, 1),
 			},
 		},
@@ -223,6 +265,14 @@ func TestScan(t *testing.T) {
 			src:         `?`,
 			expectedErr: true,
 		},
+		"single and": {
+			src:         `a & b`,
+			expectedErr: true,
+		},
+		"single or": {
+			src:         `a | b`,
+			expectedErr: true,
+		},
 		"string that starts but doesn't end": {
 			src:         `"`,
 			expectedErr: true,
@@ -247,12 +297,95 @@ func TestScan(t *testing.T) {
 				return
 			}
 
-			assert.Equal(t, test.expected, tokens)
+			assert.Equal(t, test.expected, withoutSpans(tokens)) // the spans are checked by TestScanTracksTheSpans
 			assert.Nil(t, err)
 		})
 	}
 }
 
+func TestScanKeepsTheComments(t *testing.T) {
+	scanner := NewScanner(bytes.Runes(strToBytes("# first\nprint 1; # second\n\n#third")))
+	tokens, err := scanner.Scan()
+	assert.Nil(t, err)
+	assert.Len(t, tokens, 4) // the comments are not part of the tokens
+
+	expected := []*token.Token{
+		token.NewToken(token.Hashtag, "# first", nil, 1),
+		token.NewToken(token.Hashtag, "# second", nil, 2),
+		token.NewToken(token.Hashtag, "#third", nil, 4),
+	}
+	assert.Equal(t, expected, withoutSpans(scanner.Comments()))
+}
+
+func TestScanRecoversFromTheErrors(t *testing.T) {
+	scanner := NewScanner(bytes.Runes(strToBytes("a & b;\n? 1. | c;\nprint \"open")))
+	tokens, err := scanner.Scan()
+
+	expected := []*token.Token{
+		token.NewToken(token.Identifier, "a", nil, 1),
+		token.NewToken(token.Illegal, "&", nil, 1),
+		token.NewToken(token.Identifier, "b", nil, 1),
+		token.NewToken(token.Semicolon, ";", nil, 1),
+		token.NewToken(token.Illegal, "?", nil, 2),
+		token.NewToken(token.Illegal, "1.", nil, 2),
+		token.NewToken(token.Illegal, "|", nil, 2),
+		token.NewToken(token.Identifier, "c", nil, 2),
+		token.NewToken(token.Semicolon, ";", nil, 2),
+		token.NewToken(token.Print, "print", nil, 3),
+		token.NewToken(token.Illegal, "\"open", nil, 3),
+		token.NewToken(token.EOF, "", nil, 3),
+	}
+	assert.Equal(t, expected, withoutSpans(tokens))
+
+	var lexingErr *LexingErr
+	assert.ErrorAs(t, err, &lexingErr)
+	assert.Equal(t, "error #1: line 1, column 3: unexpected char '&' (the and operator is '&&') \n"+
+		"error #2: line 2, column 1: unexpected char '?' \n"+
+		"error #3: line 2, column 3: failed to scan number with err: the number is invalid \n"+
+		"error #4: line 2, column 6: unexpected char '|' (the or operator is '||') \n"+
+		"error #5: line 3, column 7: failed to scan string with err: missing quotes to close the string \n", err.Error())
+
+	lexingErr.WithExcerpts("a & b;\n? 1. | c;\nprint \"open")
+	assert.Contains(t, lexingErr.Error(), "error #3: line 2, column 3: failed to scan number with err: the number is invalid \n2 | ? 1. | c;\n  |   ^^\n")
+}
+
+func TestScanTracksTheSpans(t *testing.T) {
+	scanner := NewScanner(bytes.Runes(strToBytes("a <= \"ñ\";\n\t# c\n  12.5")))
+	tokens, err := scanner.Scan()
+	assert.Nil(t, err)
+
+	span := func(startLine, startColumn, startOffset, endLine, endColumn, endOffset int) token.Span {
+		return token.Span{
+			Start: token.Position{Line: startLine, Column: startColumn, Offset: startOffset},
+			End:   token.Position{Line: endLine, Column: endColumn, Offset: endOffset},
+		}
+	}
+
+	expected := []token.Span{
+		span(1, 1, 0, 1, 2, 1),   // a
+		span(1, 3, 2, 1, 5, 4),   // <=
+		span(1, 6, 5, 1, 9, 9),   // "ñ" (the ñ takes 2 bytes)
+		span(1, 9, 9, 1, 10, 10), // ;
+		span(3, 3, 18, 3, 7, 22), // 12.5
+		span(3, 7, 22, 3, 7, 22), // EOF
+	}
+	for i, tok := range tokens {
+		assert.Equal(t, expected[i], tok.Span, tok.Lexeme)
+	}
+	assert.Equal(t, span(2, 2, 12, 2, 5, 15), scanner.Comments()[0].Span)
+}
+
+// withoutSpans returns copies of the tokens without their spans.
+func withoutSpans(tokens []*token.Token) []*token.Token {
+	var copies []*token.Token
+	for _, t := range tokens {
+		copied := *t
+		copied.Span = token.Span{}
+		copies = append(copies, &copied)
+	}
+	return copies
+}
+
 func strToBytes(str string) []byte {
 	return []byte(str)
 }
@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"fmt"
+
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+)
+
+// call calls a value with the arguments on top of the stack.
+// Functions, methods and classes are called in a new frame (that starts in the slot of the callee), while the
+// natives are called right away (replacing the callee and the arguments with the result).
+func (vm *VM) call(callee interface{}, argc int, line int) error {
+	var closure *Closure
+	var name string
+	base := len(vm.stack) - argc - 1
+
+	switch callee := callee.(type) {
+	case *Closure:
+		if err := interpreter.CheckArity(callee.Name(), callee.Arity(), argc); err != nil {
+			return err
+		}
+		closure, name = callee, callee.Name()
+	case *BoundMethod:
+		if err := interpreter.CheckArity(callee.Name(), callee.Arity(), argc); err != nil {
+			return err
+		}
+		closure, name = callee.method, callee.Name()
+		vm.stack[base] = callee.receiver
+	case *Class:
+		if err := interpreter.CheckArity(callee.Name(), callee.Arity(), argc); err != nil {
+			return err
+		}
+		instance := &Instance{class: callee, fields: map[string]interface{}{}}
+		initializer, ok := callee.FindMethod(initializerName)
+		if !ok {
+			vm.stack = vm.stack[:base]
+			vm.push(instance)
+			return nil
+		}
+		closure, name = initializer, callee.Name()
+		vm.stack[base] = instance
+	case interpreter.Native:
+		if err := interpreter.CheckArity(callee.Name(), callee.Arity(), argc); err != nil {
+			return err
+		}
+		if err := vm.checkContext(line); err != nil {
+			return err
+		}
+		arguments := make([]interface{}, argc)
+		copy(arguments, vm.stack[base+1:])
+		result, err := callee.Call(vm, arguments)
+		if err != nil {
+			return err
+		}
+		vm.stack = vm.stack[:base]
+		vm.push(result)
+		return nil
+	default:
+		return fmt.Errorf("tried to call a non-function")
+	}
+
+	if err := vm.checkContext(line); err != nil {
+		return err
+	}
+
+	if vm.limits.MaxCallDepth > 0 && len(vm.frames)-1 >= vm.limits.MaxCallDepth {
+		return fmt.Errorf("%w (%d)", interr.ErrCallDepthExceeded, vm.limits.MaxCallDepth)
+	}
+
+	if err := vm.checkBindings(0); err != nil {
+		return err
+	}
+
+	vm.frames = append(vm.frames, frame{closure: closure, name: name, base: base})
+	return nil
+}
+
+// checkBindings fails if the bindings (the globals of the main module and the values in the stack), plus the
+// given quantity of new bindings, exceed the max bindings limit.
+// The stack has the temporary values of the expressions too (eg: the operands and the arguments of the calls), so
+// the limit is approximate, and lower than in the interpreter (that only counts the declared bindings).
+func (vm *VM) checkBindings(bindings int) error {
+	if vm.limits.MaxBindings <= 0 {
+		return nil
+	}
+
+	if len(vm.main.globals)+len(vm.stack)+bindings > vm.limits.MaxBindings {
+		return fmt.Errorf("%w (%d)", interr.ErrBindingsLimitExceeded, vm.limits.MaxBindings)
+	}
+	return nil
+}
+
+// checkConcat fails if the concatenation of two strings exceeds the max string length limit.
+func (vm *VM) checkConcat(left interface{}, right interface{}) error {
+	if vm.limits.MaxStringLength <= 0 {
+		return nil
+	}
+
+	l, isString := left.(string)
+	if !isString {
+		return nil
+	}
+
+	r, isString := right.(string)
+	if !isString {
+		return nil
+	}
+
+	if len(l)+len(r) > vm.limits.MaxStringLength {
+		return fmt.Errorf("%w (%d)", interr.ErrStringTooLong, vm.limits.MaxStringLength)
+	}
+	return nil
+}
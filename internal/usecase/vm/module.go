@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/avazquezcode/govetryx/internal/usecase/compiler"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+)
+
+// Module is the runtime representation of an imported file.
+// Its members are the top-level declarations of the file.
+type Module struct {
+	name    string // path used in the import
+	globals map[string]interface{}
+}
+
+func newModule(name string) *Module {
+	return &Module{
+		name:    name,
+		globals: map[string]interface{}{},
+	}
+}
+
+// Get returns the value of a top-level declaration of the module.
+func (m *Module) Get(name string) (interface{}, error) {
+	value, exists := m.globals[name]
+	if !exists {
+		return nil, fmt.Errorf("the module %q has no member %q", m.name, name)
+	}
+	return value, nil
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
+
+// SetBaseDir sets the directory used to resolve the relative imports (by default, the working directory).
+func (vm *VM) SetBaseDir(dir string) {
+	vm.modules.SetBaseDir(dir)
+}
+
+// SetMainFile sets the file of the program being run, so the imports of the file itself (from the modules it
+// imports) are reported as cyclic, instead of running the file again.
+func (vm *VM) SetMainFile(path string) {
+	vm.modules.SetMainFile(path)
+}
+
+// SetOptimizations sets whether the imported files are optimized (see the optimizer package) before being resolved.
+func (vm *VM) SetOptimizations(enabled bool) {
+	vm.modules.SetOptimizations(enabled)
+}
+
+// importModule imports a module, returning the error of the import statement (if any).
+func (vm *VM) importModule(path string, line int) (*Module, error) {
+	return vm.modules.Import(path, line, func(absPath string) (*Module, error) {
+		return vm.loadModule(path, absPath)
+	})
+}
+
+// loadModule compiles and runs a file (with its own globals).
+func (vm *VM) loadModule(name string, path string) (*Module, error) {
+	statements, err := vm.modules.Parse(path, interpreter.NewResolver(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := compiler.Compile(statements)
+	if err != nil {
+		return nil, fmt.Errorf("failed compiling the statements: %w", err)
+	}
+
+	module := newModule(name)
+	_, err = vm.runMain(&Closure{function: program.Main(), program: program, module: module})
+	if err != nil {
+		return nil, err
+	}
+
+	return module, nil
+}
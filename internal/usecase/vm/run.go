@@ -0,0 +1,501 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	"github.com/avazquezcode/govetryx/internal/domain/corerule"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/evaluator"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+)
+
+// operators maps the instructions of the operators into their tokens (used to evaluate the operations
+// that are not between numbers, with the same rules of the interpreter).
+var operators = map[bytecode.OpCode]*token.Token{
+	bytecode.OpEqual:          token.NewToken(token.EqualEqual, "==", nil, 0),
+	bytecode.OpNotEqual:       token.NewToken(token.NotEqual, "!=", nil, 0),
+	bytecode.OpGreater:        token.NewToken(token.Greater, ">", nil, 0),
+	bytecode.OpGreaterOrEqual: token.NewToken(token.GreaterOrEqual, ">=", nil, 0),
+	bytecode.OpLower:          token.NewToken(token.Lower, "<", nil, 0),
+	bytecode.OpLowerOrEqual:   token.NewToken(token.LowerOrEqual, "<=", nil, 0),
+	bytecode.OpAdd:            token.NewToken(token.Plus, "+", nil, 0),
+	bytecode.OpSubtract:       token.NewToken(token.Minus, "-", nil, 0),
+	bytecode.OpMultiply:       token.NewToken(token.Star, "*", nil, 0),
+	bytecode.OpDivide:         token.NewToken(token.Slash, "/", nil, 0),
+	bytecode.OpModulus:        token.NewToken(token.Modulus, "%", nil, 0),
+	bytecode.OpNot:            token.NewToken(token.Bang, "!", nil, 0),
+	bytecode.OpNegate:         token.NewToken(token.Minus, "-", nil, 0),
+}
+
+// execute runs the instructions of the current frame (and the ones of the frames it calls), until the base frame
+// returns or an error happens.
+func (vm *VM) execute(baseFrame int) (interface{}, error) {
+	frame := &vm.frames[len(vm.frames)-1]
+	code, constants := frame.closure.function.Code, frame.closure.program.Constants
+	ip := frame.ip
+
+	// fail returns the error of the instruction that starts at the given offset (pointing to its file, line and span)
+	fail := func(err error, start int) error {
+		frame.ip = start
+		function := frame.closure.function
+		err = interr.Locate(interr.WrapRuntimeError(err, function.Lines[start]), function.Span(start))
+		return interr.InFile(err, frame.closure.module.name)
+	}
+
+	for {
+		start := ip
+		vm.steps++
+		if vm.limits.MaxSteps > 0 && vm.steps > vm.limits.MaxSteps {
+			return nil, fail(fmt.Errorf("%w (%d)", interr.ErrStepLimitExceeded, vm.limits.MaxSteps), start)
+		}
+
+		op := bytecode.OpCode(code[ip])
+		ip++
+
+		switch op {
+		case bytecode.OpConstant:
+			vm.push(constants[readUint16(code, ip)])
+			ip += 2
+		case bytecode.OpNull:
+			vm.push(nil)
+		case bytecode.OpTrue:
+			vm.push(true)
+		case bytecode.OpFalse:
+			vm.push(false)
+		case bytecode.OpPop:
+			vm.stack = vm.stack[:len(vm.stack)-1]
+
+		case bytecode.OpDefineGlobal:
+			name := constants[readUint16(code, ip)].(string)
+			ip += 2
+			globals := frame.closure.module.globals
+			if _, exists := globals[name]; !exists {
+				if err := vm.checkBindings(1); err != nil {
+					return nil, fail(err, start)
+				}
+			}
+			globals[name] = vm.pop()
+		case bytecode.OpGetGlobal:
+			name := constants[readUint16(code, ip)].(string)
+			ip += 2
+			value, err := vm.getGlobal(frame.closure.module, name)
+			if err != nil {
+				// like in the interpreter, reading an undefined variable fails without a line
+				frame.ip = start
+				return nil, err
+			}
+			vm.push(value)
+		case bytecode.OpSetGlobal:
+			name := constants[readUint16(code, ip)].(string)
+			ip += 2
+			err := vm.setGlobal(frame.closure.module, name, vm.peek(0))
+			if err != nil {
+				return nil, fail(err, start)
+			}
+		case bytecode.OpGetLocal:
+			vm.push(vm.stack[frame.base+int(code[ip])])
+			ip++
+		case bytecode.OpSetLocal:
+			vm.stack[frame.base+int(code[ip])] = vm.peek(0)
+			ip++
+		case bytecode.OpGetUpvalue:
+			vm.push(vm.getUpvalue(frame.closure.upvalues[code[ip]]))
+			ip++
+		case bytecode.OpSetUpvalue:
+			vm.setUpvalue(frame.closure.upvalues[code[ip]], vm.peek(0))
+			ip++
+		case bytecode.OpCloseUpvalue:
+			vm.closeUpvalues(len(vm.stack) - 1)
+			vm.stack = vm.stack[:len(vm.stack)-1]
+		case bytecode.OpCloseUpvalues:
+			vm.closeUpvalues(frame.base + int(code[ip]))
+			ip++
+
+		case bytecode.OpEqual:
+			right := vm.pop()
+			vm.stack[len(vm.stack)-1] = corerule.IsEqual(vm.peek(0), right)
+		case bytecode.OpNotEqual:
+			right := vm.pop()
+			vm.stack[len(vm.stack)-1] = !corerule.IsEqual(vm.peek(0), right)
+		case bytecode.OpGreater, bytecode.OpGreaterOrEqual, bytecode.OpLower, bytecode.OpLowerOrEqual,
+			bytecode.OpAdd, bytecode.OpSubtract, bytecode.OpMultiply, bytecode.OpDivide, bytecode.OpModulus:
+			right := vm.pop()
+			value, err := vm.binary(op, vm.peek(0), right)
+			if err != nil {
+				return nil, fail(err, start)
+			}
+			vm.stack[len(vm.stack)-1] = value
+		case bytecode.OpNot:
+			vm.stack[len(vm.stack)-1] = !corerule.IsTrue(vm.peek(0))
+		case bytecode.OpNegate:
+			number, isNumber := vm.peek(0).(float64)
+			if !isNumber {
+				_, err := evaluate(op, vm.peek(0), nil)
+				return nil, fail(err, start)
+			}
+			vm.stack[len(vm.stack)-1] = -number
+
+		case bytecode.OpPrint:
+			_, err := fmt.Fprintln(vm.stdout, corerule.PrintableValue(vm.pop()))
+			if err != nil {
+				return nil, fail(fmt.Errorf("failed when printing a value, with err: %w", err), start)
+			}
+		case bytecode.OpJump:
+			ip += 2 + readUint16(code, ip)
+		case bytecode.OpJumpIfFalse:
+			if !corerule.IsTrue(vm.peek(0)) {
+				ip += readUint16(code, ip)
+			}
+			ip += 2
+		case bytecode.OpLoop:
+			if err := vm.checkContext(frame.closure.function.Lines[start]); err != nil {
+				return nil, fail(err, start)
+			}
+			ip += 2 - readUint16(code, ip)
+
+		case bytecode.OpCall:
+			argc := int(code[ip])
+			frame.ip = ip + 1
+			err := vm.call(vm.peek(argc), argc, frame.closure.function.Lines[start])
+			if err != nil {
+				return nil, fail(err, start)
+			}
+			frame = &vm.frames[len(vm.frames)-1]
+			code, constants, ip = frame.closure.function.Code, frame.closure.program.Constants, frame.ip
+		case bytecode.OpClosure:
+			function := frame.closure.program.Functions[readUint16(code, ip)]
+			ip += 2
+			closure := &Closure{
+				function: function,
+				program:  frame.closure.program,
+				module:   frame.closure.module,
+				upvalues: make([]*Upvalue, len(function.Upvalues)),
+			}
+			for index, upvalue := range function.Upvalues {
+				if upvalue.IsLocal {
+					closure.upvalues[index] = vm.captureUpvalue(frame.base + upvalue.Index)
+				} else {
+					closure.upvalues[index] = frame.closure.upvalues[upvalue.Index]
+				}
+			}
+			vm.push(closure)
+		case bytecode.OpReturn:
+			result := vm.pop()
+			vm.closeUpvalues(frame.base)
+			for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frame == len(vm.frames)-1 {
+				vm.handlers = vm.handlers[:len(vm.handlers)-1]
+			}
+			vm.stack = vm.stack[:frame.base]
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == baseFrame {
+				return result, nil
+			}
+
+			vm.push(result)
+			frame = &vm.frames[len(vm.frames)-1]
+			code, constants, ip = frame.closure.function.Code, frame.closure.program.Constants, frame.ip
+		case bytecode.OpStoreResult:
+			frame.result = vm.pop()
+		case bytecode.OpLoadResult:
+			vm.push(frame.result)
+			frame.result = nil
+
+		case bytecode.OpList:
+			count := readUint16(code, ip)
+			ip += 2
+			elements := make([]interface{}, count)
+			copy(elements, vm.stack[len(vm.stack)-count:])
+			vm.stack = vm.stack[:len(vm.stack)-count]
+			vm.push(types.NewList(elements))
+		case bytecode.OpMap:
+			count := readUint16(code, ip)
+			ip += 2
+			m := types.NewMap()
+			entries := vm.stack[len(vm.stack)-2*count:]
+			for index := 0; index < len(entries); index += 2 {
+				if err := interpreter.ValidateMapKey(entries[index]); err != nil {
+					return nil, fail(err, start)
+				}
+				m.Set(entries[index], entries[index+1])
+			}
+			vm.stack = vm.stack[:len(vm.stack)-2*count]
+			vm.push(m)
+		case bytecode.OpGetIndex:
+			index := vm.pop()
+			value, err := getIndex(vm.peek(0), index)
+			if err != nil {
+				return nil, fail(err, start)
+			}
+			vm.stack[len(vm.stack)-1] = value
+		case bytecode.OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			err := setIndex(vm.peek(0), index, value)
+			if err != nil {
+				return nil, fail(err, start)
+			}
+			vm.stack[len(vm.stack)-1] = value
+		case bytecode.OpIterInit:
+			it, err := newIterator(vm.peek(0), int(code[ip]))
+			if err != nil {
+				return nil, fail(err, start)
+			}
+			ip++
+			vm.stack[len(vm.stack)-1] = it
+		case bytecode.OpIterNext:
+			it := vm.peek(0).(*iterator)
+			if it.next >= len(it.keys) {
+				ip += readUint16(code, ip)
+			} else {
+				switch {
+				case it.variables == 2:
+					vm.push(it.keys[it.next])
+					vm.push(it.values[it.next])
+				case it.isMap:
+					vm.push(it.keys[it.next])
+				default:
+					vm.push(it.values[it.next])
+				}
+				it.next++
+			}
+			ip += 2
+
+		case bytecode.OpClass:
+			name := constants[readUint16(code, ip)].(string)
+			class := &Class{name: name, methods: map[string]*Closure{}}
+			if code[ip+2] == 1 {
+				superclass, isClass := vm.pop().(*Class)
+				if !isClass {
+					return nil, fail(fmt.Errorf("the superclass of %q must be a class", name), start)
+				}
+				class.superclass = superclass
+			}
+			ip += 3
+			vm.push(class)
+		case bytecode.OpMethod:
+			name := constants[readUint16(code, ip)].(string)
+			ip += 2
+			method := vm.pop().(*Closure)
+			vm.peek(0).(*Class).methods[name] = method
+		case bytecode.OpGetProperty:
+			name := constants[readUint16(code, ip)].(string)
+			ip += 2
+			value, err := getProperty(vm.peek(0), name)
+			if err != nil {
+				return nil, fail(err, start)
+			}
+			vm.stack[len(vm.stack)-1] = value
+		case bytecode.OpSetProperty:
+			name := constants[readUint16(code, ip)].(string)
+			ip += 2
+			value := vm.pop()
+			instance, isInstance := vm.peek(0).(*Instance)
+			if !isInstance {
+				return nil, fail(fmt.Errorf("only instances have fields"), start)
+			}
+			instance.fields[name] = value
+			vm.stack[len(vm.stack)-1] = value
+		case bytecode.OpGetSuper:
+			name := constants[readUint16(code, ip)].(string)
+			ip += 2
+			superclass := vm.pop().(*Class)
+			method, ok := superclass.FindMethod(name)
+			if !ok {
+				return nil, fail(fmt.Errorf("undefined property %q", name), start)
+			}
+			vm.stack[len(vm.stack)-1] = &BoundMethod{receiver: vm.peek(0).(*Instance), method: method}
+
+		case bytecode.OpTry:
+			next := ip + 4
+			vm.handlers = append(vm.handlers, handler{
+				frame:   len(vm.frames) - 1,
+				height:  len(vm.stack),
+				catch:   handlerTarget(next, readUint16(code, ip)),
+				finally: handlerTarget(next, readUint16(code, ip+2)),
+				line:    frame.closure.function.Lines[start],
+			})
+			ip = next
+		case bytecode.OpPopHandler:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		case bytecode.OpThrow:
+			return nil, fail(interpreter.NewThrowError(vm.pop(), frame.closure.function.Lines[start]), start)
+		case bytecode.OpRethrow:
+			frame.ip = start
+			return nil, vm.pop().(pendingError).err
+
+		case bytecode.OpImport:
+			path := constants[readUint16(code, ip)].(string)
+			frame.ip = ip + 2
+			module, err := vm.importModule(path, frame.closure.function.Lines[start])
+			if err != nil {
+				return nil, interr.InFile(interr.Locate(err, frame.closure.function.Span(start)), frame.closure.module.name)
+			}
+			vm.push(module)
+			frame = &vm.frames[len(vm.frames)-1]
+			code, constants, ip = frame.closure.function.Code, frame.closure.program.Constants, frame.ip
+
+		default:
+			return nil, fail(fmt.Errorf("unknown instruction %d", op), start)
+		}
+	}
+}
+
+// readUint16 reads an u16 operand.
+func readUint16(code []byte, offset int) int {
+	return int(code[offset])<<8 | int(code[offset+1])
+}
+
+// handlerTarget returns the offset where a handler continues (or -1, when it is missing).
+func handlerTarget(next int, offset int) int {
+	if offset == 0 {
+		return -1
+	}
+	return next + offset
+}
+
+// binary evaluates a binary operation (the operations between numbers are evaluated right away).
+func (vm *VM) binary(op bytecode.OpCode, left interface{}, right interface{}) (interface{}, error) {
+	a, isNumber := left.(float64)
+	b, isOtherNumber := right.(float64)
+	if !isNumber || !isOtherNumber {
+		if op == bytecode.OpAdd {
+			if err := vm.checkConcat(left, right); err != nil {
+				return nil, err
+			}
+		}
+		return evaluate(op, left, right)
+	}
+
+	switch op {
+	case bytecode.OpGreater:
+		return a > b, nil
+	case bytecode.OpGreaterOrEqual:
+		return a >= b, nil
+	case bytecode.OpLower:
+		return a < b, nil
+	case bytecode.OpLowerOrEqual:
+		return a <= b, nil
+	case bytecode.OpAdd:
+		return a + b, nil
+	case bytecode.OpSubtract:
+		return a - b, nil
+	case bytecode.OpMultiply:
+		return a * b, nil
+	case bytecode.OpDivide:
+		if b == 0 {
+			return nil, fmt.Errorf("division per zero")
+		}
+		return a / b, nil
+	case bytecode.OpModulus:
+		if b == 0 {
+			return nil, fmt.Errorf("division per zero")
+		}
+		return math.Mod(a, b), nil
+	}
+
+	return evaluate(op, left, right)
+}
+
+// evaluate evaluates an operation with the evaluators of the interpreter.
+func evaluate(op bytecode.OpCode, left interface{}, right interface{}) (interface{}, error) {
+	var e evaluator.Evaluator
+	var err error
+	if op == bytecode.OpNot || op == bytecode.OpNegate {
+		e, err = evaluator.NewUnaryEvaluator(operators[op], left)
+	} else {
+		e, err = evaluator.NewBinaryEvaluator(left, operators[op], right)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.Evaluate()
+}
+
+// getIndex returns the element of a list, or the value of a map key (missing keys are null).
+func getIndex(object interface{}, index interface{}) (interface{}, error) {
+	switch collection := object.(type) {
+	case *types.List:
+		position, err := interpreter.ListIndex(collection, index)
+		if err != nil {
+			return nil, err
+		}
+		return collection.Get(position), nil
+	case *types.Map:
+		err := interpreter.ValidateMapKey(index)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := collection.Get(index)
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("only lists and maps can be indexed")
+}
+
+// setIndex sets the element of a list, or the value of a map key.
+func setIndex(object interface{}, index interface{}, value interface{}) error {
+	switch collection := object.(type) {
+	case *types.List:
+		position, err := interpreter.ListIndex(collection, index)
+		if err != nil {
+			return err
+		}
+		collection.Set(position, value)
+		return nil
+	case *types.Map:
+		err := interpreter.ValidateMapKey(index)
+		if err != nil {
+			return err
+		}
+		collection.Set(index, value)
+		return nil
+	}
+
+	return fmt.Errorf("only lists and maps can be indexed")
+}
+
+// getProperty returns the value of a property of an instance, a module or an error.
+func getProperty(object interface{}, name string) (interface{}, error) {
+	switch object := object.(type) {
+	case *Instance:
+		return object.Get(name)
+	case *Module:
+		return object.Get(name)
+	case *interpreter.ErrorValue:
+		return object.Get(token.NewToken(token.Identifier, name, nil, 0))
+	}
+
+	return nil, fmt.Errorf("only instances, modules and errors have properties")
+}
+
+// getGlobal returns the value of a global of the module (or a builtin).
+func (vm *VM) getGlobal(module *Module, name string) (interface{}, error) {
+	if value, exists := module.globals[name]; exists {
+		return value, nil
+	}
+
+	if value, exists := vm.builtins[name]; exists {
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("the variable %s is not defined", name)
+}
+
+// setGlobal assigns a value to a global of the module (or a builtin) already declared.
+func (vm *VM) setGlobal(module *Module, name string, value interface{}) error {
+	if _, exists := module.globals[name]; exists {
+		module.globals[name] = value
+		return nil
+	}
+
+	if _, exists := vm.builtins[name]; exists {
+		vm.builtins[name] = value
+		return nil
+	}
+
+	return fmt.Errorf("cannot assign a value to the variable %q, because it is not declared", name)
+}
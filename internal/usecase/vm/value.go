@@ -0,0 +1,163 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+)
+
+// anonymousFunctionName is the name used to refer to anonymous functions (eg: in errors).
+const anonymousFunctionName = "anonymous"
+
+const initializerName = "init"
+
+type (
+	// Closure is the runtime representation of a function: a compiled function, with the variables it captured.
+	Closure struct {
+		function *bytecode.Function
+		program  *bytecode.Program // program where the function was compiled (with its constants)
+		module   *Module           // module where the function was declared (with its globals)
+		upvalues []*Upvalue
+	}
+
+	// Upvalue is a variable captured by a closure.
+	// While the variable is in the stack the upvalue is open, and it is closed (keeping the value) when the
+	// variable goes out of scope.
+	Upvalue struct {
+		slot   int
+		value  interface{}
+		closed bool
+	}
+
+	// Class is the runtime representation of a class.
+	// Calling a class creates a new instance of it.
+	Class struct {
+		name       string
+		superclass *Class
+		methods    map[string]*Closure
+	}
+
+	// Instance is the runtime representation of an instance of a class.
+	Instance struct {
+		class  *Class
+		fields map[string]interface{}
+	}
+
+	// BoundMethod is a method bound to an instance (that can be accessed using "this").
+	BoundMethod struct {
+		receiver *Instance
+		method   *Closure
+	}
+
+	// iterator keeps the state of a range-based for loop.
+	// The elements are taken when the loop starts, so changing the collection inside the loop doesn't affect the iteration.
+	iterator struct {
+		keys      []interface{}
+		values    []interface{}
+		variables int  // quantity of loop variables
+		isMap     bool // a single variable takes the elements of a list, or the keys of a map
+		next      int
+	}
+
+	// pendingError is the error being propagated while a finally body runs.
+	pendingError struct {
+		err error
+	}
+)
+
+// Name returns the name of the function (anonymous functions don't have a name).
+func (c *Closure) Name() string {
+	if c.function.Name == "" {
+		return anonymousFunctionName
+	}
+	return c.function.Name
+}
+
+// Arity returns the quantity of parameters defined in the function signature.
+func (c *Closure) Arity() int {
+	return c.function.Arity
+}
+
+func (c *Closure) String() string {
+	return fmt.Sprintf("<fn %s>", c.Name())
+}
+
+// FindMethod finds a method in the class, or in its superclasses.
+func (c *Class) FindMethod(name string) (*Closure, bool) {
+	if method, ok := c.methods[name]; ok {
+		return method, true
+	}
+
+	if c.superclass != nil {
+		return c.superclass.FindMethod(name)
+	}
+
+	return nil, false
+}
+
+// Arity returns the quantity of parameters of the initializer (if any).
+func (c *Class) Arity() int {
+	if initializer, ok := c.FindMethod(initializerName); ok {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *Class) Name() string {
+	return c.name
+}
+
+func (c *Class) String() string {
+	return fmt.Sprintf("<class %s>", c.name)
+}
+
+// Get returns the value of a property of the instance (a field, or a method bound to the instance).
+func (i *Instance) Get(name string) (interface{}, error) {
+	if value, ok := i.fields[name]; ok {
+		return value, nil
+	}
+
+	if method, ok := i.class.FindMethod(name); ok {
+		return &BoundMethod{receiver: i, method: method}, nil
+	}
+
+	return nil, fmt.Errorf("undefined property %q", name)
+}
+
+func (i *Instance) String() string {
+	return fmt.Sprintf("<%s instance>", i.class.name)
+}
+
+func (b *BoundMethod) Name() string {
+	return b.method.Name()
+}
+
+func (b *BoundMethod) Arity() int {
+	return b.method.Arity()
+}
+
+func (b *BoundMethod) String() string {
+	return b.method.String()
+}
+
+// newIterator takes the elements of a list or a map, for a range-based for loop.
+func newIterator(iterable interface{}, variables int) (*iterator, error) {
+	it := &iterator{variables: variables}
+
+	switch collection := iterable.(type) {
+	case *types.List:
+		it.values = append(it.values, collection.Elements...)
+		for index := range it.values {
+			it.keys = append(it.keys, float64(index))
+		}
+	case *types.Map:
+		it.keys = collection.Keys()
+		it.values = collection.Values()
+		it.isMap = true
+	default:
+		return nil, fmt.Errorf("only lists and maps can be iterated")
+	}
+
+	return it, nil
+}
@@ -0,0 +1,297 @@
+// Package vm contains the virtual machine that runs the programs compiled into bytecode.
+// It is a stack-based VM: the instructions take their operands from a stack of values, and each
+// function call runs in a frame (a window of the stack, where its locals live).
+package vm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+)
+
+type (
+	// VM runs compiled programs.
+	// The globals are kept across the runs (eg: for the REPL).
+	VM struct {
+		ctx          context.Context
+		stdout       io.Writer
+		stdin        *bufio.Reader
+		builtins     map[string]interface{} // values shared by all the modules (eg: the native functions)
+		main         *Module                // module with the globals of the programs run by the VM
+		stack        []interface{}
+		frames       []frame
+		handlers     []handler  // exception handlers of the try statements being run
+		openUpvalues []*Upvalue // upvalues of the variables still in the stack (sorted by slot)
+		modules      *interpreter.Modules[*Module]
+
+		limits interpreter.Limits
+		steps  int // quantity of instructions run in the current run
+	}
+
+	// frame is a function call being run.
+	frame struct {
+		closure *Closure
+		name    string      // name of the called value (the class, in the calls to the initializers)
+		ip      int         // offset of the next instruction
+		base    int         // slot of the stack where the frame starts (with the callee, or the instance in methods)
+		result  interface{} // value being returned, while the finally bodies run
+	}
+
+	// handler is the exception handler of a try statement being run.
+	handler struct {
+		frame   int // index of the frame of the try statement
+		height  int // height of the stack when the try started
+		catch   int // offset of the catch body (-1 when there is no catch, or it is already running)
+		finally int // offset of the finally body (-1 when there is no finally)
+		line    int // line of the try statement (used for the errors caught without a line)
+	}
+)
+
+// New is a constructor for a VM.
+func New(stdout io.Writer) *VM {
+	builtins := map[string]interface{}{}
+	for name, native := range interpreter.Natives() {
+		builtins[name] = native
+	}
+
+	return &VM{
+		ctx:      context.Background(),
+		stdout:   stdout,
+		stdin:    bufio.NewReader(strings.NewReader("")),
+		builtins: builtins,
+		main:     newModule(""),
+		modules:  interpreter.NewModules[*Module](),
+		limits:   interpreter.DefaultLimits(),
+	}
+}
+
+// Define defines a new builtin, visible from all the modules (eg: a native function registered by the host).
+func (vm *VM) Define(name string, value interface{}) {
+	vm.builtins[name] = value
+}
+
+// SetStdin sets the reader used by the VM to read input (eg: the input native fn).
+func (vm *VM) SetStdin(stdin io.Reader) {
+	vm.stdin = bufio.NewReader(stdin)
+}
+
+// SetLimits sets the resource limits enforced by the VM.
+func (vm *VM) SetLimits(limits interpreter.Limits) {
+	vm.limits = limits
+	vm.modules.SetMaxStringLength(limits.MaxStringLength)
+}
+
+// Context returns the context of the current run.
+func (vm *VM) Context() context.Context {
+	return vm.ctx
+}
+
+// Stdin returns the reader used by the VM to read input.
+func (vm *VM) Stdin() *bufio.Reader {
+	return vm.stdin
+}
+
+// Globals returns the entries defined in the global environment (including the builtins).
+func (vm *VM) Globals() map[string]interface{} {
+	globals := make(map[string]interface{}, len(vm.builtins)+len(vm.main.globals))
+	for name, value := range vm.builtins {
+		globals[name] = value
+	}
+	for name, value := range vm.main.globals {
+		globals[name] = value
+	}
+	return globals
+}
+
+// Run runs a program while observing the given context, returning the value it returns.
+// The context is checked at loop iterations and function calls (and while sleeping), and when
+// it is done, the execution stops with ErrCanceled or ErrDeadlineExceeded.
+func (vm *VM) Run(ctx context.Context, program *bytecode.Program) (interface{}, error) {
+	previousCtx := vm.ctx
+	vm.ctx = ctx
+	defer func() { vm.ctx = previousCtx }()
+
+	vm.steps = 0
+
+	if err := vm.checkContext(0); err != nil {
+		return nil, err
+	}
+
+	return vm.runMain(&Closure{function: program.Main(), program: program, module: vm.main})
+}
+
+// runMain runs the top-level code of a program (in a new frame), until it returns.
+// The code that misuses the stack (eg: a program loaded from a crafted file, see bytecode.Decode) makes the run
+// fail with a runtime error that wraps bytecode.ErrInvalidFormat.
+func (vm *VM) runMain(closure *Closure) (result interface{}, err error) {
+	vm.push(closure)
+	vm.frames = append(vm.frames, frame{closure: closure, base: len(vm.stack) - 1})
+	baseFrame := len(vm.frames) - 1
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			vm.unwind(baseFrame)
+			result, err = nil, interr.RuntimeError{
+				Message: fmt.Sprintf("%s: %v", bytecode.ErrInvalidFormat, recovered),
+				Err:     bytecode.ErrInvalidFormat,
+			}
+		}
+	}()
+
+	for {
+		result, err := vm.execute(baseFrame)
+		if err == nil {
+			return result, nil
+		}
+
+		if !vm.handle(err, baseFrame) {
+			err = vm.trace(err, baseFrame)
+			vm.unwind(baseFrame)
+			return nil, err
+		}
+	}
+}
+
+// handle looks for the handler of an error (in the frames above the base frame), and makes the execution continue
+// in its catch (with the caught value in the stack) or in its finally (with the pending error in the stack).
+func (vm *VM) handle(err error, baseFrame int) bool {
+	for len(vm.handlers) > 0 {
+		h := vm.handlers[len(vm.handlers)-1]
+		if h.frame < baseFrame {
+			return false
+		}
+
+		var target int
+		var value interface{}
+		switch {
+		case h.catch != -1 && interpreter.IsCatchable(err):
+			// the handler is kept while the catch body runs, only if there is a finally body
+			target, value = h.catch, interpreter.CaughtValue(err, h.line)
+			vm.handlers[len(vm.handlers)-1].catch = -1
+			if h.finally == -1 {
+				vm.handlers = vm.handlers[:len(vm.handlers)-1]
+			}
+		case h.finally != -1:
+			target, value = h.finally, pendingError{err: vm.trace(err, h.frame)}
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+		default:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+			continue
+		}
+
+		vm.closeUpvalues(h.height)
+		vm.stack = vm.stack[:h.height]
+		vm.frames = vm.frames[:h.frame+1]
+		vm.frames[h.frame].ip = target
+		vm.push(value)
+		return true
+	}
+
+	return false
+}
+
+// trace adds the calls of the frames above the given one to the stack trace of an error, from the innermost one
+// (like the interpreter, the frames of the natives and of the top level are not part of the trace).
+func (vm *VM) trace(err error, frame int) error {
+	for i := len(vm.frames) - 1; i > frame; i-- {
+		caller := vm.frames[i-1]
+		err = interr.WithFrame(err, interr.Frame{
+			Function: vm.frames[i].name,
+			Line:     caller.closure.function.Lines[caller.ip-1],
+			File:     caller.closure.module.name,
+		})
+	}
+	return err
+}
+
+// unwind discards the frames from the base frame (and their handlers), after an error that was not handled.
+func (vm *VM) unwind(baseFrame int) {
+	base := vm.frames[baseFrame].base
+	vm.closeUpvalues(base)
+	vm.stack = vm.stack[:base]
+	vm.frames = vm.frames[:baseFrame]
+
+	for len(vm.handlers) > 0 && vm.handlers[len(vm.handlers)-1].frame >= baseFrame {
+		vm.handlers = vm.handlers[:len(vm.handlers)-1]
+	}
+}
+
+// Stack
+
+func (vm *VM) push(value interface{}) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() interface{} {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+func (vm *VM) peek(distance int) interface{} {
+	return vm.stack[len(vm.stack)-1-distance]
+}
+
+// Upvalues
+
+// captureUpvalue returns the upvalue of the variable in the given slot (creating it, if it was not captured yet).
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	index := len(vm.openUpvalues)
+	for index > 0 && vm.openUpvalues[index-1].slot >= slot {
+		if vm.openUpvalues[index-1].slot == slot {
+			return vm.openUpvalues[index-1]
+		}
+		index--
+	}
+
+	upvalue := &Upvalue{slot: slot}
+	vm.openUpvalues = append(vm.openUpvalues, nil)
+	copy(vm.openUpvalues[index+1:], vm.openUpvalues[index:])
+	vm.openUpvalues[index] = upvalue
+	return upvalue
+}
+
+// closeUpvalues closes the upvalues of the variables from the given slot (since they are going out of the stack).
+func (vm *VM) closeUpvalues(slot int) {
+	for len(vm.openUpvalues) > 0 {
+		upvalue := vm.openUpvalues[len(vm.openUpvalues)-1]
+		if upvalue.slot < slot {
+			return
+		}
+
+		upvalue.value = vm.stack[upvalue.slot]
+		upvalue.closed = true
+		vm.openUpvalues = vm.openUpvalues[:len(vm.openUpvalues)-1]
+	}
+}
+
+func (vm *VM) getUpvalue(upvalue *Upvalue) interface{} {
+	if upvalue.closed {
+		return upvalue.value
+	}
+	return vm.stack[upvalue.slot]
+}
+
+func (vm *VM) setUpvalue(upvalue *Upvalue, value interface{}) {
+	if upvalue.closed {
+		upvalue.value = value
+		return
+	}
+	vm.stack[upvalue.slot] = value
+}
+
+// checkContext returns a runtime error if the context of the execution is done.
+func (vm *VM) checkContext(line int) error {
+	select {
+	case <-vm.ctx.Done():
+		return interr.WrapRuntimeError(interpreter.ContextErr(vm.ctx), line)
+	default:
+		return nil
+	}
+}
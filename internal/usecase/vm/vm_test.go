@@ -0,0 +1,154 @@
+package vm_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/usecase/compiler"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+	"github.com/avazquezcode/govetryx/internal/usecase/vm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	tests := map[string]struct {
+		src            string
+		expectedStdout string
+		expectedValue  interface{}
+		expectedLine   int // line of the runtime error (0 means no error is expected)
+	}{
+		"value of the last expression": {
+			src:           "dec a = 2; a * 21;",
+			expectedValue: 42.0,
+		},
+		"upvalues are shared by the closures": {
+			src:            "fn make() { dec n = 0; fn inc() { n = n + 1; } fn get() { return n; } return [inc, get]; } dec fs = make(); fs[0](); fs[0](); print fs[1]();",
+			expectedStdout: "2\n",
+		},
+		"upvalues are closed at the end of a block": {
+			src:            "dec f; { dec a = \"block\"; f = () => a; } print f();",
+			expectedStdout: "block\n",
+		},
+		"errors are caught through frames": {
+			src:            "fn a() { return 1 / 0; } fn b() { return a(); } try { b(); } catch (e) { print e.line; } print \"after\";",
+			expectedStdout: "1\nafter\n",
+		},
+		"stack is restored after a caught error": {
+			src:            "dec l = []; for i := 0; i < 3; i = i + 1 { try { push(l, i + null); } catch (e) { push(l, i); } } print l;",
+			expectedStdout: "[0, 1, 2]\n",
+		},
+		"initializer returns the instance": {
+			src:            "class A { init() { this.x = 1; return; } } dec a = A(); print a.x; print a.init();",
+			expectedStdout: "1\n<A instance>\n",
+		},
+		"runtime error points to its line": {
+			src:          "dec a = 1;\n\nprint a + \"b\";",
+			expectedLine: 3,
+		},
+		"runtime error inside a function points to the line inside the function": {
+			src:          "fn f() {\n  return -\"a\";\n}\nf();",
+			expectedLine: 2,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			var stdout bytes.Buffer
+			machine := vm.New(&stdout)
+
+			value, err := machine.Run(context.Background(), compile(t, test.src))
+			assert.Equal(t, test.expectedStdout, stdout.String())
+			if test.expectedLine == 0 {
+				assert.NoError(t, err)
+				assert.Equal(t, test.expectedValue, value)
+				return
+			}
+
+			var runtimeErr interr.RuntimeError
+			assert.ErrorAs(t, err, &runtimeErr)
+			assert.Equal(t, test.expectedLine, runtimeErr.Line)
+		})
+	}
+}
+
+func TestRunKeepsTheGlobals(t *testing.T) {
+	var stdout bytes.Buffer
+	machine := vm.New(&stdout)
+	machine.Define("answer", 42.0)
+
+	_, err := machine.Run(context.Background(), compile(t, "dec a = answer; fn f() { return a; }"))
+	assert.NoError(t, err)
+
+	// a failed run leaves the VM ready for the next ones
+	_, err = machine.Run(context.Background(), compile(t, "fn g() { throw \"boom\"; } g();"))
+	assert.Error(t, err)
+
+	value, err := machine.Run(context.Background(), compile(t, "f();"))
+	assert.NoError(t, err)
+	assert.Equal(t, 42.0, value)
+	assert.Contains(t, machine.Globals(), "a")
+	assert.Contains(t, machine.Globals(), "clock")
+}
+
+func TestRunRejectsCodeThatMisusesTheStack(t *testing.T) {
+	tests := map[string]struct {
+		code []bytecode.OpCode
+	}{
+		"local slot outside the stack": {
+			code: []bytecode.OpCode{bytecode.OpGetLocal},
+		},
+		"pop of an empty stack": {
+			code: []bytecode.OpCode{bytecode.OpPop, bytecode.OpPop},
+		},
+		"binary operation without operands": {
+			code: []bytecode.OpCode{bytecode.OpAdd},
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			main := &bytecode.Function{}
+			for _, op := range test.code {
+				main.WriteInstruction(1, op, 200)
+			}
+			main.WriteInstruction(1, bytecode.OpNull)
+			main.WriteInstruction(1, bytecode.OpReturn)
+
+			// the program passes the checks of a program loaded from a file
+			data, err := bytecode.Encode(&bytecode.Program{Functions: []*bytecode.Function{main}})
+			assert.NoError(t, err)
+			program, err := bytecode.Decode(data)
+			assert.NoError(t, err)
+
+			machine := vm.New(&bytes.Buffer{})
+			_, err = machine.Run(context.Background(), program)
+			assert.ErrorIs(t, err, bytecode.ErrInvalidFormat)
+
+			// the VM is still ready for the next runs
+			value, err := machine.Run(context.Background(), compile(t, "1 + 2;"))
+			assert.NoError(t, err)
+			assert.Equal(t, 3.0, value)
+		})
+	}
+}
+
+// compile scans, parses, resolves and compiles the code.
+func compile(t *testing.T, src string) *bytecode.Program {
+	tokens, err := scanner.NewScanner(bytes.Runes([]byte(src))).Scan()
+	assert.NoError(t, err)
+
+	statements, err := parser.NewParser(tokens).Parse()
+	assert.NoError(t, err)
+
+	err = interpreter.NewResolver(nil).Resolve(statements)
+	assert.NoError(t, err)
+
+	program, err := compiler.Compile(statements)
+	assert.NoError(t, err)
+	return program
+}
@@ -0,0 +1,333 @@
+package vetryx_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/vetryx"
+	"github.com/stretchr/testify/assert"
+)
+
+// runInBackend compiles and runs the code in a new runtime of the given backend, returning what it printed.
+func runInBackend(backend vetryx.Backend, src string, opts ...vetryx.Option) (string, error) {
+	var stdout bytes.Buffer
+	opts = append(opts, vetryx.WithStdout(&stdout), vetryx.WithBackend(backend))
+	runtime := vetryx.NewRuntime(opts...)
+
+	program, err := runtime.Compile(src)
+	if err != nil {
+		return "", err
+	}
+
+	err = runtime.Run(context.Background(), program)
+	return stdout.String(), err
+}
+
+func TestBackendsProduceTheSameOutput(t *testing.T) {
+	dir := t.TempDir()
+	modules := map[string]string{
+		"math.vx":  "dec pi = 3; fn double(n) { return n * 2; } print \"loading math\";",
+		"a.vx":     `import "b.vx" as b;`,
+		"b.vx":     `import "a.vx" as a;`,
+		"fails.vx": "print 1 / 0;",
+	}
+	for name, src := range modules {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600)
+		assert.NoError(t, err)
+	}
+
+	tests := map[string]struct {
+		src            string
+		expectedStdout string
+		expectedErr    bool
+	}{
+		"arithmetic": {
+			src:            "print 1 + 2 * 3; print 10 / 4; print 7 % 3; print -(2 - 5); print \"a\" + \"b\";",
+			expectedStdout: "7\n2.5\n1\n3\nab\n",
+		},
+		"comparison and logical operators": {
+			src:            "print 1 < 2; print 2 <= 1; print 1 == 1; print \"a\" <> \"a\"; print null || \"x\"; print 1 && 2; print !true;",
+			expectedStdout: "true\nfalse\ntrue\nfalse\nx\n2\nfalse\n",
+		},
+		"scopes": {
+			src:            "dec a = 1; { dec a = 2; print a; } print a;",
+			expectedStdout: "2\n1\n",
+		},
+		"loops": {
+			src:            "dec i = 0; while i < 5 { i = i + 1; if i == 2 { continue; } if i == 4 { break; } print i; }",
+			expectedStdout: "1\n3\n",
+		},
+		"range-based for loops": {
+			src:            "for i, v in [\"a\", \"b\"] { print i; print v; } for k, v in {\"x\": 1} { print k + \"=\"; print v; }",
+			expectedStdout: "0\na\n1\nb\nx=\n1\n",
+		},
+		"recursion": {
+			src:            "fn fib(n) { if n < 2 { return n; } return fib(n - 1) + fib(n - 2); } print fib(15);",
+			expectedStdout: "610\n",
+		},
+		"closures keep their own state": {
+			src:            "fn counter() { dec c = 0; return () => { c = c + 1; return c; }; } dec a = counter(); dec b = counter(); print a(); print a(); print b();",
+			expectedStdout: "1\n2\n1\n",
+		},
+		"closures capture each iteration of a for loop": {
+			src:            "dec fs = []; for i := 0; i < 3; i = i + 1 { push(fs, fn() { return i; }); } for f in fs { print f(); }",
+			expectedStdout: "0\n1\n2\n",
+		},
+		"closures capture variables of enclosing functions": {
+			src:            "fn outer() { dec x = \"outer\"; fn middle() { fn inner() { return x; } return inner; } return middle()(); } print outer();",
+			expectedStdout: "outer\n",
+		},
+		"collections": {
+			src:            "dec l = [1, 2]; l[0] = 9; push(l, 3); print l; print len(l); dec m = {\"a\": 1}; m[\"b\"] = 2; print m; print m[\"c\"];",
+			expectedStdout: "[9, 2, 3]\n3\n{\"a\": 1, \"b\": 2}\nnull\n",
+		},
+		"classes and inheritance": {
+			src: `
+class Animal {
+  init(name) { this.name = name; }
+  speak() { return this.name + " makes a sound"; }
+}
+class Dog < Animal {
+  speak() { return super.speak() + " (woof)"; }
+}
+dec d = Dog("rex");
+print d.speak();
+print d;
+print Dog;
+dec speak = d.speak;
+print speak();`,
+			expectedStdout: "rex makes a sound (woof)\n<Dog instance>\n<class Dog>\nrex makes a sound (woof)\n",
+		},
+		"functions are printed with their names": {
+			src:            "fn f() {} print f; print (a) => a; print clock;",
+			expectedStdout: "<fn f>\n<fn anonymous>\n<native fn clock>\n",
+		},
+		"caught exceptions": {
+			src:            "try { throw \"boom\"; } catch (e) { print e; } try { print 1 / 0; } catch (e) { print e.message; print e.line; }",
+			expectedStdout: "boom\ndivision per zero\n1\n",
+		},
+		"finally runs on return, break and errors": {
+			src: `
+fn f() { try { return "try"; } finally { print "finally"; } }
+print f();
+for i := 0; i < 3; i = i + 1 { try { if i == 1 { break; } } finally { print i; } }
+try { try { throw "inner"; } finally { print "cleanup"; } } catch (e) { print e; }`,
+			expectedStdout: "finally\ntry\n0\n1\ncleanup\ninner\n",
+		},
+		"return inside finally overrides the try": {
+			src:            "fn f() { try { return 1; } finally { return 2; } } print f();",
+			expectedStdout: "2\n",
+		},
+		"imports": {
+			src:            "import \"math.vx\" as m; import \"math.vx\" as again; print m.double(m.pi); print m;",
+			expectedStdout: "loading math\n6\n<module math.vx>\n",
+		},
+		"cyclic imports": {
+			src:         `import "a.vx" as a;`,
+			expectedErr: true,
+		},
+		"failed imports": {
+			src:         `import "fails.vx" as f;`,
+			expectedErr: true,
+		},
+		"uncaught exception": {
+			src:            "print 1; throw \"boom\";",
+			expectedStdout: "1\n",
+			expectedErr:    true,
+		},
+		"invalid operands": {
+			src:         "print \"a\" + 1;",
+			expectedErr: true,
+		},
+		"wrong quantity of arguments": {
+			src:         "fn f(a) {} f();",
+			expectedErr: true,
+		},
+		"call of a non-function": {
+			src:         "dec a = 1; a();",
+			expectedErr: true,
+		},
+		"undefined variable": {
+			src:         "print a;",
+			expectedErr: true,
+		},
+		"undefined property": {
+			src:         "class A {} A().foo;",
+			expectedErr: true,
+		},
+		"lists that contain themselves": {
+			src:            "dec xs = [1]; push(xs, xs); print xs; print xs == xs; dec ys = [1]; push(ys, ys); print xs == ys; print xs <> [1, [2]];",
+			expectedStdout: "[1, [...]]\ntrue\ntrue\ntrue\n",
+		},
+		"maps that contain themselves": {
+			src:            `dec m = {"a": 1}; m["self"] = m; print m; print m == m; dec n = {"a": 1}; n["self"] = n; print m == n;`,
+			expectedStdout: "{\"a\": 1, \"self\": {...}}\ntrue\ntrue\n",
+		},
+		"the keys deleted from a map inside a loop are still visited": {
+			src:            `dec m = {"a": 1, "b": 2}; for k, v in m { delete(m, "b"); m["c"] = 3; print k; print v; } print m;`,
+			expectedStdout: "a\n1\nb\n2\n{\"a\": 1, \"c\": 3}\n",
+		},
+		"index out of range": {
+			src:         "dec l = [1]; print l[1];",
+			expectedErr: true,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			treeStdout, treeErr := runInBackend(vetryx.BackendTree, test.src, vetryx.WithBaseDir(dir))
+			vmStdout, vmErr := runInBackend(vetryx.BackendVM, test.src, vetryx.WithBaseDir(dir))
+
+			assert.Equal(t, test.expectedStdout, treeStdout)
+			assert.Equal(t, treeStdout, vmStdout)
+			assert.Equal(t, test.expectedErr, treeErr != nil)
+			if treeErr != nil {
+				assert.EqualError(t, vmErr, treeErr.Error())
+			} else {
+				assert.NoError(t, vmErr)
+			}
+
+			// the optimizations don't change the output (nor the errors)
+			for _, backend := range backends {
+				stdout, err := runInBackend(backend, test.src, vetryx.WithBaseDir(dir), vetryx.WithOptimizations(true))
+				assert.Equal(t, treeStdout, stdout, backend)
+				if treeErr != nil {
+					assert.EqualError(t, err, treeErr.Error(), backend)
+				} else {
+					assert.NoError(t, err, backend)
+				}
+			}
+		})
+	}
+}
+
+func TestBackendsReportTheImportOfTheMainFile(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "c1.vx")
+	src := `print "loading c1"; import "c2.vx" as c2;`
+	assert.NoError(t, os.WriteFile(main, []byte(src), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "c2.vx"), []byte(`import "c1.vx" as c1;`), 0o600))
+
+	for _, backend := range backends {
+		t.Run(string(backend), func(t *testing.T) {
+			stdout, err := runInBackend(backend, src, vetryx.WithBaseDir(dir), vetryx.WithMainFile(main))
+			assert.Equal(t, "loading c1\n", stdout)
+			assert.ErrorContains(t, err, fmt.Sprintf(`failed importing "c2.vx": cyclic import: %s -> c2.vx -> c1.vx`, main))
+		})
+	}
+}
+
+func TestBackendsRunTheExamples(t *testing.T) {
+	examples, err := filepath.Glob("../web/examples/*.vx")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, examples)
+
+	for _, example := range examples {
+		t.Run(filepath.Base(example), func(t *testing.T) {
+			code, err := os.ReadFile(example)
+			assert.NoError(t, err)
+
+			treeStdout, treeErr := runInBackend(vetryx.BackendTree, string(code))
+			vmStdout, vmErr := runInBackend(vetryx.BackendVM, string(code))
+			assert.NoError(t, treeErr)
+			assert.NoError(t, vmErr)
+			assert.Equal(t, treeStdout, vmStdout)
+
+			optimizedStdout, err := runInBackend(vetryx.BackendVM, string(code), vetryx.WithOptimizations(true))
+			assert.NoError(t, err)
+			assert.Equal(t, treeStdout, optimizedStdout)
+		})
+	}
+}
+
+func TestRuntimeWithVMBackend(t *testing.T) {
+	var stdout bytes.Buffer
+	runtime := vetryx.NewRuntime(vetryx.WithStdout(&stdout), vetryx.WithBackend(vetryx.BackendVM))
+	assert.Equal(t, vetryx.BackendVM, runtime.Backend())
+
+	runtime.RegisterRawFunc("twice", 1, func(args []interface{}) (interface{}, error) {
+		return args[0].(float64) * 2, nil
+	})
+
+	setup, err := runtime.Compile("dec counter = 0;")
+	assert.NoError(t, err)
+	assert.NoError(t, runtime.Run(context.Background(), setup))
+
+	program, err := runtime.Compile("counter = counter + 1; twice(counter);")
+	assert.NoError(t, err)
+	assert.Contains(t, program.Disassemble(), "OP_CALL")
+
+	value, err := runtime.Eval(context.Background(), program)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+
+	value, err = runtime.Eval(context.Background(), program)
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, value)
+
+	assert.Equal(t, 2.0, runtime.Globals()["counter"])
+	assert.Contains(t, runtime.Globals(), "twice")
+}
+
+func TestParseBackend(t *testing.T) {
+	backend, err := vetryx.ParseBackend("vm")
+	assert.NoError(t, err)
+	assert.Equal(t, vetryx.BackendVM, backend)
+
+	backend, err = vetryx.ParseBackend("tree")
+	assert.NoError(t, err)
+	assert.Equal(t, vetryx.BackendTree, backend)
+
+	_, err = vetryx.ParseBackend("jit")
+	assert.Error(t, err)
+}
+
+func TestLoadCompiledPrograms(t *testing.T) {
+	examples, err := filepath.Glob("../web/examples/*.vx")
+	assert.NoError(t, err)
+
+	for _, example := range examples {
+		t.Run(filepath.Base(example), func(t *testing.T) {
+			code, err := os.ReadFile(example)
+			assert.NoError(t, err)
+
+			// the programs compiled by the tree backend can be loaded too
+			program, err := vetryx.NewRuntime().Compile(string(code))
+			assert.NoError(t, err)
+			data, err := program.MarshalBinary()
+			assert.NoError(t, err)
+
+			var stdout bytes.Buffer
+			runtime := vetryx.NewRuntime(vetryx.WithStdout(&stdout), vetryx.WithBackend(vetryx.BackendVM))
+			loaded, err := runtime.Load(data)
+			assert.NoError(t, err)
+			assert.NoError(t, runtime.Run(context.Background(), loaded))
+
+			expectedStdout, err := runInBackend(vetryx.BackendTree, string(code))
+			assert.NoError(t, err)
+			assert.Equal(t, expectedStdout, stdout.String())
+		})
+	}
+}
+
+func TestLoadFails(t *testing.T) {
+	program, err := vetryx.NewRuntime().Compile("print 1;")
+	assert.NoError(t, err)
+	data, err := program.MarshalBinary()
+	assert.NoError(t, err)
+
+	_, err = vetryx.NewRuntime().Load(data)
+	assert.Error(t, err, "the tree backend can't run compiled programs")
+
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = vetryx.NewRuntime(vetryx.WithBackend(vetryx.BackendVM)).Load(corrupted)
+	assert.ErrorIs(t, err, vetryx.ErrChecksumMismatch)
+
+	_, err = vetryx.NewRuntime(vetryx.WithBackend(vetryx.BackendVM)).Load([]byte("print 1;"))
+	assert.ErrorIs(t, err, vetryx.ErrInvalidCompiledProgram)
+}
@@ -0,0 +1,65 @@
+package vetryx_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/avazquezcode/govetryx/vetryx"
+)
+
+func BenchmarkFibonacciExample(b *testing.B) {
+	code, err := os.ReadFile("../web/examples/fibonacci.vx")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	benchmarkBackends(b, string(code))
+}
+
+func BenchmarkRecursiveFibonacci(b *testing.B) {
+	benchmarkBackends(b, `
+fn fibonacci(n) {
+    if n < 2 {
+        return n;
+    }
+    return fibonacci(n - 1) + fibonacci(n - 2);
+}
+
+fibonacci(15);
+`)
+}
+
+func BenchmarkNumericLoop(b *testing.B) {
+	benchmarkBackends(b, `
+dec sum = 0;
+for i := 0; i < 10000; i = i + 1 {
+    sum = sum + i * 2 % 7;
+}
+`)
+}
+
+// benchmarkBackends runs the benchmark of the code in each backend.
+func benchmarkBackends(b *testing.B, code string) {
+	for _, backend := range backends {
+		b.Run(string(backend), func(b *testing.B) {
+			benchmarkRun(b, vetryx.NewRuntime(vetryx.WithBackend(backend)), code)
+		})
+	}
+}
+
+// benchmarkRun compiles the code once, and runs it b.N times.
+func benchmarkRun(b *testing.B, runtime *vetryx.Runtime, code string) {
+	program, err := runtime.Compile(code)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := runtime.Run(context.Background(), program)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package vetryx
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/avazquezcode/govetryx/internal/domain/types"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+	"github.com/avazquezcode/govetryx/internal/usecase/vm"
+)
+
+// VariadicArity is the arity to use in RegisterRawFunc, when the function accepts any quantity of arguments.
+const VariadicArity = interpreter.VariadicArity
+
+// RawFunc is a host function that receives the Vetryx values as they are.
+// Numbers are float64, strings are string, booleans are bool and null is nil.
+type RawFunc func(args []interface{}) (interface{}, error)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterRawFunc registers a host function as a global of the runtime, without any conversion of its arguments.
+// A panic in the function ends the script with a runtime error.
+func (r *Runtime) RegisterRawFunc(name string, arity int, fn RawFunc) {
+	native := interpreter.NewNativeFunction(name, arity, func(args []interface{}) (result interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("fn %q: panic: %v", name, recovered)
+			}
+		}()
+
+		return fn(args)
+	})
+	if r.backend == BackendVM {
+		r.vm.Define(name, native)
+		return
+	}
+	r.interpreter.Define(name, native)
+}
+
+// RegisterFunc registers a host function as a global of the runtime.
+// The arguments and results are converted from/to Vetryx values automatically, based on the signature of fn.
+// Supported parameters and results are numbers (ints, uints and floats), strings, bools and interface{}.
+// The function can return no result, one result, or one result and an error (the error can also be the only result).
+// The interface{} results can only hold the supported types, or the values received from the runtime.
+// A panic in the function ends the script with a runtime error.
+func (r *Runtime) RegisterFunc(name string, fn interface{}) error {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return fmt.Errorf("cannot register %q: expected a function, got %T", name, fn)
+	}
+
+	fnType := value.Type()
+	for i := 0; i < fnType.NumIn(); i++ {
+		in := fnType.In(i)
+		if fnType.IsVariadic() && i == fnType.NumIn()-1 {
+			in = in.Elem()
+		}
+		if !isSupportedType(in) {
+			return fmt.Errorf("cannot register %q: the parameter #%d has an unsupported type %s", name, i+1, in)
+		}
+	}
+
+	if err := validateResults(fnType); err != nil {
+		return fmt.Errorf("cannot register %q: %w", name, err)
+	}
+
+	arity := fnType.NumIn()
+	if fnType.IsVariadic() {
+		arity = VariadicArity
+	}
+
+	r.RegisterRawFunc(name, arity, func(args []interface{}) (interface{}, error) {
+		in, err := toGoArguments(fnType, args)
+		if err != nil {
+			return nil, fmt.Errorf("fn %q: %w", name, err)
+		}
+
+		out := value.Call(in)
+		result, err := fromGoResults(fnType, out)
+		if err != nil {
+			return nil, fmt.Errorf("fn %q: %w", name, err)
+		}
+		return result, nil
+	})
+
+	return nil
+}
+
+// validateResults validates that the results of the function are supported.
+func validateResults(fnType reflect.Type) error {
+	switch fnType.NumOut() {
+	case 0:
+		return nil
+	case 1:
+		out := fnType.Out(0)
+		if out != errorType && !isSupportedType(out) {
+			return fmt.Errorf("the result has an unsupported type %s", out)
+		}
+		return nil
+	case 2:
+		if !isSupportedType(fnType.Out(0)) {
+			return fmt.Errorf("the first result has an unsupported type %s", fnType.Out(0))
+		}
+		if fnType.Out(1) != errorType {
+			return fmt.Errorf("the second result must be an error")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("the function must return at most 2 results")
+}
+
+// isSupportedType returns true if the type can be converted from/to a Vetryx value.
+func isSupportedType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	}
+
+	return false
+}
+
+// toGoArguments converts the Vetryx arguments into the values expected by the function.
+func toGoArguments(fnType reflect.Type, args []interface{}) ([]reflect.Value, error) {
+	in := make([]reflect.Value, 0, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if fnType.IsVariadic() && i >= fnType.NumIn()-1 {
+			paramType = fnType.In(fnType.NumIn() - 1).Elem()
+		} else {
+			paramType = fnType.In(i)
+		}
+
+		value, err := toGoValue(arg, paramType)
+		if err != nil {
+			return nil, fmt.Errorf("argument #%d %w", i+1, err)
+		}
+		in = append(in, value)
+	}
+
+	if fnType.IsVariadic() && len(args) < fnType.NumIn()-1 {
+		return nil, fmt.Errorf("expected at least %d argument(s), but got %d", fnType.NumIn()-1, len(args))
+	}
+
+	return in, nil
+}
+
+// toGoValue converts a Vetryx value into a Go value of the given type.
+func toGoValue(arg interface{}, t reflect.Type) (reflect.Value, error) {
+	switch t.Kind() {
+	case reflect.Interface:
+		if arg == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(arg), nil
+	case reflect.Bool:
+		b, ok := arg.(bool)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("must be a bool")
+		}
+		return reflect.ValueOf(b).Convert(t), nil
+	case reflect.String:
+		s, ok := arg.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("must be a string")
+		}
+		return reflect.ValueOf(s).Convert(t), nil
+	case reflect.Float32, reflect.Float64:
+		f, ok := arg.(float64)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("must be a number")
+		}
+		return reflect.ValueOf(f).Convert(t), nil
+	}
+
+	// Integers
+	f, ok := arg.(float64)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("must be a number")
+	}
+	if f != math.Trunc(f) {
+		return reflect.Value{}, fmt.Errorf("must be an integer")
+	}
+
+	value := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f < math.MinInt64 || f >= math.MaxInt64 || value.OverflowInt(int64(f)) {
+			return reflect.Value{}, fmt.Errorf("overflows %s", t)
+		}
+		value.SetInt(int64(f))
+	default:
+		if f < 0 || f >= math.MaxUint64 || value.OverflowUint(uint64(f)) {
+			return reflect.Value{}, fmt.Errorf("overflows %s", t)
+		}
+		value.SetUint(uint64(f))
+	}
+
+	return value, nil
+}
+
+// fromGoResults converts the results of the function into a Vetryx value.
+func fromGoResults(fnType reflect.Type, out []reflect.Value) (interface{}, error) {
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	last := out[len(out)-1]
+	if fnType.Out(len(out)-1) == errorType {
+		if !last.IsNil() {
+			return nil, last.Interface().(error)
+		}
+		if len(out) == 1 {
+			return nil, nil
+		}
+	}
+
+	return fromGoValue(out[0])
+}
+
+// fromGoValue converts a Go value into a Vetryx value.
+func fromGoValue(value reflect.Value) (interface{}, error) {
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return fromGoValue(value.Elem())
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), nil
+	}
+
+	if value.CanInterface() && isRuntimeValue(value.Interface()) {
+		return value.Interface(), nil
+	}
+
+	return nil, fmt.Errorf("the result has an unsupported type %s", value.Type())
+}
+
+// isRuntimeValue returns true if the value is one of the values of the runtime (eg: a list received as an
+// interface{} argument), which are returned to the scripts as they are.
+func isRuntimeValue(value interface{}) bool {
+	switch value.(type) {
+	case *types.List, *types.Map,
+		interpreter.Native, interpreter.Callable, *interpreter.Instance, *interpreter.Module, *interpreter.ErrorValue,
+		*vm.Closure, *vm.Class, *vm.Instance, *vm.BoundMethod, *vm.Module:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package vetryx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/vetryx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterFunc(t *testing.T) {
+	tests := map[string]struct {
+		fn             interface{}
+		src            string
+		expectedStdout string
+		expectedErr    string
+	}{
+		"numbers and strings": {
+			fn: func(n float64, s string) (bool, error) {
+				return float64(len(s)) == n, nil
+			},
+			src:            `print host(5, "hello"); print host(1, "hello");`,
+			expectedStdout: "true\nfalse\n",
+		},
+		"integers": {
+			fn:             func(a int, b uint8) int { return a * int(b) },
+			src:            "print host(-2, 3);",
+			expectedStdout: "-6\n",
+		},
+		"no results": {
+			fn:             func() {},
+			src:            "print host();",
+			expectedStdout: "null\n",
+		},
+		"only error result": {
+			fn:          func() error { return errors.New("boom") },
+			src:         "host();",
+			expectedErr: "boom",
+		},
+		"interface parameters": {
+			fn:             func(v interface{}) interface{} { return v },
+			src:            `print host(null); print host("a"); print host(true);`,
+			expectedStdout: "null\na\ntrue\n",
+		},
+		"variadic function": {
+			fn: func(sep string, parts ...string) string {
+				return strings.Join(parts, sep)
+			},
+			src:            `print host("-", "a", "b", "c");`,
+			expectedStdout: "a-b-c\n",
+		},
+		"argument with invalid type": {
+			fn:          func(n float64) float64 { return n },
+			src:         `host("a");`,
+			expectedErr: `fn "host": argument #1 must be a number`,
+		},
+		"argument that is not an integer": {
+			fn:          func(n int) int { return n },
+			src:         `host(1.5);`,
+			expectedErr: `fn "host": argument #1 must be an integer`,
+		},
+		"argument that overflows": {
+			fn:          func(n int8) int8 { return n },
+			src:         `host(300);`,
+			expectedErr: `fn "host": argument #1 overflows int8`,
+		},
+		"runtime values through interface parameters": {
+			fn:             func(v interface{}) interface{} { return v },
+			src:            `print host([1, 2]); print host({"a": 1}); print host(clock) == clock;`,
+			expectedStdout: "[1, 2]\n{\"a\": 1}\ntrue\n",
+		},
+		"interface result with an unsupported type": {
+			fn:          func() interface{} { return []int{1} },
+			src:         `host();`,
+			expectedErr: `fn "host": the result has an unsupported type []int`,
+		},
+		"function that panics": {
+			fn:          func() { panic("boom") },
+			src:         `host();`,
+			expectedErr: `runtime error occurred at line 1: fn "host": panic: boom`,
+		},
+		"wrong quantity of arguments": {
+			fn:          func(a, b float64) float64 { return a + b },
+			src:         `host(1);`,
+			expectedErr: `the fn "host" expects 2 argument(s), but got 1`,
+		},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			var stdout bytes.Buffer
+			runtime := vetryx.NewRuntime(vetryx.WithStdout(&stdout))
+			assert.NoError(t, runtime.RegisterFunc("host", test.fn))
+
+			program, err := runtime.Compile(test.src)
+			assert.NoError(t, err)
+
+			err = runtime.Run(context.Background(), program)
+			if test.expectedErr != "" {
+				assert.ErrorContains(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.expectedStdout, stdout.String())
+		})
+	}
+}
+
+func TestRegisterFuncPanicIsRuntimeError(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(string(backend), func(t *testing.T) {
+			runtime := vetryx.NewRuntime(vetryx.WithBackend(backend))
+			assert.NoError(t, runtime.RegisterFunc("host", func(n int) int { return 10 / n }))
+
+			program, err := runtime.Compile("dec a = 1;\nhost(0);")
+			assert.NoError(t, err)
+
+			err = runtime.Run(context.Background(), program)
+			var runtimeErr interr.RuntimeError
+			assert.ErrorAs(t, err, &runtimeErr)
+			assert.Equal(t, 2, runtimeErr.Line)
+			assert.ErrorContains(t, err, "integer divide by zero")
+		})
+	}
+}
+
+func TestRegisterFuncReturnsTheRuntimeValues(t *testing.T) {
+	src := `class A { init() { this.n = 1; } } fn f() { return 2; } dec l = [1];
+print host(A()).n; print host(f)(); print host(A) == A; print host(l) == l; print host(len)("ab");`
+
+	for _, backend := range backends {
+		t.Run(string(backend), func(t *testing.T) {
+			var stdout bytes.Buffer
+			runtime := vetryx.NewRuntime(vetryx.WithBackend(backend), vetryx.WithStdout(&stdout))
+			assert.NoError(t, runtime.RegisterFunc("host", func(v interface{}) interface{} { return v }))
+
+			program, err := runtime.Compile(src)
+			assert.NoError(t, err)
+			assert.NoError(t, runtime.Run(context.Background(), program))
+			assert.Equal(t, "1\n2\ntrue\ntrue\n2\n", stdout.String())
+		})
+	}
+}
+
+func TestRegisterRawFuncPanicIsRuntimeError(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(string(backend), func(t *testing.T) {
+			runtime := vetryx.NewRuntime(vetryx.WithBackend(backend))
+			runtime.RegisterRawFunc("host", 1, func(args []interface{}) (interface{}, error) {
+				return args[0].([]int)[0], nil
+			})
+
+			program, err := runtime.Compile("dec a = 1;\nhost(a);")
+			assert.NoError(t, err)
+
+			err = runtime.Run(context.Background(), program)
+			var runtimeErr interr.RuntimeError
+			assert.ErrorAs(t, err, &runtimeErr)
+			assert.Equal(t, 2, runtimeErr.Line)
+			assert.ErrorContains(t, err, `fn "host": panic: interface conversion`)
+		})
+	}
+}
+
+func TestRegisterFuncWithUnsupportedSignature(t *testing.T) {
+	tests := map[string]struct {
+		fn interface{}
+	}{
+		"not a function":          {fn: 1},
+		"nil function":            {fn: (func())(nil)},
+		"unsupported parameter":   {fn: func(m map[string]int) {}},
+		"unsupported result":      {fn: func() []int { return nil }},
+		"second result not error": {fn: func() (int, int) { return 1, 1 }},
+		"too many results":        {fn: func() (int, int, error) { return 1, 1, nil }},
+	}
+
+	for desc, test := range tests {
+		t.Run(desc, func(t *testing.T) {
+			assert.Error(t, vetryx.NewRuntime().RegisterFunc("host", test.fn))
+		})
+	}
+}
+
+func TestRegisterRawFunc(t *testing.T) {
+	var stdout bytes.Buffer
+	runtime := vetryx.NewRuntime(vetryx.WithStdout(&stdout))
+	runtime.RegisterRawFunc("count", vetryx.VariadicArity, func(args []interface{}) (interface{}, error) {
+		return float64(len(args)), nil
+	})
+
+	program, err := runtime.Compile(`print count(); print count(1, "a", null);`)
+	assert.NoError(t, err)
+	assert.NoError(t, runtime.Run(context.Background(), program))
+	assert.Equal(t, "0\n3\n", stdout.String())
+}
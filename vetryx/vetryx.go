@@ -0,0 +1,429 @@
+// Package vetryx exposes the public API to embed the Vetryx interpreter in Go programs.
+//
+// A Runtime compiles source code into a Program (scanning, parsing and resolving it),
+// which can later be run (and re-run) in the same Runtime.
+package vetryx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/avazquezcode/govetryx/internal/domain/ast"
+	"github.com/avazquezcode/govetryx/internal/domain/bytecode"
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/internal/domain/token"
+	"github.com/avazquezcode/govetryx/internal/usecase/compiler"
+	"github.com/avazquezcode/govetryx/internal/usecase/interpreter"
+	"github.com/avazquezcode/govetryx/internal/usecase/optimizer"
+	"github.com/avazquezcode/govetryx/internal/usecase/parser"
+	"github.com/avazquezcode/govetryx/internal/usecase/scanner"
+	"github.com/avazquezcode/govetryx/internal/usecase/vm"
+)
+
+var (
+	// ErrCanceled is the error returned when the execution of a program is canceled.
+	ErrCanceled = interr.ErrCanceled
+	// ErrDeadlineExceeded is the error returned when the execution of a program exceeds its deadline.
+	ErrDeadlineExceeded = interr.ErrDeadlineExceeded
+
+	// ErrStepLimitExceeded is the error returned when a program exceeds Limits.MaxSteps.
+	ErrStepLimitExceeded = interr.ErrStepLimitExceeded
+	// ErrCallDepthExceeded is the error returned when a program exceeds Limits.MaxCallDepth.
+	ErrCallDepthExceeded = interr.ErrCallDepthExceeded
+	// ErrStringTooLong is the error returned when a program exceeds Limits.MaxStringLength.
+	ErrStringTooLong = interr.ErrStringTooLong
+	// ErrBindingsLimitExceeded is the error returned when a program exceeds Limits.MaxBindings.
+	ErrBindingsLimitExceeded = interr.ErrBindingsLimitExceeded
+
+	// ErrInvalidCompiledProgram is the error returned when loading data that is not a compiled program.
+	ErrInvalidCompiledProgram = bytecode.ErrInvalidFormat
+	// ErrVersionMismatch is the error returned when loading a program compiled with another version of the format.
+	ErrVersionMismatch = bytecode.ErrVersionMismatch
+	// ErrChecksumMismatch is the error returned when loading a compiled program that is corrupted.
+	ErrChecksumMismatch = bytecode.ErrChecksumMismatch
+)
+
+// CompiledExtension is the extension of the files with compiled programs.
+const CompiledExtension = ".vxc"
+
+// Limits are the resource limits enforced while running programs (a zero value means there is no limit).
+// With the VM backend, MaxBindings also counts the temporary values of the expressions, so it's approximate, and a
+// program can exceed it with BackendVM but not with BackendTree.
+type Limits = interpreter.Limits
+
+// DefaultLimits returns the limits used by default (only the call depth is limited).
+func DefaultLimits() Limits {
+	return interpreter.DefaultLimits()
+}
+
+// Profiler is notified of the statements and the calls while the programs run, to measure where the time is spent.
+type Profiler = interpreter.Profiler
+
+// Backend is the engine used to run the programs.
+type Backend string
+
+const (
+	// BackendTree runs the programs by walking their AST.
+	BackendTree Backend = "tree"
+	// BackendVM compiles the programs into bytecode, and runs them in a stack-based virtual machine.
+	BackendVM Backend = "vm"
+)
+
+// ParseBackend returns the backend with the given name.
+func ParseBackend(name string) (Backend, error) {
+	switch backend := Backend(name); backend {
+	case BackendTree, BackendVM:
+		return backend, nil
+	}
+
+	return "", fmt.Errorf("unknown backend %q (expected %q or %q)", name, BackendTree, BackendVM)
+}
+
+// Runtime holds the state needed to compile and run Vetryx programs.
+// The global environment is shared by all the programs run in the same runtime.
+type Runtime struct {
+	backend     Backend
+	interpreter *interpreter.Interpreter // engine of the tree backend
+	vm          *vm.VM                   // engine of the vm backend
+	resolver    *interpreter.Resolver
+	stdout      io.Writer
+	stderr      io.Writer
+	stdin       io.Reader
+	limits      Limits
+	baseDir     string
+	mainFile    string
+	optimize    bool
+	profiler    Profiler
+}
+
+// Option configures a Runtime.
+type Option func(r *Runtime)
+
+// WithStdout sets the writer used by the programs to print.
+func WithStdout(stdout io.Writer) Option {
+	return func(r *Runtime) {
+		r.stdout = stdout
+	}
+}
+
+// WithStderr sets the writer used to report errors (eg: by the REPL).
+func WithStderr(stderr io.Writer) Option {
+	return func(r *Runtime) {
+		r.stderr = stderr
+	}
+}
+
+// WithStdin sets the reader used by the programs to read input.
+func WithStdin(stdin io.Reader) Option {
+	return func(r *Runtime) {
+		r.stdin = stdin
+	}
+}
+
+// WithLimits sets the resource limits enforced while running programs.
+func WithLimits(limits Limits) Option {
+	return func(r *Runtime) {
+		r.limits = limits
+	}
+}
+
+// WithBaseDir sets the directory used to resolve the relative imports (by default, the working directory).
+// Imports inside an imported file are resolved relative to the directory of that file.
+func WithBaseDir(dir string) Option {
+	return func(r *Runtime) {
+		r.baseDir = dir
+	}
+}
+
+// WithMainFile sets the path of the file of the programs (eg: the script run by the CLI), so the imports of the
+// file itself (from the modules it imports) are reported as cyclic, instead of running the file again.
+func WithMainFile(path string) Option {
+	return func(r *Runtime) {
+		r.mainFile = path
+	}
+}
+
+// WithBackend sets the engine used to run the programs (by default, BackendTree).
+// Both backends produce the same output for the same programs.
+func WithBackend(backend Backend) Option {
+	return func(r *Runtime) {
+		r.backend = backend
+	}
+}
+
+// WithOptimizations sets whether the programs (and the files they import) are optimized before being resolved
+// (by default, they are not): the operations between literals are folded, and the code that can't be reached
+// is removed. The optimized programs produce the same output as the original ones.
+func WithOptimizations(enabled bool) Option {
+	return func(r *Runtime) {
+		r.optimize = enabled
+	}
+}
+
+// WithProfiler sets the profiler notified while the programs run (only the tree backend supports it).
+func WithProfiler(profiler Profiler) Option {
+	return func(r *Runtime) {
+		r.profiler = profiler
+	}
+}
+
+// NewRuntime is a constructor for a Runtime.
+// By default, the output of the programs is discarded and the input is empty.
+func NewRuntime(opts ...Option) *Runtime {
+	r := &Runtime{
+		backend: BackendTree,
+		stdout:  io.Discard,
+		stderr:  io.Discard,
+		stdin:   bytes.NewReader(nil),
+		limits:  DefaultLimits(),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.backend == BackendVM {
+		r.vm = vm.New(r.stdout)
+		r.vm.SetStdin(r.stdin)
+		r.vm.SetLimits(r.limits)
+		r.vm.SetBaseDir(r.baseDir)
+		if r.mainFile != "" {
+			r.vm.SetMainFile(r.mainFile)
+		}
+		r.vm.SetOptimizations(r.optimize)
+		r.resolver = interpreter.NewResolver(nil)
+		return r
+	}
+
+	r.interpreter = interpreter.NewInterpreter(r.stdout)
+	r.interpreter.SetStdin(r.stdin)
+	r.interpreter.SetLimits(r.limits)
+	r.interpreter.SetBaseDir(r.baseDir)
+	if r.mainFile != "" {
+		r.interpreter.SetMainFile(r.mainFile)
+	}
+	r.interpreter.SetOptimizations(r.optimize)
+	r.interpreter.SetProfiler(r.profiler)
+	r.resolver = interpreter.NewResolver(r.interpreter)
+
+	return r
+}
+
+// SetBaseDir sets the directory used to resolve the relative imports of the programs run from now on (see WithBaseDir).
+func (r *Runtime) SetBaseDir(dir string) {
+	r.baseDir = dir
+	if r.backend == BackendVM {
+		r.vm.SetBaseDir(dir)
+		return
+	}
+	r.interpreter.SetBaseDir(dir)
+}
+
+// Backend returns the engine used to run the programs.
+func (r *Runtime) Backend() Backend {
+	return r.backend
+}
+
+// Stdout returns the writer used by the programs to print.
+func (r *Runtime) Stdout() io.Writer {
+	return r.stdout
+}
+
+// Stderr returns the writer used to report errors.
+func (r *Runtime) Stderr() io.Writer {
+	return r.stderr
+}
+
+// Compile scans, parses, optimizes (see WithOptimizations) and resolves the source code, returning a Program
+// ready to be run.
+func (r *Runtime) Compile(source string) (*Program, error) {
+	s := scanner.NewScanner(bytes.Runes([]byte(source)))
+	tokens, err := s.Scan()
+	if err != nil {
+		return nil, fmt.Errorf("failed on the lexer layer: %w", withExcerpt(err, source))
+	}
+
+	p := parser.NewParser(tokens)
+	statements, err := p.Parse()
+	if err != nil {
+		return nil, withExcerpt(err, source)
+	}
+
+	if r.optimize {
+		o := optimizer.NewOptimizer()
+		o.SetMaxStringLength(r.limits.MaxStringLength)
+		statements, err = o.Optimize(statements)
+		if err != nil {
+			return nil, fmt.Errorf("failed optimizing the statements: %w", err)
+		}
+	}
+
+	err = r.resolver.Resolve(statements)
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving the statements: %w", withExcerpt(err, source))
+	}
+
+	program := &Program{
+		runtime:    r,
+		source:     source,
+		tokens:     tokens,
+		statements: statements,
+	}
+
+	if r.backend == BackendVM {
+		program.bytecode, err = compiler.Compile(statements)
+		if err != nil {
+			return nil, fmt.Errorf("failed compiling the statements: %w", err)
+		}
+	}
+
+	return program, nil
+}
+
+// Load loads a program from its binary format (see Program.MarshalBinary), so it can be run without scanning,
+// parsing and compiling its source code again.
+// The loaded programs can only be run by the vm backend, and they don't have source code, tokens nor statements.
+func (r *Runtime) Load(data []byte) (*Program, error) {
+	if r.backend != BackendVM {
+		return nil, fmt.Errorf("the compiled programs can only be run by the %q backend", BackendVM)
+	}
+
+	program, err := bytecode.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading the compiled program: %w", err)
+	}
+
+	return &Program{
+		runtime:  r,
+		bytecode: program,
+	}, nil
+}
+
+// Run runs a program previously compiled by this runtime.
+// The execution stops with ErrCanceled or ErrDeadlineExceeded when the context is done.
+func (r *Runtime) Run(ctx context.Context, program *Program) error {
+	if program.runtime != r {
+		return fmt.Errorf("the program was compiled by a different runtime")
+	}
+
+	if r.backend == BackendVM {
+		_, err := r.vm.Run(ctx, program.bytecode)
+		return withExcerpt(err, program.source)
+	}
+
+	return withExcerpt(r.interpreter.InterpretContext(ctx, program.statements), program.source)
+}
+
+// Eval runs a program previously compiled by this runtime, like Run does, and returns the value
+// of its last statement when it is an expression statement (eg: "1 + 2;"), or nil otherwise.
+func (r *Runtime) Eval(ctx context.Context, program *Program) (interface{}, error) {
+	if program.runtime != r {
+		return nil, fmt.Errorf("the program was compiled by a different runtime")
+	}
+
+	value, err := r.eval(ctx, program)
+	return value, withExcerpt(err, program.source)
+}
+
+// eval runs a program, and returns the value of its last statement (see Eval).
+func (r *Runtime) eval(ctx context.Context, program *Program) (interface{}, error) {
+	if r.backend == BackendVM {
+		// the compiled program already returns the value of its last expression statement
+		return r.vm.Run(ctx, program.bytecode)
+	}
+
+	statements := program.statements
+	if len(statements) == 0 {
+		return nil, nil
+	}
+
+	last, isExpression := statements[len(statements)-1].(*ast.ExpressionStatement)
+	if !isExpression {
+		return nil, r.interpreter.InterpretContext(ctx, statements)
+	}
+
+	err := r.interpreter.InterpretContext(ctx, statements[:len(statements)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	return r.interpreter.EvaluateContext(ctx, last.Expression)
+}
+
+// withExcerpt adds the excerpt of the source code where an error was found (when the error points to it).
+func withExcerpt(err error, source string) error {
+	switch e := err.(type) {
+	case *scanner.LexingErr:
+		return e.WithExcerpts(source)
+	case *parser.ParsingErr:
+		return e.WithExcerpts(source)
+	case interpreter.ResolverErr:
+		return e.WithExcerpt(source)
+	case interr.RuntimeError:
+		return e.WithExcerpt(source)
+	}
+	return err
+}
+
+// Globals returns the values defined in the global environment of the runtime (including the native functions).
+func (r *Runtime) Globals() map[string]interface{} {
+	if r.backend == BackendVM {
+		return r.vm.Globals()
+	}
+	return r.interpreter.Globals()
+}
+
+// Program is the result of compiling source code.
+type Program struct {
+	runtime    *Runtime
+	source     string
+	tokens     []*token.Token
+	statements []ast.Statement
+	bytecode   *bytecode.Program // compiled program (only for the vm backend)
+}
+
+// Source returns the source code of the program.
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Tokens returns the tokens scanned from the source code.
+func (p *Program) Tokens() []*token.Token {
+	return p.tokens
+}
+
+// Statements returns the AST of the program (after being optimized, when the optimizations are enabled).
+func (p *Program) Statements() []ast.Statement {
+	return p.statements
+}
+
+// Dump returns a human readable rendering of the AST of the program, as S-expressions (eg: (print (+ a 1))).
+// It's useful to inspect the result of the optimizations.
+func (p *Program) Dump() string {
+	return ast.Dump(p.statements)
+}
+
+// MarshalBinary serializes the bytecode of the program into a versioned binary format, that can be loaded
+// with Runtime.Load (the program is compiled into bytecode if the runtime doesn't use the vm backend).
+func (p *Program) MarshalBinary() ([]byte, error) {
+	program := p.bytecode
+	if program == nil {
+		var err error
+		program, err = compiler.Compile(p.statements)
+		if err != nil {
+			return nil, fmt.Errorf("failed compiling the statements: %w", err)
+		}
+	}
+
+	return bytecode.Encode(program)
+}
+
+// Disassemble returns a human readable listing of the bytecode of the program (only for the vm backend).
+func (p *Program) Disassemble() string {
+	if p.bytecode == nil {
+		return ""
+	}
+	return bytecode.Disassemble(p.bytecode)
+}
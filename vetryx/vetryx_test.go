@@ -0,0 +1,392 @@
+package vetryx_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	interr "github.com/avazquezcode/govetryx/internal/domain/error"
+	"github.com/avazquezcode/govetryx/vetryx"
+	"github.com/stretchr/testify/assert"
+)
+
+// backends are the backends where the programs of the tests run.
+var backends = []vetryx.Backend{vetryx.BackendTree, vetryx.BackendVM}
+
+func TestRuntime(t *testing.T) {
+	tests := map[string]struct {
+		src            string
+		stdin          string
+		expectedStdout string
+		expectedErr    bool
+	}{
+		"simple program": {
+			src:            "dec a = 1; print a + 1;",
+			expectedStdout: "2\n",
+		},
+		"program reading from stdin": {
+			src:            "print input(); print input(); print input();",
+			stdin:          "hello\nworld\n",
+			expectedStdout: "hello\nworld\nnull\n",
+		},
+		"scanning error": {
+			src:         "dec a = 1.;",
+			expectedErr: true,
+		},
+		"parsing error": {
+			src:         "dec = 1;",
+			expectedErr: true,
+		},
+		"resolving error": {
+			src:         "break;",
+			expectedErr: true,
+		},
+		"runtime error": {
+			src:         "print 1 / 0;",
+			expectedErr: true,
+		},
+	}
+
+	for desc, test := range tests {
+		for _, backend := range backends {
+			t.Run(desc+"/"+string(backend), func(t *testing.T) {
+				var stdout bytes.Buffer
+				runtime := vetryx.NewRuntime(
+					vetryx.WithStdout(&stdout),
+					vetryx.WithStdin(strings.NewReader(test.stdin)),
+					vetryx.WithBackend(backend),
+				)
+
+				program, err := runtime.Compile(test.src)
+				if err == nil {
+					err = runtime.Run(context.Background(), program)
+				}
+
+				assert.Equal(t, test.expectedErr, err != nil)
+				assert.Equal(t, test.expectedStdout, stdout.String())
+			})
+		}
+	}
+}
+
+func TestRuntimeErrorsShowTheExcerpt(t *testing.T) {
+	tests := map[string]struct {
+		src         string
+		expectedErr string
+	}{
+		"parsing error": {
+			src:         "dec a = 1;\ndec = 2;",
+			expectedErr: "error #1: expected a valid variable name: unexpected token at line 2, column 5 \n2 | dec = 2;\n  |     ^\n",
+		},
+		"resolving error": {
+			src:         "fn f() {\n  break;\n}",
+			expectedErr: "failed resolving the statements: cannot execute a break statement outside a loop (at line 2, column 3)\n2 |   break;\n  |   ^^^^^^",
+		},
+		"runtime error": {
+			src:         "dec a = \"ñ\";\nprint 1 + (a + 1);",
+			expectedErr: "runtime error occurred at line 2: type is invalid\n2 | print 1 + (a + 1);\n  |            ^^^^^",
+		},
+		"runtime error inside a function": {
+			src:         "fn half(n) {\n  return n / (n - n);\n}\nprint half(1);",
+			expectedErr: "runtime error occurred at line 2: division per zero\n2 |   return n / (n - n);\n  |          ^^^^^^^^^^^\nstack trace:\n  at half (called at line 4)",
+		},
+		"runtime error in a multi-line expression": {
+			src:         "print 1 +\n  null;",
+			expectedErr: "runtime error occurred at line 1: type is invalid\n1 | print 1 +\n  |       ^^^",
+		},
+	}
+
+	for desc, test := range tests {
+		for _, backend := range backends {
+			t.Run(desc+"/"+string(backend), func(t *testing.T) {
+				runtime := vetryx.NewRuntime(vetryx.WithBackend(backend))
+				program, err := runtime.Compile(test.src)
+				if err == nil {
+					err = runtime.Run(context.Background(), program)
+				}
+
+				assert.EqualError(t, err, test.expectedErr)
+			})
+		}
+	}
+}
+
+func TestRuntimeErrorsShowTheStackTrace(t *testing.T) {
+	tests := map[string]struct {
+		src           string
+		expectedTrace []interr.Frame
+		expectedErr   string
+	}{
+		"nested calls": {
+			src:           "fn inner(n) {\n  return n / 0;\n}\nfn outer(n) {\n  return inner(n);\n}\nouter(1);",
+			expectedTrace: []interr.Frame{{Function: "inner", Line: 5}, {Function: "outer", Line: 7}},
+			expectedErr:   "runtime error occurred at line 2: division per zero\n2 |   return n / 0;\n  |          ^^^^^\nstack trace:\n  at inner (called at line 5)\n  at outer (called at line 7)",
+		},
+		"classes, methods and anonymous functions": {
+			src:           "class A {\n  init() { this.b(); }\n  b() { return (() => -null)(); }\n}\nA();",
+			expectedTrace: []interr.Frame{{Function: "anonymous", Line: 3}, {Function: "b", Line: 2}, {Function: "A", Line: 5}},
+		},
+		"the error goes through a finally": {
+			src:           "fn a() {\n  try { throw 1; } finally { print 2; }\n}\nfn b() { a(); }\nb();",
+			expectedTrace: []interr.Frame{{Function: "a", Line: 4}, {Function: "b", Line: 5}},
+		},
+		"the error is rethrown": {
+			src:           "fn a() { throw 1; }\nfn b() {\n  try { a(); } catch (e) { throw e; }\n}\nb();",
+			expectedTrace: []interr.Frame{{Function: "b", Line: 5}},
+		},
+		"recursion": {
+			src:         "fn a(n) {\n  if n == 0 { return -null; }\n  return a(n - 1);\n}\na(30);",
+			expectedErr: "runtime error occurred at line 2: not a number\n2 |   if n == 0 { return -null; }\n  |                      ^^^^^\nstack trace:\n  at a (called at line 3)\n  ... repeated 29 more times\n  at a (called at line 5)",
+		},
+		"error outside the functions": {
+			src:         "fn a() { return 1; }\nprint a() / 0;",
+			expectedErr: "runtime error occurred at line 2: division per zero\n2 | print a() / 0;\n  |       ^^^^^^^",
+		},
+	}
+
+	for desc, test := range tests {
+		for _, backend := range backends {
+			t.Run(desc+"/"+string(backend), func(t *testing.T) {
+				runtime := vetryx.NewRuntime(vetryx.WithBackend(backend), vetryx.WithStdout(io.Discard))
+				program, err := runtime.Compile(test.src)
+				assert.NoError(t, err)
+
+				err = runtime.Run(context.Background(), program)
+				var runtimeErr interr.RuntimeError
+				assert.ErrorAs(t, err, &runtimeErr)
+				if test.expectedTrace != nil {
+					assert.Equal(t, test.expectedTrace, runtimeErr.Trace)
+				}
+				if test.expectedErr != "" {
+					assert.EqualError(t, err, test.expectedErr)
+				}
+			})
+		}
+	}
+}
+
+func TestRuntimeErrorsInImportedFilesPointToTheFile(t *testing.T) {
+	dir := t.TempDir()
+	modules := map[string]string{
+		"mod.vx":     "fn f() {\n  return 1 / 0;\n}\nfn call(g) { return g(); }",
+		"syntax.vx":  "dec x = 1;\n\ndec y = (1 + ;",
+		"runtime.vx": "dec x = 1;\nprint -null;",
+		"nested.vx":  "\nimport \"runtime.vx\" as r;",
+	}
+	for name, src := range modules {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600)
+		assert.NoError(t, err)
+	}
+
+	tests := map[string]struct {
+		src         string
+		expectedErr string
+	}{
+		"the error happens in a function of the imported file": {
+			src:         "dec a = 1;\nimport \"mod.vx\" as m;\nprint m.f();",
+			expectedErr: "runtime error occurred at line 2 of mod.vx: division per zero\nstack trace:\n  at f (called at line 3)",
+		},
+		"the imported file calls a function of the program": {
+			src:         "import \"mod.vx\" as m;\nfn g() {\n  return -null;\n}\nm.call(g);",
+			expectedErr: "runtime error occurred at line 3: not a number\n3 |   return -null;\n  |          ^^^^^\nstack trace:\n  at g (called at line 4 of mod.vx)\n  at call (called at line 5)",
+		},
+		"the imported file can't be parsed": {
+			src:         "import \"syntax.vx\" as s;",
+			expectedErr: "runtime error occurred in syntax.vx: failed importing \"syntax.vx\": error #1: expected an expression \n3 | dec y = (1 + ;\n  |              ^\nstack trace:\n  at <module syntax.vx> (called at line 1)",
+		},
+		"the imported file fails while it's run": {
+			src:         "dec a = 1;\nimport \"runtime.vx\" as r;",
+			expectedErr: "runtime error occurred at line 2 of runtime.vx: failed importing \"runtime.vx\": not a number\n2 | print -null;\n  |       ^^^^^\nstack trace:\n  at <module runtime.vx> (called at line 2)",
+		},
+		"the file imported by the imported file fails": {
+			src:         "import \"nested.vx\" as n;",
+			expectedErr: "runtime error occurred at line 2 of runtime.vx: failed importing \"nested.vx\": failed importing \"runtime.vx\": not a number\n2 | print -null;\n  |       ^^^^^\nstack trace:\n  at <module runtime.vx> (called at line 2 of nested.vx)\n  at <module nested.vx> (called at line 1)",
+		},
+	}
+
+	for desc, test := range tests {
+		for _, backend := range backends {
+			t.Run(desc+"/"+string(backend), func(t *testing.T) {
+				runtime := vetryx.NewRuntime(vetryx.WithBackend(backend), vetryx.WithBaseDir(dir), vetryx.WithStdout(io.Discard))
+				program, err := runtime.Compile(test.src)
+				assert.NoError(t, err)
+
+				err = runtime.Run(context.Background(), program)
+				assert.EqualError(t, err, test.expectedErr)
+			})
+		}
+	}
+}
+
+func TestRuntimeReusesProgram(t *testing.T) {
+	var stdout bytes.Buffer
+	runtime := vetryx.NewRuntime(vetryx.WithStdout(&stdout))
+
+	setup, err := runtime.Compile("dec counter = 0;")
+	assert.NoError(t, err)
+	assert.NoError(t, runtime.Run(context.Background(), setup))
+
+	program, err := runtime.Compile("counter = counter + 1; print counter;")
+	assert.NoError(t, err)
+	assert.Equal(t, "counter = counter + 1; print counter;", program.Source())
+	assert.Len(t, program.Statements(), 2)
+
+	assert.NoError(t, runtime.Run(context.Background(), program))
+	assert.NoError(t, runtime.Run(context.Background(), program))
+	assert.Equal(t, "1\n2\n", stdout.String())
+}
+
+func TestProgramDump(t *testing.T) {
+	src := "dec a = 1 + 2; if false { print a; } else { print a * 2; }"
+
+	program, err := vetryx.NewRuntime().Compile(src)
+	assert.NoError(t, err)
+	assert.Equal(t, "(dec a (+ 1 2))\n(if false\n  (block\n    (print a))\n  (block\n    (print (* a 2))))\n", program.Dump())
+
+	program, err = vetryx.NewRuntime(vetryx.WithOptimizations(true)).Compile(src)
+	assert.NoError(t, err)
+	assert.Equal(t, "(dec a 3)\n(block\n  (print (* a 2)))\n", program.Dump())
+}
+
+func TestRuntimeRejectsForeignProgram(t *testing.T) {
+	program, err := vetryx.NewRuntime().Compile("print 1;")
+	assert.NoError(t, err)
+
+	err = vetryx.NewRuntime().Run(context.Background(), program)
+	assert.Error(t, err)
+}
+
+func TestRuntimeRunWithCanceledContext(t *testing.T) {
+	runtime := vetryx.NewRuntime()
+	program, err := runtime.Compile("print 1;")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = runtime.Run(ctx, program)
+	assert.ErrorIs(t, err, vetryx.ErrCanceled)
+}
+
+func TestRuntimeRunWithTimeout(t *testing.T) {
+	tests := map[string]struct {
+		src          string
+		expectedLine int
+	}{
+		"infinite loop": {
+			src:          "dec a = 0;\nwhile true {\n a = a + 1;\n}",
+			expectedLine: 2,
+		},
+		"long sleep": {
+			src:          "print 1;\nsleep(100000);",
+			expectedLine: 2,
+		},
+		"sleep inside a function": {
+			src:          "fn a() {\n sleep(100000);\n}\na();",
+			expectedLine: 2,
+		},
+	}
+
+	for desc, test := range tests {
+		for _, backend := range backends {
+			t.Run(desc+"/"+string(backend), func(t *testing.T) {
+				runtime := vetryx.NewRuntime(vetryx.WithBackend(backend))
+				program, err := runtime.Compile(test.src)
+				assert.NoError(t, err)
+
+				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+				defer cancel()
+
+				err = runtime.Run(ctx, program)
+				assert.ErrorIs(t, err, vetryx.ErrDeadlineExceeded)
+
+				var runtimeErr interr.RuntimeError
+				assert.ErrorAs(t, err, &runtimeErr)
+				assert.Equal(t, test.expectedLine, runtimeErr.Line)
+			})
+		}
+	}
+}
+
+func TestRuntimeRunWithLimits(t *testing.T) {
+	tests := map[string]struct {
+		src         string
+		limits      vetryx.Limits
+		optimize    bool
+		expectedErr error
+	}{
+		"infinite loop exceeds the max steps": {
+			src:         "while true {}",
+			limits:      vetryx.Limits{MaxSteps: 1000},
+			expectedErr: vetryx.ErrStepLimitExceeded,
+		},
+		"exceeded limits can't be caught": {
+			src:         "try { while true {} } catch (e) {}",
+			limits:      vetryx.Limits{MaxSteps: 1000},
+			expectedErr: vetryx.ErrStepLimitExceeded,
+		},
+		"loop within the max steps": {
+			src:    "dec a = 0; while a < 10 { a = a + 1; }",
+			limits: vetryx.Limits{MaxSteps: 1000},
+		},
+		"deep recursion exceeds the default max call depth": {
+			src:         "fn a(n) { return a(n + 1); } a(0);",
+			limits:      vetryx.DefaultLimits(),
+			expectedErr: vetryx.ErrCallDepthExceeded,
+		},
+		"recursion exceeds the max call depth": {
+			src:         "fn a(n) { if n == 0 { return 0; } return a(n - 1); } a(20);",
+			limits:      vetryx.Limits{MaxCallDepth: 10},
+			expectedErr: vetryx.ErrCallDepthExceeded,
+		},
+		"recursion within the max call depth": {
+			src:    "fn a(n) { if n == 0 { return 0; } return a(n - 1); } a(5);",
+			limits: vetryx.Limits{MaxCallDepth: 10},
+		},
+		"concatenation exceeds the max string length": {
+			src:         `dec s = "ab"; while true { s = s + s; }`,
+			limits:      vetryx.Limits{MaxStringLength: 1024},
+			expectedErr: vetryx.ErrStringTooLong,
+		},
+		"concatenation of literals exceeds the max string length (when it's optimized)": {
+			src:         `print "abc" + "def";`,
+			limits:      vetryx.Limits{MaxStringLength: 5},
+			optimize:    true,
+			expectedErr: vetryx.ErrStringTooLong,
+		},
+		"recursion exceeds the max bindings": {
+			src:         "fn a(n) { dec b = n; return a(n + 1); } a(0);",
+			limits:      vetryx.Limits{MaxBindings: 100},
+			expectedErr: vetryx.ErrBindingsLimitExceeded,
+		},
+		"bindings of finished blocks are released": {
+			src:    "dec i = 0; while i < 100 { dec a = i; dec b = i; i = i + 1; }",
+			limits: vetryx.Limits{MaxBindings: 10},
+		},
+	}
+
+	for desc, test := range tests {
+		for _, backend := range backends {
+			t.Run(desc+"/"+string(backend), func(t *testing.T) {
+				runtime := vetryx.NewRuntime(vetryx.WithLimits(test.limits), vetryx.WithBackend(backend), vetryx.WithOptimizations(test.optimize), vetryx.WithStdout(io.Discard))
+				program, err := runtime.Compile(test.src)
+				assert.NoError(t, err)
+
+				err = runtime.Run(context.Background(), program)
+				if test.expectedErr == nil {
+					assert.NoError(t, err)
+					return
+				}
+
+				assert.ErrorIs(t, err, test.expectedErr)
+				var runtimeErr interr.RuntimeError
+				assert.ErrorAs(t, err, &runtimeErr)
+			})
+		}
+	}
+}
@@ -6,7 +6,7 @@ function initVetryxLanguage() {
     monaco.languages.setMonarchTokensProvider('vetryx', {
         // Keywords
         keywords: [
-            'fn', 'return', 'if', 'else', 'while', 'dec', 'print', 'break', 'continue', 'true', 'false', 'null'
+            'fn', 'return', 'if', 'else', 'while', 'for', 'in', 'dec', 'print', 'break', 'continue', 'true', 'false', 'null', 'class', 'this', 'super', 'import', 'as', 'throw', 'try', 'catch', 'finally'
         ],
 
         // Built-in functions
@@ -30,7 +30,7 @@ function initVetryxLanguage() {
                 [/#.*$/, 'comment'],
 
                 // Keywords
-                [/\b(fn|return|if|else|while|dec|print|break|continue|true|false|null)\b/, 'keyword'],
+                [/\b(fn|return|if|else|while|for|in|dec|print|break|continue|true|false|null|class|this|super|import|as|throw|try|catch|finally)\b/, 'keyword'],
 
                 // Built-in functions
                 [/\b(min|max|sleep|clock)\b/, 'function'],